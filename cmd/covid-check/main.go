@@ -0,0 +1,8 @@
+// Command covid-check finds COVID-19 exposure sites matching a query.
+package main
+
+import "github.com/fubarhouse/covid-check/v2/internal/cli"
+
+func main() {
+	cli.Main()
+}
@@ -0,0 +1,72 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// AliasMap is the name each variant of a place is known by, keyed by the
+// variant normalized like the fields of a Hash, such as "civic" for "City".
+type AliasMap map[string]string
+
+// DefaultAliases is the AliasMap of the suburbs and locations treated as
+// the same place by filters and deduplication, which can be replaced to
+// change the aliases everywhere.
+var DefaultAliases = AliasMap{
+	"civic":                 "City",
+	"canberra city":         "City",
+	"city centre":           "City",
+	"belconnen westfield":   "Westfield Belconnen",
+	"woden westfield":       "Westfield Woden",
+	"tuggeranong westfield": "Westfield Tuggeranong",
+	"the canberra centre":   "Canberra Centre",
+}
+
+// AliasesPath will return the path of the aliases loaded without -aliases,
+// which is $XDG_CONFIG_HOME/covid-check/aliases.json on Linux.
+func AliasesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "covid-check", "aliases.json"), nil
+}
+
+// LoadAliases will read a JSON object of the names of places keyed by their
+// variants, such as {"gunghalin": "Gungahlin"}, which are added to the
+// DefaultAliases, replacing those of the same variants.
+func LoadAliases(path string) (AliasMap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := map[string]string{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse aliases: %s", err.Error())
+	}
+
+	aliases := AliasMap{}
+	for k, v := range DefaultAliases {
+		aliases[k] = v
+	}
+	for k, v := range file {
+		if normalizeField(k) == "" || normalizeField(v) == "" {
+			return nil, fmt.Errorf("could not parse aliases: '%s' and '%s' can't be empty", k, v)
+		}
+		aliases[normalizeField(k)] = v
+	}
+	return aliases, nil
+}
+
+// Canonical will return the name the place is known by, or the name itself
+// when it has no alias.
+func (a AliasMap) Canonical(name string) string {
+	if canonical, ok := a[normalizeField(name)]; ok {
+		return canonical
+	}
+	return name
+}
@@ -0,0 +1,102 @@
+package covidcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestAliases will check the variants of a place are filtered and
+// deduplicated as the same place, and aliases are loaded from files.
+func TestAliases(t *testing.T) {
+	t.Run("Naming places", func(t *testing.T) {
+		for name, canonical := range map[string]string{
+			"Civic":               "City",
+			"  CANBERRA   city ":  "City",
+			"Belconnen Westfield": "Westfield Belconnen",
+			"Westfield Belconnen": "Westfield Belconnen",
+			"Kaleen":              "Kaleen",
+		} {
+			if c := DefaultAliases.Canonical(name); c != canonical {
+				t.Errorf("expected %q to be known as %q, got %q", name, canonical, c)
+			}
+		}
+	})
+
+	t.Run("Deduplicating variants", func(t *testing.T) {
+		date := time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+		entries := Entries{Items: []Entry{
+			{ExposureLocation: "Westfield Belconnen", Suburb: "Belconnen", Date: &date},
+			{ExposureLocation: "Belconnen Westfield", Suburb: "Belconnen", Date: &date},
+			{ExposureLocation: "Canberra Centre", Suburb: "Civic", Date: &date},
+			{ExposureLocation: "Canberra Centre", Suburb: "City", Date: &date},
+			{ExposureLocation: "Canberra Centre", Suburb: "Braddon", Date: &date},
+		}}
+		if entries.Items[2].Hash() == entries.Items[3].Hash() {
+			t.Error("expected the hashes of variants to be of their own names")
+		}
+		if removed := entries.Dedupe(); removed != 2 || entries.Len() != 3 {
+			t.Errorf("expected 2 variants to be removed, got %d leaving %d", removed, entries.Len())
+		}
+	})
+
+	t.Run("Filtering variants", func(t *testing.T) {
+		entries := Entries{Items: []Entry{
+			{ExposureLocation: "Belconnen Westfield", Suburb: "Belconnen"},
+			{ExposureLocation: "Canberra Centre", Suburb: "Canberra City"},
+			{ExposureLocation: "Glebe Park", Suburb: "Civic"},
+			{ExposureLocation: "ALDI Kaleen", Suburb: "Kaleen"},
+		}}
+		for _, test := range []struct {
+			Filter  Filter
+			Matches int
+		}{
+			{Filter{Suburb: "city"}, 2},
+			{Filter{Suburb: "civic"}, 2},
+			{Filter{Suburb: "!civic"}, 2},
+			{Filter{ExposureLocation: "westfield belconnen"}, 1},
+			{Filter{ExposureLocation: "westfeild belconnen", Fuzzy: true}, 1},
+			{Filter{ExcludeSuburb: []string{"city"}}, 2},
+			{Filter{Suburb: "^city$", Regex: true}, 2},
+		} {
+			covid := &Client{RawResults: entries}
+			covid.Query(&test.Filter, QueryParams{})
+			if covid.FilteredResults.Len() != test.Matches {
+				t.Errorf("expected %d matches of %+v, got %d", test.Matches, test.Filter, covid.FilteredResults.Len())
+			}
+		}
+	})
+
+	t.Run("Loading aliases", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "covid-check-aliases")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "aliases.json")
+
+		ioutil.WriteFile(path, []byte(`{"Gunghalin": "Gungahlin", "Civic": "Canberra City"}`), 0644)
+		aliases, err := LoadAliases(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if aliases.Canonical("gunghalin") != "Gungahlin" || aliases.Canonical("civic") != "Canberra City" || aliases.Canonical("the canberra centre") != "Canberra Centre" {
+			t.Errorf("expected the aliases to be added to the defaults, got %v", aliases)
+		}
+		if DefaultAliases.Canonical("civic") != "City" {
+			t.Error("expected the defaults to be unchanged")
+		}
+
+		for _, data := range []string{`{"Civic": ""}`, `["Civic"]`} {
+			ioutil.WriteFile(path, []byte(data), 0644)
+			if _, err := LoadAliases(path); err == nil {
+				t.Errorf("expected %s to be reported", data)
+			}
+		}
+		if _, err := LoadAliases(filepath.Join(dir, "missing.json")); err == nil {
+			t.Error("expected a missing file to be reported")
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package covidcheck
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GitArchiver will write canonical snapshots into a git repository and
+// commit them, so a public history of the exposure lists can be kept.
+type GitArchiver struct {
+	// Repo is the path to an existing git repository.
+	Repo string
+	// File is the path of the snapshot relative to Repo, whose directories
+	// are created when missing. The format of the snapshot is json when
+	// the file has a .json extension, otherwise csv.
+	File string
+	// Push will push the repository to its default remote after committing.
+	Push bool
+	// Now returns the time used in the commit message.
+	Now func() time.Time
+}
+
+// Archive will write the FilteredResults as a canonical snapshot and commit
+// it to the repository. It returns false without committing anything when
+// the snapshot is unchanged from the previous commit.
+func (a *GitArchiver) Archive(x *Client) (bool, error) {
+	format := "csv"
+	if strings.EqualFold(filepath.Ext(a.File), ".json") {
+		format = "json"
+	}
+
+	var buf bytes.Buffer
+	if err := x.Export(&buf, format, true); err != nil {
+		return false, err
+	}
+	path := filepath.Join(a.Repo, a.File)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return false, err
+	}
+
+	if _, err := a.git("add", "--", a.File); err != nil {
+		return false, err
+	}
+	if _, err := a.git("diff", "--cached", "--quiet", "--", a.File); err == nil {
+		return false, nil
+	}
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	message := fmt.Sprintf("Exposure site snapshot %s", now().Format(time.RFC3339))
+	if _, err := a.git("commit", "-m", message, "--", a.File); err != nil {
+		return false, err
+	}
+
+	if a.Push {
+		if _, err := a.git("push"); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+// git will run a git command in the repository and return its output.
+func (a *GitArchiver) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", a.Repo}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("git %s failed: %s %s", args[0], err.Error(), strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
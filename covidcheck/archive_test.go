@@ -0,0 +1,98 @@
+package covidcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGitArchiver will archive snapshots into a temporary git repository and
+// check commits are only made when the snapshot changes.
+func TestGitArchiver(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo, err := ioutil.TempDir("", "covid-check-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "covid-check"},
+		{"config", "user.email", "covid-check@localhost"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+	}
+
+	date, _ := time.Parse("02/01/2006", "04/10/2021")
+	covid := &Client{FilteredResults: Entries{Items: []Entry{
+		{ExposureLocation: "ALDI Belconnen", Suburb: "Belconnen", State: "ACT", Date: &date, ArrivalTime: &time.Time{}, DepartureTime: &time.Time{}},
+	}}}
+	archiver := &GitArchiver{
+		Repo: repo,
+		File: "snapshot.json",
+		Now:  func() time.Time { return date },
+	}
+
+	t.Run("Committing the first snapshot", func(t *testing.T) {
+		committed, err := archiver.Archive(covid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !committed {
+			t.Fail()
+		}
+		log, _ := archiver.git("log", "--format=%s")
+		if strings.TrimSpace(log) != "Exposure site snapshot 2021-10-04T00:00:00Z" {
+			t.Errorf("unexpected commit message %s", log)
+		}
+	})
+
+	t.Run("Skipping an unchanged snapshot", func(t *testing.T) {
+		committed, err := archiver.Archive(covid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if committed {
+			t.Fail()
+		}
+	})
+
+	t.Run("Committing a changed snapshot", func(t *testing.T) {
+		covid.FilteredResults.Items[0].Contact = "Close"
+		committed, err := archiver.Archive(covid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !committed {
+			t.Fail()
+		}
+		count, _ := archiver.git("rev-list", "--count", "HEAD")
+		if strings.TrimSpace(count) != "2" {
+			t.Errorf("expected 2 commits, got %s", count)
+		}
+	})
+
+	t.Run("Committing into a new directory", func(t *testing.T) {
+		nested := &GitArchiver{Repo: repo, File: "snapshots/act/snapshot.csv", Now: archiver.Now}
+		committed, err := nested.Archive(covid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !committed {
+			t.Fail()
+		}
+		if _, err := os.Stat(filepath.Join(repo, "snapshots", "act", "snapshot.csv")); err != nil {
+			t.Errorf("expected the snapshot to be written, got %s", err)
+		}
+	})
+}
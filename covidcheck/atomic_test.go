@@ -0,0 +1,50 @@
+package covidcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteFileAtomic will replace a file and check no temporary files are
+// left behind, including when the write fails.
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-atomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "results.json")
+
+	t.Run("Replacing a file", func(t *testing.T) {
+		if err := WriteFileAtomic(path, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteFileAtomic(path, []byte("new"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil || string(data) != "new" {
+			t.Errorf("expected the file to be replaced, got %q %v", data, err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("expected the permissions to be set, got %v %v", info.Mode(), err)
+		}
+		if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+			t.Errorf("expected only the written file, got %d files", len(files))
+		}
+	})
+
+	t.Run("Failing to write", func(t *testing.T) {
+		if err := WriteFileAtomic(filepath.Join(dir, "missing", "results.json"), []byte("new"), 0644); err == nil {
+			t.Error("expected an error writing to a missing directory")
+		}
+		if err := WriteFileAtomic(dir, []byte("new"), 0644); err == nil {
+			t.Error("expected an error replacing a directory")
+		}
+		if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+			t.Errorf("expected the temporary file to be removed, got %d files", len(files))
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package covidcheck
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is an on-disk store of downloaded data, which is reused while it is
+// fresh so repeated queries don't hammer the official endpoints.
+type Cache struct {
+	// Dir is the directory the cached data is stored in.
+	Dir string
+	// TTL is how long cached data is considered fresh for.
+	TTL time.Duration
+	// Now returns the current time, used to check freshness.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+// CacheValidators identify the version of cached data, as given by the
+// ETag and Last-Modified headers of the response it was downloaded from, so
+// it can be requested again only if it has changed.
+type CacheValidators struct {
+	// URL is where the data was downloaded from.
+	URL string `json:"url"`
+	// ETag is the entity tag of the data, sent back as If-None-Match.
+	ETag string `json:"etag,omitempty"`
+	// LastModified is when the data last changed, sent back as
+	// If-Modified-Since.
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// CacheStats are the number of lookups of a Cache which found fresh data,
+// and which didn't.
+type CacheStats struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// HitRate will return the fraction of lookups which found fresh data, which
+// is zero before any lookups.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Stats will return the lookups of the Cache so far. A nil Cache has none.
+func (c *Cache) Stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses}
+}
+
+// record will count a lookup of the Cache.
+func (c *Cache) record(hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// NewCache will return a Cache stored under the user cache directory, which
+// is $XDG_CACHE_HOME/covid-check on Linux.
+func NewCache(ttl time.Duration) (*Cache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: filepath.Join(dir, "covid-check"), TTL: ttl}, nil
+}
+
+// path will return the file path for the data cached under the key.
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, fmt.Sprintf("%x.cache", sum[:8]))
+}
+
+// validatorsPath will return the file path for the CacheValidators of the
+// data cached under the key.
+func (c *Cache) validatorsPath(key string) string {
+	return strings.TrimSuffix(c.path(key), ".cache") + ".meta"
+}
+
+// now will return the current time of the Cache.
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// Get will return the data cached under the key, and whether it was found
+// and is still fresh. A nil Cache is always empty.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, ok := c.get(key)
+	c.record(ok)
+	return data, ok
+}
+
+// get will return the data cached under the key, and whether it was found
+// and is still fresh.
+func (c *Cache) get(key string) ([]byte, bool) {
+	info, err := os.Stat(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	if c.now().Sub(info.ModTime()) > c.TTL {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Stale will return the data cached under the key however old it is, with
+// its CacheValidators when it was stored with them, and whether it was
+// found. A nil Cache is always empty.
+func (c *Cache) Stale(key string) ([]byte, CacheValidators, bool) {
+	if c == nil {
+		return nil, CacheValidators{}, false
+	}
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, CacheValidators{}, false
+	}
+	v := CacheValidators{}
+	if meta, err := ioutil.ReadFile(c.validatorsPath(key)); err == nil {
+		json.Unmarshal(meta, &v)
+	}
+	return data, v, true
+}
+
+// Touch will make the data cached under the key fresh again, as it was
+// found to be unchanged. Nothing is touched by a nil Cache.
+func (c *Cache) Touch(key string) error {
+	if c == nil {
+		return nil
+	}
+	now := c.now()
+	return os.Chtimes(c.path(key), now, now)
+}
+
+// PutValidated will store the data under the key like Put, along with the
+// CacheValidators of the response it was downloaded from.
+func (c *Cache) PutValidated(key string, data []byte, v CacheValidators) error {
+	if c == nil {
+		return nil
+	}
+	if err := c.Put(key, data); err != nil {
+		return err
+	}
+	if v.ETag == "" && v.LastModified == "" {
+		return nil
+	}
+	meta, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(c.validatorsPath(key), meta, 0644)
+}
+
+// Put will store the data under the key, replacing any previous data and
+// its CacheValidators. Nothing is stored by a nil Cache.
+func (c *Cache) Put(key string, data []byte) error {
+	if c == nil {
+		return nil
+	}
+	os.Remove(c.validatorsPath(key))
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(c.Dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
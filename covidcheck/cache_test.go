@@ -0,0 +1,223 @@
+package covidcheck
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestCache will store data in a temporary cache and check it is only
+// returned while it is fresh, and that sources reuse it.
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := &Cache{Dir: dir, TTL: time.Minute}
+
+	t.Run("Reading a missing key", func(t *testing.T) {
+		if _, ok := cache.Get("missing"); ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Reading a fresh key", func(t *testing.T) {
+		if err := cache.Put("key", []byte("data")); err != nil {
+			t.Fatal(err)
+		}
+		data, ok := cache.Get("key")
+		if !ok || string(data) != "data" {
+			t.Fail()
+		}
+	})
+
+	t.Run("Reading a stale key", func(t *testing.T) {
+		stale := &Cache{Dir: dir, TTL: time.Minute, Now: func() time.Time { return time.Now().Add(2 * time.Minute) }}
+		if _, ok := stale.Get("key"); ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Counting lookups", func(t *testing.T) {
+		stats := cache.Stats()
+		if stats.Hits != 1 || stats.Misses != 1 || stats.HitRate() != 0.5 {
+			t.Errorf("unexpected stats %+v", stats)
+		}
+		if (CacheStats{}).HitRate() != 0 {
+			t.Error("expected no hit rate before any lookups")
+		}
+	})
+
+	t.Run("Using a nil cache", func(t *testing.T) {
+		var none *Cache
+		if err := none.Put("key", []byte("data")); err != nil {
+			t.Fail()
+		}
+		if _, ok := none.Get("key"); ok || none.Stats().Hits != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("Reusing cached source data", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			fmt.Fprint(w, nswTestData)
+		}))
+		defer server.Close()
+
+		src, _ := NewSource("nsw", SourceOptions{Endpoint: server.URL, Cache: cache})
+		for i := 0; i < 3; i++ {
+			entries, err := src.Fetch()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries.Len() != 2 {
+				t.Fail()
+			}
+		}
+		if requests != 1 {
+			t.Errorf("expected 1 request, got %d", requests)
+		}
+	})
+
+	t.Run("Revalidating stale source data", func(t *testing.T) {
+		requests, unchanged := 0, 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				unchanged++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			fmt.Fprint(w, nswTestData)
+		}))
+		defer server.Close()
+
+		now := time.Now()
+		clock := &Cache{Dir: dir, TTL: time.Minute, Now: func() time.Time { return now }}
+		src, _ := NewSource("nsw", SourceOptions{Endpoint: server.URL, Cache: clock})
+		for i := 0; i < 3; i++ {
+			now = now.Add(2 * time.Minute)
+			entries, err := src.Fetch()
+			if err != nil || entries.Len() != 2 {
+				t.Fatalf("expected 2 entries, got %d and %v", entries.Len(), err)
+			}
+		}
+		if requests != 3 || unchanged != 2 {
+			t.Errorf("expected 2 of 3 requests to be unchanged, got %d of %d", unchanged, requests)
+		}
+		if _, ok := clock.Get("nsw " + server.URL); !ok {
+			t.Error("expected the unchanged copy to be fresh again")
+		}
+
+		clock.Put("nsw "+server.URL, []byte(nswTestData))
+		if _, v, _ := clock.Stale("nsw " + server.URL); v.ETag != "" {
+			t.Errorf("expected Put to forget the validators, got %+v", v)
+		}
+	})
+
+	t.Run("Falling back to stale source data", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests > 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprint(w, nswTestData)
+		}))
+		defer server.Close()
+
+		now := time.Now()
+		clock := &Cache{Dir: dir, TTL: time.Minute, Now: func() time.Time { return now }}
+		src, _ := NewSource("nsw", SourceOptions{Endpoint: server.URL + "/fallback", Cache: clock})
+		for i := 0; i < 2; i++ {
+			now = now.Add(2 * time.Minute)
+			entries, err := src.Fetch()
+			if err != nil || entries.Len() != 2 {
+				t.Fatalf("expected 2 entries, got %d and %v", entries.Len(), err)
+			}
+		}
+		if requests != 2 {
+			t.Errorf("expected the stale copy to be revalidated, got %d requests", requests)
+		}
+	})
+
+	t.Run("Revalidating the ACT file without the web page", func(t *testing.T) {
+		pages, files := 0, 0
+		mux := http.NewServeMux()
+		server := httptest.NewServer(mux)
+		defer server.Close()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			pages++
+			fmt.Fprintf(w, "<html><body><script>\nPapa.parse(\"%s/data.csv\", {download: true});\n</script></body></html>", server.URL)
+		})
+		mux.HandleFunc("/data.csv", func(w http.ResponseWriter, r *http.Request) {
+			files++
+			modified := "Mon, 04 Oct 2021 07:00:00 GMT"
+			w.Header().Set("Last-Modified", modified)
+			if r.Header.Get("If-Modified-Since") == modified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			fmt.Fprint(w, actTestCSV)
+		})
+
+		now := time.Now()
+		clock := &Cache{Dir: dir, TTL: time.Minute, Now: func() time.Time { return now }}
+		src, _ := NewSource("act", SourceOptions{Endpoint: server.URL, Cache: clock})
+		for i := 0; i < 2; i++ {
+			now = now.Add(2 * time.Minute)
+			entries, err := src.Fetch()
+			if err != nil || entries.Len() != 2 {
+				t.Fatalf("expected 2 entries, got %d and %v", entries.Len(), err)
+			}
+		}
+		if pages != 1 || files != 2 {
+			t.Errorf("expected 1 page and 2 file requests, got %d and %d", pages, files)
+		}
+	})
+
+	t.Run("Falling back to the stale ACT file", func(t *testing.T) {
+		pages, files := 0, 0
+		mux := http.NewServeMux()
+		server := httptest.NewServer(mux)
+		defer server.Close()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			pages++
+			fmt.Fprintf(w, "<html><body><script>\nPapa.parse(\"%s/data.csv\", {download: true});\n</script></body></html>", server.URL)
+		})
+		mux.HandleFunc("/data.csv", func(w http.ResponseWriter, r *http.Request) {
+			files++
+			if files > 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprint(w, actTestCSV)
+		})
+
+		now := time.Now()
+		clock := &Cache{Dir: dir, TTL: time.Minute, Now: func() time.Time { return now }}
+		src, _ := NewSource("act", SourceOptions{Endpoint: server.URL + "/fallback", Cache: clock})
+		for i := 0; i < 2; i++ {
+			now = now.Add(2 * time.Minute)
+			entries, err := src.Fetch()
+			if err != nil || entries.Len() != 2 {
+				t.Fatalf("expected 2 entries, got %d and %v", entries.Len(), err)
+			}
+		}
+		if pages != 1 || files != 2 {
+			t.Errorf("expected 1 page and 2 file requests, got %d and %d", pages, files)
+		}
+	})
+}
@@ -0,0 +1,430 @@
+// Package covidcheck will search and display data from official COVID-19
+// exposure location lists. It contains the scraping, parsing, filtering and
+// rendering logic used by the covid-check command, so other Go programs can
+// embed the same functionality.
+package covidcheck
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SampleEndpointURL is a reference to a mirror of an official data
+// file from official sources during the pandemic which will allow
+// this tool to be used against a source, and for tests to be run
+// against a predictable dataset.
+var SampleEndpointURL = "https://gist.githubusercontent.com/fubarhouse/a827e4db69590556a3bf795ab1f93c89/raw/b536c5d534734da3c7484d9e1db8fe7ba56d7af5/sample-dataset-covidcheck.md"
+
+// Client is a client for our API which contains all of the functionality
+// we need to put data into the system and display it to the user.
+type Client struct {
+	// DataEndPoint is the endpoint of the input CSV file to scrape and process
+	DataEndpoint string
+	// RawCSV is the raw CSV data represented as a string.
+	RawCSV string
+	// RawHTML is the raw HTML of the web page endpoint represented as a string
+	RawHTML string
+	// HTMLEndpoint is the URL the RawHTML was retrieved from, after any
+	// redirects, which relative links to the CSV file are resolved against.
+	HTMLEndpoint string
+	// RawResults is the unchanged, processed input from the CSV file.
+	RawResults Entries
+	// FilteredResults is the Entries object of all values matching input queries.
+	// If no input queries are provided, this objeect will match the length of
+	// RawResults.
+	FilteredResults Entries
+	// Filter is the last Filter which was queried against the results
+	// in order to filter the list of results to the end users preference.
+	Filter Filter
+}
+
+// GetHTML will retrieve the HTML endpoint and add it to the RawHTML field.
+func (x *Client) GetHTML(endpoint string) error {
+	resp, err := DefaultRetryPolicy.Get(endpoint)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("failed to fetch data: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	rawHTML, err := ioutil.ReadAll(newUTF8Reader(resp.Body))
+	if err != nil {
+		return err
+	}
+
+	x.RawHTML = string(rawHTML)
+	x.HTMLEndpoint = endpoint
+	if resp.Request != nil {
+		x.HTMLEndpoint = resp.Request.URL.String()
+	}
+	return nil
+}
+
+// papaParse matches the URL given to the Papa.parse calls of minified or
+// formatted scripts, quoted with any of the quotes of JavaScript.
+var papaParse = regexp.MustCompile(`Papa\.parse\s*\(\s*(?:"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)'|` + "`([^`]*)`)")
+
+// csvLiteral matches the string literals of scripts ending with ".csv",
+// which are used when no Papa.parse call is given a CSV file, such as when
+// its URL is kept in a variable.
+var csvLiteral = regexp.MustCompile(`(?i)["']([^"'\s]+?\.csv(?:\?[^"'\s]*)?)["']`)
+
+// GetCSVReference will try to grab the URL path of the CSV to process from
+// the scripts of the RawHTML, preferring the files given to Papa.parse over
+// the other CSV files the scripts mention. Relative URLs are resolved against
+// the HTMLEndpoint, or the base element of the page. When no CSV file is
+// found, the error lists the URLs which were considered.
+func (x *Client) GetCSVReference() error {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(x.RawHTML))
+	if err != nil {
+		return err
+	}
+	base, _ := url.Parse(x.HTMLEndpoint)
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok && base != nil {
+		if u, err := base.Parse(strings.TrimSpace(href)); err == nil {
+			base = u
+		}
+	}
+
+	candidates := csvCandidates(doc)
+	for _, link := range candidates {
+		u, err := url.Parse(link)
+		if err != nil || !strings.HasSuffix(strings.ToLower(u.Path), ".csv") {
+			continue
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		x.DataEndpoint = u.String()
+		return nil
+	}
+
+	page := x.HTMLEndpoint
+	if page == "" {
+		page = "the page"
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("could not find a link to the csv file in %s: none of its %d scripts call Papa.parse or mention a csv file, try -csv-url", page, doc.Find("script").Length())
+	}
+	return fmt.Errorf("could not find a link to the csv file in %s: none of the urls given to Papa.parse are csv files [%s], try -csv-url", page, strings.Join(candidates, ", "))
+}
+
+// csvCandidates will return the URLs given to Papa.parse by the scripts of
+// the document, followed by their string literals naming CSV files, in the
+// order they appear.
+func csvCandidates(doc *goquery.Document) []string {
+	scripts := []string{}
+	doc.Find("script").Each(func(_ int, s *goquery.Selection) {
+		scripts = append(scripts, s.Text())
+	})
+
+	candidates := []string{}
+	seen := map[string]bool{}
+	add := func(link string) {
+		link = strings.TrimSpace(strings.ReplaceAll(link, `\/`, "/"))
+		if link != "" && !seen[link] {
+			seen[link] = true
+			candidates = append(candidates, link)
+		}
+	}
+	for _, script := range scripts {
+		for _, match := range papaParse.FindAllStringSubmatch(script, -1) {
+			add(match[1] + match[2] + match[3])
+		}
+	}
+	for _, script := range scripts {
+		for _, match := range csvLiteral.FindAllStringSubmatch(script, -1) {
+			add(match[1])
+		}
+	}
+	return candidates
+}
+
+// openCSVData will request the CSV data file, returning the response for
+// the caller to read and close.
+func (x *Client) openCSVData() (*http.Response, error) {
+	resp, err := DefaultRetryPolicy.Get(x.DataEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch data: %d %s", resp.StatusCode, resp.Status)
+	}
+	return resp, nil
+}
+
+// GetCSVData will grabx the CSV data file and set the RawCSV
+// field to the contents of that file. When the file is longer than the
+// MaxFetchBytes of the DefaultLimits, its complete lines are kept and the
+// LimitError is returned.
+func (x *Client) GetCSVData() error {
+	resp, err := x.openCSVData()
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	RawCSV, err := ioutil.ReadAll(newUTF8Reader(resp.Body))
+	if isLimit(err) {
+		x.RawCSV = string(RawCSV[:bytes.LastIndexByte(RawCSV, '\n')+1])
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	x.RawCSV = string(RawCSV)
+	return nil
+}
+
+// StreamCSVData will grab the CSV data file and populate the RawResults
+// and FilteredResults fields with ReadCSVData as it is downloaded, without
+// keeping the file in RawCSV. The file is copied to w as it is read when w
+// isn't nil.
+func (x *Client) StreamCSVData(w io.Writer) error {
+	resp, err := x.openCSVData()
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	var r io.Reader = resp.Body
+	if w != nil {
+		r = io.TeeReader(resp.Body, w)
+	}
+	return x.ReadCSVData(r)
+}
+
+// SetCSVData will populate the RawResults field with the inputs after
+// processing the RawCSV data into the expected format (type Entry)
+func (x *Client) SetCSVData() {
+	p := &actParser{client: x}
+	for _, record := range readCSV(x.RawCSV) {
+		p.add(record)
+	}
+	p.done()
+}
+
+// ReadCSVData will populate the RawResults and FilteredResults fields with
+// the rows of CSV data as they are read from r, cleaning them as Clean does
+// and reading the columns named by a header row when there is one. Text
+// which isn't UTF-8 is read as Windows-1252. When
+// reading fails part way, such as at the MaxFetchBytes of the DefaultLimits,
+// the complete rows read before are kept and the error is returned.
+func (x *Client) ReadCSVData(r io.Reader) error {
+	reader := newCSVReader(&lineCleaner{r: bufio.NewReader(newUTF8Reader(r))})
+	p := &actParser{client: x, detect: true}
+	skipped := Entries{}
+	// The short rows are kept until a row is long enough, as they are all
+	// parsed instead when every row is too short.
+	var short [][]string
+	var err error
+	for {
+		var record []string
+		if record, err = reader.Read(); err != nil {
+			break
+		}
+		if len(record) < 9 {
+			skipped.warn("act", record, "too few fields", true)
+			if p.rows == 0 {
+				short = append(short, record)
+			}
+			continue
+		}
+		short = nil
+		for len(record) > 0 && record[len(record)-1] == "" {
+			record = record[:len(record)-1]
+		}
+		p.add(record)
+	}
+
+	if p.rows == 0 {
+		for _, record := range short {
+			p.add(record)
+		}
+	} else {
+		x.RawResults.Warnings = append(skipped.Warnings, x.RawResults.Warnings...)
+		if n := len(skipped.Warnings); n > 0 {
+			DefaultLogger.Info("skipped rows with too few fields", "source", "act", "rows", n)
+		}
+	}
+	p.done()
+	return readError(err)
+}
+
+// actParser translates the records of an ACT CSV file into the Entries of
+// a Client one at a time, finding the fields relative to the date, or by
+// the columns named by a header row when detect is set.
+type actParser struct {
+	client *Client
+	detect bool
+	// mapping reads the columns named by the header row, when the first
+	// record was one.
+	mapping *mappingParser
+	// rows is the number of records added so far.
+	rows int
+	// skipped is the number of rows without a date or suburb.
+	skipped int
+}
+
+// add will translate the record into an Entry of the client.
+func (p *actParser) add(record []string) {
+	defer func() { p.rows++ }()
+	if p.rows == 0 && p.detect {
+		// The columns named by a header row are more reliable than finding
+		// them relative to the date.
+		if m := detectMapping(record); m != nil {
+			if p.mapping, _ = m.parser(nil); p.mapping != nil {
+				DefaultLogger.Debug("mapped columns by the header row", "source", "act")
+				return
+			}
+		}
+	}
+	if p.mapping != nil {
+		if e, ok := p.mapping.entry(record, &p.client.RawResults); ok {
+			p.client.AddRaw(&e)
+			p.client.AddFiltered(&e)
+		}
+		return
+	}
+
+	newEntry := fieldTranslate(record)
+	if newEntry.Suburb == "" {
+		p.skipped++
+		DefaultLogger.Debug("skipped row without a date or suburb", "row", strings.Join(record, ","))
+	}
+	if reason, dropped := recordProblem(newEntry); reason != "" && !(p.rows == 0 && headerRow(record)) {
+		p.client.RawResults.warn("act", record, reason, dropped)
+	}
+	p.client.AddRaw(&newEntry)
+	p.client.AddFiltered(&newEntry)
+}
+
+// done will log how many rows were skipped once every record is added.
+func (p *actParser) done() {
+	if p.skipped > 0 {
+		DefaultLogger.Info("skipped rows without a date or suburb", "source", "act", "rows", p.skipped)
+	}
+}
+
+// lineCleaner reads CSV data with the stray characters removed from the
+// start and end of each row, as Clean does. When reading fails part way,
+// the incomplete last line is left out.
+type lineCleaner struct {
+	r *bufio.Reader
+	// line is what is left of the cleaned line being read.
+	line []byte
+	err  error
+}
+
+// Read will read the cleaned lines into p.
+func (l *lineCleaner) Read(p []byte) (int, error) {
+	for len(l.line) == 0 {
+		if l.err != nil {
+			return 0, l.err
+		}
+		line, err := l.r.ReadBytes('\n')
+		if err != nil {
+			l.err = err
+			if err != io.EOF || len(line) == 0 {
+				continue
+			}
+		}
+		line = bytes.Trim(bytes.TrimSuffix(line, []byte("\n")), "\r")
+		l.line = append(bytes.Trim(line, "!"), '\n')
+	}
+	n := copy(p, l.line)
+	l.line = l.line[n:]
+	return n, nil
+}
+
+// AddFiltered will check if the input has a suburb associated to it and
+// adds the result to the FilteredResults slice for rendering.
+func (x *Client) AddFiltered(e *Entry) {
+	if e.Suburb == "" {
+		return
+	}
+	x.FilteredResults.Items = append(x.FilteredResults.Items, *e)
+}
+
+// AddRaw will check if the input has a suburb associated to it and
+// adds the result to the FilteredResults slice for rendering.
+func (x *Client) AddRaw(e *Entry) {
+	if e.Suburb == "" {
+		return
+	}
+	x.RawResults.Items = append(x.RawResults.Items, *e)
+}
+
+// Clean will filter garbage in raw CSV data. Rows with fewer than nine
+// fields are dropped, and stray characters are removed from the start and
+// end of each row along with any trailing empty fields.
+func (x *Client) Clean() {
+	// I don't even know how this garbage ended up here...
+	reader := newCSVReader(&lineCleaner{r: bufio.NewReader(strings.NewReader(x.RawCSV))})
+
+	var cleaned bytes.Buffer
+	writer := csv.NewWriter(&cleaned)
+
+	skipped := Entries{}
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			break
+		}
+		if len(record) < 9 {
+			skipped.warn("act", record, "too few fields", true)
+			continue
+		}
+		for len(record) > 0 && record[len(record)-1] == "" {
+			record = record[:len(record)-1]
+		}
+
+		if err := writer.Write(record); err != nil {
+			return
+		}
+	}
+	writer.Flush()
+
+	// When every row is dropped, they are all left for SetCSVData to
+	// report instead.
+	if cleaned.Len() != 0 {
+		x.RawCSV = cleaned.String()
+		x.RawResults.Warnings = append(x.RawResults.Warnings, skipped.Warnings...)
+		if n := len(skipped.Warnings); n > 0 {
+			DefaultLogger.Info("skipped rows with too few fields", "source", "act", "rows", n)
+		}
+	}
+}
+
+// GenerateData will fetch the mirror of an official dataset found at
+// SampleEndpointURL and return a Client populated with its contents. When
+// the dataset is cut off by a limit, the Client is still populated with what
+// was read and returned with the LimitError.
+func GenerateData() (*Client, error) {
+	c := &Client{}
+	c.DataEndpoint = SampleEndpointURL
+	err := c.GetCSVData()
+	if err != nil && !isLimit(err) {
+		return c, err
+	}
+	c.SetCSVData()
+	return c, err
+}
@@ -0,0 +1,221 @@
+package covidcheck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var testEndpoint = "https://www.covid19.act.gov.au/act-status-and-response/act-covid-19-exposure-locations"
+
+// TestDataLengthDynamic will check for entries known to be specific
+// lengths to be those specific lengths. This is tested dynamically
+// with by querying known data - opposed to the tests which follow
+// which provide static data to the same test.
+func TestDataLengthDynamic(t *testing.T) {
+	covid := &Client{}
+	var err error
+	t.Run("Getting Endpoint", func(t *testing.T) {
+		err = covid.GetHTML(testEndpoint)
+		if err != nil {
+			t.Fail()
+		}
+	})
+	t.Run("Getting CSV File URL", func(t *testing.T) {
+		err = covid.GetCSVReference()
+		if err != nil {
+			t.Fail()
+		}
+	})
+	t.Run("Getting CSV File Contents", func(t *testing.T) {
+		err = covid.GetCSVData()
+		if err != nil {
+			t.Fail()
+		}
+	})
+	t.Run("Translating CSV File to Struct", func(t *testing.T) {
+		covid.SetCSVData()
+		if len(covid.RawResults.Items) == 0 {
+			t.Fail()
+		}
+	})
+	t.Run("CLeaning Raw CSV data", func(t *testing.T) {
+		covid.Clean()
+		for _, line := range strings.Split(covid.RawCSV, "\n") {
+			if strings.HasPrefix(line, string(rune(13))) {
+				t.Fail()
+			}
+			if strings.HasSuffix(line, string(rune(13))) {
+				t.Fail()
+			}
+			if strings.HasPrefix(line, string(rune(33))) {
+				t.Fail()
+			}
+			if strings.HasSuffix(line, string(rune(33))) {
+				t.Fail()
+			}
+			if strings.HasPrefix(line, string(rune(44))) {
+				t.Fail()
+			}
+			if strings.HasSuffix(line, string(rune(44))) {
+				t.Fail()
+			}
+		}
+	})
+}
+
+// TestData is an integration test to ensure certain thresholds are as
+// expected. Failing these tests would indicate a change in data
+// structure which would mean adjustments need to be made.
+func TestData(t *testing.T) {
+	covid := &Client{}
+	var err error
+	t.Run("Getting Endpoint", func(t *testing.T) {
+		err = covid.GetHTML(testEndpoint)
+		if err != nil {
+			t.Fail()
+		}
+	})
+	t.Run("Getting CSV File URL", func(t *testing.T) {
+		err = covid.GetCSVReference()
+		if err != nil {
+			t.Fail()
+		}
+	})
+	t.Run("Getting CSV File Contents", func(t *testing.T) {
+		err = covid.GetCSVData()
+		if err != nil {
+			t.Fail()
+		}
+	})
+	t.Run("Cleaning CSV content", func(t *testing.T) {
+		covid.Clean()
+		for _, line := range strings.Split(covid.RawCSV, "\n") {
+			if strings.HasPrefix(line, string(rune(13))) {
+				t.Fail()
+			}
+			if strings.HasSuffix(line, string(rune(13))) {
+				t.Fail()
+			}
+			if strings.HasPrefix(line, string(rune(33))) {
+				t.Fail()
+			}
+			if strings.HasSuffix(line, string(rune(33))) {
+				t.Fail()
+			}
+			if strings.HasPrefix(line, string(rune(44))) {
+				t.Fail()
+			}
+			if strings.HasSuffix(line, string(rune(44))) {
+				t.Fail()
+			}
+		}
+	})
+	t.Run("Translating CSV File to Struct", func(t *testing.T) {
+		covid.SetCSVData()
+		if len(covid.RawResults.Items) == 0 {
+			t.Fail()
+		}
+	})
+	t.Run("Perform a query without filter", func(t *testing.T) {
+		covid.Query(&Filter{}, QueryParams{
+			PrintRAWCSV: false,
+		})
+	})
+	t.Run("Assert results pass validation criteria", func(t *testing.T) {
+		for _, item := range covid.FilteredResults.Items {
+			// Is row item nil?
+			if fmt.Sprint(&Entry{}) == fmt.Sprint(item) {
+				t.Fail()
+			}
+		}
+	})
+}
+
+// cutReader is an io.Reader of data which fails with err once it is read.
+type cutReader struct {
+	data string
+	err  error
+}
+
+func (r *cutReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// TestReadCSVData will stream static CSV data and check it is cleaned and
+// translated as it is read, keeping the complete rows when reading fails.
+func TestReadCSVData(t *testing.T) {
+	t.Run("Cleaning rows as they are read", func(t *testing.T) {
+		covid := &Client{}
+		raw := "!" + strings.ReplaceAll(actTestCSV, "\n", "!\r\n") + "short,row\n"
+		if err := covid.ReadCSVData(strings.NewReader(raw)); err != nil {
+			t.Fatal(err)
+		}
+		if covid.RawResults.Len() != 2 || covid.FilteredResults.Len() != 2 || covid.RawResults.Items[1].Contact != ContactClose {
+			t.Errorf("expected the cleaned entries, got %+v", covid.RawResults.Items)
+		}
+		if len(covid.RawResults.Warnings) != 1 || covid.RawResults.Warnings[0].Reason != "too few fields" {
+			t.Errorf("expected the short row to be warned about, got %+v", covid.RawResults.Warnings)
+		}
+	})
+
+	t.Run("Mapping columns by the header row", func(t *testing.T) {
+		covid := &Client{}
+		raw := "Suburb,Date,Location\nKaleen,04/10/2021,ALDI\nBelconnen,05/10/2021,Coles"
+		if err := covid.ReadCSVData(strings.NewReader(raw)); err != nil {
+			t.Fatal(err)
+		}
+		if covid.RawResults.Len() != 2 || covid.RawResults.Items[1].ExposureLocation != "Coles" {
+			t.Errorf("expected the mapped entries, got %+v", covid.RawResults.Items)
+		}
+	})
+
+	t.Run("Keeping complete rows when reading fails", func(t *testing.T) {
+		covid := &Client{}
+		cut := &LimitError{Limit: "max-fetch-bytes", Value: 10}
+		err := covid.ReadCSVData(&cutReader{data: actTestCSV[:len(actTestCSV)-20], err: cut})
+		if err != cut {
+			t.Errorf("expected the limit error, got %v", err)
+		}
+		if covid.RawResults.Len() != 1 || covid.RawResults.Items[0].ExposureLocation != "ALDI Belconnen" {
+			t.Errorf("expected only the complete row, got %+v", covid.RawResults.Items)
+		}
+	})
+}
+
+// TestGetCSVReference will find the CSV files of static web pages, with
+// minified and formatted scripts and relative links.
+func TestGetCSVReference(t *testing.T) {
+	page := "https://www.covid19.act.gov.au/act-status-and-response/act-covid-19-exposure-locations"
+	examples := map[string]string{
+		"<script>\nPapa.parse(\"https://example.com/data.csv\", {download: true});\n</script>":                                               "https://example.com/data.csv",
+		"<script>var a=1;Papa.parse('/files/config.json',{download:!0});Papa.parse('/files/sites.csv?v=3',{download:!0,header:!1})</script>": "https://www.covid19.act.gov.au/files/sites.csv?v=3",
+		"<script>jQuery(function(){})</script><script>Papa.parse( `../data/act.csv` , {})</script>":                                          "https://www.covid19.act.gov.au/data/act.csv",
+		"<script>var u=\"https:\\/\\/example.com\\/x\\/act.CSV\";Papa.parse(u,{download:true})</script>":                                     "https://example.com/x/act.CSV",
+		"<base href=\"https://example.com/mirror/\"><script>Papa.parse(\"act.csv\")</script>":                                                "https://example.com/mirror/act.csv",
+	}
+	for html, expected := range examples {
+		covid := &Client{RawHTML: "<html><head>" + html + "</head></html>", HTMLEndpoint: page}
+		if err := covid.GetCSVReference(); err != nil {
+			t.Fatal(err)
+		}
+		if covid.DataEndpoint != expected {
+			t.Errorf("expected %q from %s, got %q", expected, html, covid.DataEndpoint)
+		}
+	}
+	for html, expected := range map[string]string{
+		"<script>Papa.parse(\"data.json\");Papa.parse('/other.xlsx')</script>": "[data.json, /other.xlsx]",
+		"<script>var a = 1;</script><p>No scripts here</p>":                    "none of its 1 scripts",
+	} {
+		covid := &Client{RawHTML: "<html><head>" + html + "</head></html>", HTMLEndpoint: page}
+		err := covid.GetCSVReference()
+		if err == nil || !strings.Contains(err.Error(), expected) || !strings.Contains(err.Error(), page) || covid.DataEndpoint != "" {
+			t.Errorf("expected an error mentioning %q from %s, got %v", expected, html, err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// column is a field of an Entry which can be chosen for the table, CSV and
+// JSON output.
+type column struct {
+	// Name is the name of the column given to -columns.
+	Name string
+	// Header is the header of the column in tables and CSV.
+	Header string
+	// Key is the key of the field in JSON objects.
+	Key string
+	// Text will return the value of the field in tables and CSV.
+	Text func(e *Entry) string
+	// JSON will return the value of the field in JSON objects, or is nil
+	// when it is the same as Text.
+	JSON func(e *Entry) interface{}
+}
+
+// columns are the columns which can be chosen, in their default order.
+var columns = []column{
+	{Name: "status", Header: "Status", Key: "status", Text: func(e *Entry) string { return string(e.Status) }},
+	{Name: "location", Header: "Location", Key: "location", Text: func(e *Entry) string { return e.ExposureLocation }},
+	{Name: "street", Header: "Street", Key: "street", Text: func(e *Entry) string { return e.Street }},
+	{Name: "suburb", Header: "Suburb", Key: "suburb", Text: func(e *Entry) string { return e.Suburb }},
+	{Name: "state", Header: "State", Key: "state", Text: func(e *Entry) string { return string(e.State) }},
+	{
+		Name: "date", Header: "Date", Key: "date",
+		Text: func(e *Entry) string { return formatTime(e.Date, canonicalDateFormat) },
+		JSON: func(e *Entry) interface{} { return formatTime(e.Date, jsonDateFormat) },
+	},
+	{
+		Name: "start", Header: "Start", Key: "start_time",
+		Text: func(e *Entry) string { return formatTime(e.ArrivalTime, canonicalTimeFormat) },
+		JSON: func(e *Entry) interface{} { return formatTime(e.ArrivalTime, jsonTimeFormat) },
+	},
+	{
+		Name: "end", Header: "End", Key: "end_time",
+		Text: func(e *Entry) string { return formatTime(e.DepartureTime, canonicalTimeFormat) },
+		JSON: func(e *Entry) interface{} { return formatTime(e.DepartureTime, jsonTimeFormat) },
+	},
+	{Name: "contact", Header: "Contact", Key: "contact", Text: func(e *Entry) string { return string(e.Contact) }},
+	{Name: "trust", Header: "Trust", Key: "trust", Text: func(e *Entry) string { return e.Trust }},
+	{
+		Name: "risk", Header: "Risk", Key: "risk",
+		Text: func(e *Entry) string { return fmt.Sprintf("%.2f", e.Risk()) },
+		JSON: func(e *Entry) interface{} { return e.Risk() },
+	},
+	{Name: "slug", Header: "Slug", Key: "slug", Text: func(e *Entry) string { return e.Slug() }},
+	{Name: "hash", Header: "Hash", Key: "hash", Text: func(e *Entry) string { return e.Hash() }},
+}
+
+// findColumn will return the column with a name.
+func findColumn(name string) (column, bool) {
+	for _, c := range columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+// ParseColumns will return the names of the comma separated columns given
+// to -columns, such as "status,location,suburb,date,contact".
+func ParseColumns(value string) ([]string, error) {
+	names := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := findColumn(name); !ok {
+			known := []string{}
+			for _, c := range columns {
+				known = append(known, c.Name)
+			}
+			return nil, fmt.Errorf("unknown column '%s', expected one of [%s]", name, strings.Join(known, "|"))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// selectColumns will return the columns with the names, skipping unknown
+// ones.
+func selectColumns(names []string) []column {
+	selected := []column{}
+	for _, name := range names {
+		if c, ok := findColumn(name); ok {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
+
+// columnValue is the value of a column in a columnRecord.
+type columnValue struct {
+	Key   string
+	Value interface{}
+}
+
+// columnRecord is the JSON object of an Entry exported with chosen columns,
+// which keeps its keys in the order of the columns rather than sorting them
+// like a map.
+type columnRecord []columnValue
+
+// MarshalJSON will encode the record as an object with its keys in order.
+func (r columnRecord) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for n, v := range r {
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(v.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ExportColumns will write the FilteredResults to w like Export, with only
+// the named columns in their order when there are any. CSV has a header row
+// of the columns when canonical is set, and JSON objects have the keys of
+// the columns in the same order. Other formats are written whole.
+func (x *Client) ExportColumns(w io.Writer, format string, canonical bool, names []string) error {
+	if len(names) == 0 || (format != "csv" && format != "json") {
+		return x.Export(w, format, canonical)
+	}
+	selected := selectColumns(names)
+	items := x.FilteredResults.Items
+	if canonical {
+		items = sortedByHash(items)
+	}
+
+	if format == "json" {
+		records := []columnRecord{}
+		for i := range items {
+			record := columnRecord{}
+			for _, c := range selected {
+				if c.JSON != nil {
+					record = append(record, columnValue{c.Key, c.JSON(&items[i])})
+				} else {
+					record = append(record, columnValue{c.Key, c.Text(&items[i])})
+				}
+			}
+			records = append(records, record)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+
+	quote := func(fields []string) string {
+		for n, field := range fields {
+			fields[n] = "\"" + strings.ReplaceAll(field, "\"", "\"\"") + "\""
+		}
+		return strings.Join(fields, ",")
+	}
+	if canonical {
+		header := []string{}
+		for _, c := range selected {
+			header = append(header, c.Header)
+		}
+		fmt.Fprintln(w, quote(header))
+	}
+	for i := range items {
+		fields := []string{}
+		for _, c := range selected {
+			fields = append(fields, c.Text(&items[i]))
+		}
+		if _, err := fmt.Fprintln(w, quote(fields)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
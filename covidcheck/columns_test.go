@@ -0,0 +1,79 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestColumns will choose columns of static entries and check they are
+// rendered and exported in order.
+func TestColumns(t *testing.T) {
+	covid := &Client{}
+	for _, record := range readCSV(actTestCSV) {
+		e := fieldTranslate(record)
+		covid.AddFiltered(&e)
+	}
+
+	t.Run("Parsing columns", func(t *testing.T) {
+		names, err := ParseColumns("Suburb, contact,date")
+		if err != nil || strings.Join(names, ",") != "suburb,contact,date" {
+			t.Errorf("unexpected columns %v: %v", names, err)
+		}
+		if _, err := ParseColumns("suburb,bogus"); err == nil || !strings.Contains(err.Error(), "bogus") {
+			t.Errorf("expected an unknown column error, got %v", err)
+		}
+		if _, err := ParseColumns(""); err == nil {
+			t.Error("expected an error without columns")
+		}
+	})
+
+	t.Run("Rendering columns", func(t *testing.T) {
+		var buf bytes.Buffer
+		covid.Render(&buf, RenderParams{Width: 50, Columns: []string{"contact", "suburb"}, Slug: true})
+		lines := strings.Split(buf.String(), "\n")
+		if strings.Join(strings.Fields(lines[1]), " ") != "| CONTACT | SUBURB |" || strings.Join(strings.Fields(lines[3]), " ") != "| Casual | Belconnen |" {
+			t.Errorf("unexpected table %s", buf.String())
+		}
+	})
+
+	t.Run("Exporting columns", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := covid.ExportColumns(&buf, "csv", false, []string{"suburb", "date", "start"}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != "\"Belconnen\",\"04/10/2021\",\"7:00PM\"\n\"Kaleen\",\"01/09/2021\",\"6:15PM\"\n" {
+			t.Errorf("unexpected csv %q", buf.String())
+		}
+
+		buf.Reset()
+		if err := covid.ExportColumns(&buf, "json", false, []string{"location", "date", "risk"}); err != nil {
+			t.Fatal(err)
+		}
+		var records []map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 2 || len(records[0]) != 3 || records[0]["location"] != "ALDI Belconnen" || records[0]["date"] != "2021-10-04" {
+			t.Errorf("unexpected records %v", records)
+		}
+		if _, ok := records[0]["risk"].(float64); !ok {
+			t.Errorf("expected a numeric risk, got %v", records[0]["risk"])
+		}
+
+		buf.Reset()
+		covid.ExportColumns(&buf, "json", false, []string{"status", "location", "suburb"})
+		status, location, suburb := strings.Index(buf.String(), `"status"`), strings.Index(buf.String(), `"location"`), strings.Index(buf.String(), `"suburb"`)
+		if status < 0 || status > location || location > suburb {
+			t.Errorf("expected the keys in the order of the columns, got %s", buf.String())
+		}
+
+		var whole, selected bytes.Buffer
+		covid.Export(&whole, "html", false)
+		covid.ExportColumns(&selected, "html", false, []string{"suburb"})
+		if whole.String() != selected.String() {
+			t.Error("expected html to be exported whole")
+		}
+	})
+}
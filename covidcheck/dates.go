@@ -0,0 +1,62 @@
+package covidcheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateFormat is the format of dates given to the date filters.
+const dateFormat = "02/01/2006"
+
+// day will return the calendar day of t, as entry dates are parsed.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseDate will parse a date filter, which is either formatted as
+// DD/MM/YYYY or YYYY-MM-DD, or is a date as people type them relative to
+// now, such as "yesterday", "last tuesday", "3 days ago" or "Sep 28".
+func ParseDate(value string, now time.Time) (time.Time, error) {
+	if t, err := parseFirst(value, []string{dateFormat, jsonDateFormat}); err == nil {
+		return t, nil
+	}
+	if t, ok := parseNaturalDate(value, now); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("date format is DD/MM/YYYY, YYYY-MM-DD or a date such as 28 Sep, yesterday, last tuesday or 3 days ago: could not parse '%s'", value)
+}
+
+// ParseSince will parse the start of a date range, which is either a date
+// accepted by ParseDate or an age in days or weeks such as "3d" or "2w".
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if len(v) > 1 {
+		unit := map[byte]int{'d': 1, 'w': 7}[v[len(v)-1]]
+		if n, err := strconv.Atoi(v[:len(v)-1]); err == nil && unit != 0 && n >= 0 {
+			return day(now).AddDate(0, 0, -n*unit), nil
+		}
+	}
+	t, err := ParseDate(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since is an age such as 3d or 2w, or a date: could not parse '%s'", value)
+	}
+	return t, nil
+}
+
+// inRange will check the date is within the inclusive range of days, where
+// a nil bound is open.
+func inRange(date, since, until *time.Time) bool {
+	if date == nil {
+		return false
+	}
+	d := day(*date)
+	if since != nil && d.Before(day(*since)) {
+		return false
+	}
+	if until != nil && d.After(day(*until)) {
+		return false
+	}
+	return true
+}
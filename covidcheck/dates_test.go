@@ -0,0 +1,82 @@
+package covidcheck
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRelativeDates will parse relative date filters against a fixed time
+// and check the date range filters the expected entries.
+func TestRelativeDates(t *testing.T) {
+	now := time.Date(2021, 10, 14, 18, 30, 0, 0, time.Local)
+
+	t.Run("Parsing dates", func(t *testing.T) {
+		examples := map[string]time.Time{
+			"today":      time.Date(2021, 10, 14, 0, 0, 0, 0, time.UTC),
+			"Yesterday":  time.Date(2021, 10, 13, 0, 0, 0, 0, time.UTC),
+			"01/10/2021": time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC),
+			"2021-10-02": time.Date(2021, 10, 2, 0, 0, 0, 0, time.UTC),
+		}
+		for value, expected := range examples {
+			if d, err := ParseDate(value, now); err != nil || !d.Equal(expected) {
+				t.Errorf("expected %s to be %v, got %v %v", value, expected, d, err)
+			}
+		}
+		if _, err := ParseDate("2021/10/01", now); err == nil {
+			t.Error("expected an error for an invalid date")
+		}
+	})
+
+	t.Run("Parsing since", func(t *testing.T) {
+		examples := map[string]time.Time{
+			"3d":        time.Date(2021, 10, 11, 0, 0, 0, 0, time.UTC),
+			"1w":        time.Date(2021, 10, 7, 0, 0, 0, 0, time.UTC),
+			"yesterday": time.Date(2021, 10, 13, 0, 0, 0, 0, time.UTC),
+		}
+		for value, expected := range examples {
+			if d, err := ParseSince(value, now); err != nil || !d.Equal(expected) {
+				t.Errorf("expected %s to be %v, got %v %v", value, expected, d, err)
+			}
+		}
+		if _, err := ParseSince("3y", now); err == nil {
+			t.Error("expected an error for an invalid age")
+		}
+	})
+
+	t.Run("Filtering by date range", func(t *testing.T) {
+		old := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+		recent := time.Date(2021, 10, 12, 0, 0, 0, 0, time.UTC)
+		since, _ := ParseSince("3d", now)
+
+		covid := &Client{RawResults: Entries{Items: []Entry{
+			{ExposureLocation: "Westfield Woden", Date: &old},
+			{ExposureLocation: "Coles Kaleen", Date: &recent},
+		}}}
+		covid.Query(&Filter{Since: &since}, QueryParams{})
+		if len(covid.FilteredResults.Items) != 1 || covid.FilteredResults.Items[0].ExposureLocation != "Coles Kaleen" {
+			t.Fail()
+		}
+
+		covid.Query(&Filter{Until: &old}, QueryParams{})
+		if len(covid.FilteredResults.Items) != 1 || covid.FilteredResults.Items[0].ExposureLocation != "Westfield Woden" {
+			t.Fail()
+		}
+	})
+
+	t.Run("Filtering by a day", func(t *testing.T) {
+		first := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+		eleventh := time.Date(2021, 10, 11, 0, 0, 0, 0, time.UTC)
+		later := time.Date(2021, 10, 1, 19, 30, 0, 0, time.UTC)
+
+		covid := &Client{RawResults: Entries{Items: []Entry{
+			{ExposureLocation: "Westfield Woden", Date: &first},
+			{ExposureLocation: "Coles Kaleen", Date: &eleventh},
+			{ExposureLocation: "ALDI Belconnen", Date: &later},
+			{ExposureLocation: "Glebe Park"},
+		}}}
+		covid.Query(&Filter{Date: &first}, QueryParams{})
+		if covid.FilteredResults.Len() != 2 || covid.FilteredResults.Items[0].ExposureLocation != "Westfield Woden" || covid.FilteredResults.Items[1].ExposureLocation != "ALDI Belconnen" {
+			t.Errorf("expected only the entries of 1/10 to match, got %+v", covid.FilteredResults.Items)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugStatus is the internal state of a long-running instance, served on
+// /debug/status by a Debug handler.
+type DebugStatus struct {
+	// Entries is the number of exposure sites in the latest dataset.
+	Entries int `json:"entries"`
+	// Updated is when the latest dataset was fetched.
+	Updated string `json:"updated,omitempty"`
+	// Uptime is how long the instance has been running.
+	Uptime string `json:"uptime"`
+	// Goroutines is the number of running goroutines.
+	Goroutines int `json:"goroutines"`
+	// HeapAlloc is the number of bytes of allocated heap objects.
+	HeapAlloc uint64 `json:"heap_alloc_bytes"`
+	// Sys is the number of bytes of memory obtained from the system.
+	Sys uint64 `json:"sys_bytes"`
+	// NumGC is the number of completed garbage collections.
+	NumGC uint32 `json:"num_gc"`
+	// LastGC is when the last garbage collection finished.
+	LastGC string `json:"last_gc,omitempty"`
+	// Caches are the lookups of each named Cache.
+	Caches []DebugCache `json:"caches"`
+}
+
+// DebugCache is the lookups of a named Cache in a DebugStatus.
+type DebugCache struct {
+	Name string `json:"name"`
+	CacheStats
+	HitRate float64 `json:"hit_rate"`
+}
+
+// Debug is an http.Handler serving the DebugStatus on /debug/status and the
+// runtime profiles of net/http/pprof under /debug/pprof/, to diagnose
+// long-running instances. It should only be served on a private address.
+type Debug struct {
+	// Started is when the instance started, used for its uptime.
+	Started time.Time
+	// Caches are the caches to report the lookups of, by name.
+	Caches map[string]*Cache
+
+	mu      sync.Mutex
+	entries int
+	updated time.Time
+}
+
+// Update will record the number of exposure sites in the latest dataset.
+// Nothing is recorded by a nil Debug.
+func (d *Debug) Update(entries Entries) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = entries.Len()
+	d.updated = time.Now()
+}
+
+// Status will return the current DebugStatus.
+func (d *Debug) Status() DebugStatus {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	d.mu.Lock()
+	status := DebugStatus{
+		Entries:    d.entries,
+		Uptime:     time.Since(d.Started).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  mem.HeapAlloc,
+		Sys:        mem.Sys,
+		NumGC:      mem.NumGC,
+		Caches:     []DebugCache{},
+	}
+	if !d.updated.IsZero() {
+		status.Updated = d.updated.UTC().Format(time.RFC3339)
+	}
+	d.mu.Unlock()
+
+	if mem.LastGC > 0 {
+		status.LastGC = time.Unix(0, int64(mem.LastGC)).UTC().Format(time.RFC3339)
+	}
+	for name, c := range d.Caches {
+		stats := c.Stats()
+		status.Caches = append(status.Caches, DebugCache{Name: name, CacheStats: stats, HitRate: stats.HitRate()})
+	}
+	sort.Slice(status.Caches, func(i, j int) bool { return status.Caches[i].Name < status.Caches[j].Name })
+	return status
+}
+
+// ServeHTTP will serve the DebugStatus and runtime profiles.
+func (d *Debug) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/debug/status":
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(d.Status())
+	case "/debug/pprof/cmdline":
+		pprof.Cmdline(w, r)
+	case "/debug/pprof/profile":
+		pprof.Profile(w, r)
+	case "/debug/pprof/symbol":
+		pprof.Symbol(w, r)
+	case "/debug/pprof/trace":
+		pprof.Trace(w, r)
+	default:
+		if !strings.HasPrefix(r.URL.Path, "/debug/pprof/") {
+			http.NotFound(w, r)
+			return
+		}
+		pprof.Index(w, r)
+	}
+}
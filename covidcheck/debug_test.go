@@ -0,0 +1,63 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDebug will serve the status of static entries and a cache, and check
+// the status and profiles are served.
+func TestDebug(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := &Cache{Dir: dir, TTL: time.Minute}
+	cache.Get("missing")
+
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV) {
+		entries.Add(fieldTranslate(record))
+	}
+	debug := &Debug{Started: time.Now().Add(-time.Minute), Caches: map[string]*Cache{"source": cache, "geocoder": nil}}
+	debug.Update(entries)
+	server := httptest.NewServer(debug)
+	defer server.Close()
+
+	t.Run("Serving the status", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/debug/status")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		status := DebugStatus{}
+		if err := json.NewDecoder(res.Body).Decode(&status); err != nil {
+			t.Fatal(err)
+		}
+		if status.Entries != 2 || status.Updated == "" || status.Uptime != "1m0s" || status.Goroutines == 0 || status.HeapAlloc == 0 {
+			t.Errorf("unexpected status %+v", status)
+		}
+		if len(status.Caches) != 2 || status.Caches[1].Name != "source" || status.Caches[1].Misses != 1 {
+			t.Errorf("unexpected caches %+v", status.Caches)
+		}
+	})
+
+	t.Run("Serving profiles", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/debug/pprof/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		if res.StatusCode != http.StatusOK || !strings.Contains(string(body), "goroutine") {
+			t.Errorf("unexpected profiles %d %s", res.StatusCode, body)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// changeRecord is the representation of Changes in JSON exports.
+type changeRecord struct {
+	Added   []exportRecord `json:"added"`
+	Removed []exportRecord `json:"removed"`
+	Updated []exportRecord `json:"updated"`
+}
+
+// ExportChanges will write the changes to w as JSON, with the added,
+// removed and updated entries in the same format as Export.
+func ExportChanges(w io.Writer, changes Changes) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(changeRecord{
+		Added:   exportRecords(changes.Added.Items),
+		Removed: exportRecords(changes.Removed.Items),
+		Updated: exportRecords(changes.Updated.Items),
+	})
+}
+
+// ExportChangesCSV will write the changes to w as CSV in the canonical
+// format of Export, with a leading column saying how each entry changed.
+func ExportChangesCSV(w io.Writer, changes Changes) error {
+	var buf bytes.Buffer
+	fmt.Fprintln(w, "\"Change\","+canonicalCSVHeader)
+	for _, group := range []struct {
+		Label   string
+		Entries Entries
+	}{{"Added", changes.Added}, {"Removed", changes.Removed}, {"Updated", changes.Updated}} {
+		for _, item := range group.Entries.Items {
+			buf.Reset()
+			if err := exportCanonicalCSV(&buf, []Entry{item}, false); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "\"%s\",%s", group.Label, buf.String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RenderChanges will render a table of the changes to the user, with a
+// column saying how each entry changed.
+func RenderChanges(w io.Writer, changes Changes, width int) {
+	if changes.Len() == 0 {
+		fmt.Fprintln(w, "no changes found")
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Change", "Status", "Location", "Street", "Suburb", "State", "Date/Time", "Contact"})
+	table.SetCaption(false, "COVID-19 Exposure Site Changes")
+	table.SetColWidth(width)
+	for _, group := range []struct {
+		Label   string
+		Entries Entries
+	}{{"Added", changes.Added}, {"Removed", changes.Removed}, {"Updated", changes.Updated}} {
+		for _, item := range group.Entries.Items {
+			table.Append([]string{
+				group.Label,
+				string(item.Status),
+				item.ExposureLocation,
+				item.Street,
+				item.Suburb,
+				string(item.State),
+				fmt.Sprintf("%s %s - %s", formatTime(item.Date, "2-1-2006"), formatTime(item.ArrivalTime, time.Kitchen), formatTime(item.DepartureTime, time.Kitchen)),
+				string(item.Contact),
+			})
+		}
+	}
+	table.Render()
+}
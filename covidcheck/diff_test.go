@@ -0,0 +1,58 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestDiffOutput will render and export static changes and check each kind
+// of change is included.
+func TestDiffOutput(t *testing.T) {
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV) {
+		entries.Add(fieldTranslate(record))
+	}
+	updated := entries.Items[0]
+	updated.Status = "Updated"
+	changes := Diff(Entries{Items: entries.Items[:1]}, Entries{Items: []Entry{updated, entries.Items[1]}})
+
+	t.Run("Rendering changes", func(t *testing.T) {
+		var buf bytes.Buffer
+		RenderChanges(&buf, changes, 50)
+		out := buf.String()
+		if !strings.Contains(out, "| Added  ") || !strings.Contains(out, "| Updated ") || strings.Contains(out, "Removed") {
+			t.Errorf("unexpected table %s", out)
+		}
+
+		buf.Reset()
+		RenderChanges(&buf, Changes{}, 50)
+		if !strings.Contains(buf.String(), "no changes found") {
+			t.Fail()
+		}
+	})
+
+	t.Run("Exporting changes", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := ExportChanges(&buf, changes); err != nil {
+			t.Fatal(err)
+		}
+		record := map[string][]map[string]string{}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if len(record["added"]) != 1 || len(record["removed"]) != 0 || record["updated"][0]["status"] != "Updated" {
+			t.Errorf("unexpected changes %s", buf.String())
+		}
+
+		buf.Reset()
+		if err := ExportChangesCSV(&buf, changes); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 3 || !strings.HasPrefix(lines[0], "\"Change\",\"Status\"") || !strings.HasPrefix(lines[1], "\"Added\",") || !strings.HasPrefix(lines[2], "\"Updated\",\"Updated\"") {
+			t.Errorf("unexpected csv %s", buf.String())
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package covidcheck
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// acceptEncoding are the content encodings requested of every endpoint.
+const acceptEncoding = "gzip, deflate"
+
+// encodingTransport is a http.RoundTripper which asks endpoints to compress
+// their responses, and decodes the gzip and deflate encoded bodies so they
+// are read as sent. The limits of a download count the decoded bytes.
+type encodingTransport struct {
+	// Transport sends the requests.
+	Transport http.RoundTripper
+}
+
+// RoundTrip will send the request and decode the body of the response.
+func (t *encodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A request already asking for an encoding is decoded by its sender.
+	if req.Header.Get("Accept-Encoding") != "" {
+		return t.Transport.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil || req.Method == http.MethodHead {
+		return resp, err
+	}
+
+	var body io.ReadCloser
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		body = &decodedBody{open: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }, body: resp.Body}
+	case "deflate":
+		body = &decodedBody{open: openDeflate, body: resp.Body}
+	default:
+		return resp, nil
+	}
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// openDeflate will read a deflate encoded body, which is meant to be zlib
+// wrapped but is sent as raw deflate data by some servers.
+func openDeflate(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// decodedBody is the body of a response which is decoded as it is first
+// read, so the headers are available before the body arrives.
+type decodedBody struct {
+	open    func(io.Reader) (io.ReadCloser, error)
+	body    io.ReadCloser
+	decoder io.ReadCloser
+	err     error
+}
+
+// Read will read the decoded body.
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.decoder == nil && b.err == nil {
+		b.decoder, b.err = b.open(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.decoder.Read(p)
+}
+
+// Close will close the body of the response.
+func (b *decodedBody) Close() error {
+	if b.decoder != nil {
+		b.decoder.Close()
+	}
+	return b.body.Close()
+}
+
+// windows1252 are the characters of the bytes 0x80 to 0x9f in Windows-1252,
+// the rest of the bytes above 0x7f being the same as in Unicode. The five
+// bytes it leaves undefined are read as the control characters they are in
+// ISO-8859-1.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// utf8Reader is an io.Reader of text which converts the bytes which aren't
+// valid UTF-8 from Windows-1252, so smart quotes and accents saved by Excel
+// aren't read as invalid characters. Text which is already UTF-8 is read
+// unchanged.
+type utf8Reader struct {
+	r io.Reader
+	// in are the bytes read but not yet converted, which end with an
+	// incomplete character until the rest of it is read.
+	in []byte
+	// out is what is left of the converted text.
+	out []byte
+	err error
+}
+
+// newUTF8Reader will return a reader of r as UTF-8 text.
+func newUTF8Reader(r io.Reader) io.Reader {
+	return &utf8Reader{r: r}
+}
+
+// Read will read the converted text into p.
+func (u *utf8Reader) Read(p []byte) (int, error) {
+	for len(u.out) == 0 {
+		if u.err != nil && len(u.in) == 0 {
+			return 0, u.err
+		}
+		if u.err == nil {
+			buf := make([]byte, 4096)
+			n, err := u.r.Read(buf)
+			u.in = append(u.in, buf[:n]...)
+			u.err = err
+		}
+		u.convert()
+	}
+	n := copy(p, u.out)
+	u.out = u.out[n:]
+	return n, nil
+}
+
+// convert will convert the bytes read so far, keeping an incomplete
+// character at the end until more is read or the text ends.
+func (u *utf8Reader) convert() {
+	out := make([]byte, 0, len(u.in))
+	var char [utf8.UTFMax]byte
+	in := u.in
+	for len(in) > 0 {
+		if in[0] < utf8.RuneSelf {
+			out = append(out, in[0])
+			in = in[1:]
+			continue
+		}
+		if !utf8.FullRune(in) && u.err == nil {
+			break
+		}
+		r, size := utf8.DecodeRune(in)
+		if r == utf8.RuneError && size == 1 {
+			r = rune(in[0])
+			if in[0] < 0xa0 {
+				r = windows1252[in[0]-0x80]
+			}
+		}
+		out = append(out, char[:utf8.EncodeRune(char[:], r)]...)
+		in = in[size:]
+	}
+	u.out = out
+	u.in = append(u.in[:0], in...)
+}
@@ -0,0 +1,79 @@
+package covidcheck
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestEncoding will serve compressed and Windows-1252 encoded data and check
+// it is read as UTF-8 text.
+func TestEncoding(t *testing.T) {
+	t.Run("Decoding compressed responses", func(t *testing.T) {
+		compressors := map[string]func(io.Writer) io.WriteCloser{
+			"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+			"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+			"raw":     func(w io.Writer) io.WriteCloser { fw, _ := flate.NewWriter(w, flate.DefaultCompression); return fw },
+		}
+		for name, compress := range compressors {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept-Encoding") != acceptEncoding {
+					t.Errorf("unexpected Accept-Encoding %q", r.Header.Get("Accept-Encoding"))
+				}
+				encoding := name
+				if name == "raw" {
+					encoding = "deflate"
+				}
+				w.Header().Set("Content-Encoding", encoding)
+				cw := compress(w)
+				io.WriteString(cw, actTestCSV)
+				cw.Close()
+			}))
+
+			resp, err := DefaultRetryPolicy.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			server.Close()
+			if err != nil || string(body) != actTestCSV || resp.Header.Get("Content-Encoding") != "" {
+				t.Errorf("expected the %s body to be decoded, got %q and %v", name, body, err)
+			}
+		}
+	})
+
+	t.Run("Converting Windows-1252", func(t *testing.T) {
+		examples := map[string]string{
+			"Caf\xe9 \x93Le Bon\x94 \x96 Kaleen": "Café “Le Bon” – Kaleen",
+			"Café “Le Bon”":                      "Café “Le Bon”",
+			"\x80\x81 \xff":                      "€\u0081 ÿ",
+			"plain":                              "plain",
+		}
+		for in, expected := range examples {
+			out, err := ioutil.ReadAll(newUTF8Reader(iotest.OneByteReader(strings.NewReader(in))))
+			if err != nil || string(out) != expected {
+				t.Errorf("expected %q, got %q and %v", expected, out, err)
+			}
+		}
+	})
+
+	t.Run("Reading Windows-1252 rows", func(t *testing.T) {
+		covid := &Client{}
+		raw := strings.Replace(actTestCSV, "ALDI Belconnen", "ALDI Belconnen \x96 Caf\xe9", 1)
+		if err := covid.ReadCSVData(bytes.NewReader([]byte(raw))); err != nil {
+			t.Fatal(err)
+		}
+		if covid.RawResults.Items[0].ExposureLocation != "ALDI Belconnen – Café" {
+			t.Errorf("unexpected location %q", covid.RawResults.Items[0].ExposureLocation)
+		}
+	})
+}
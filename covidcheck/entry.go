@@ -0,0 +1,256 @@
+package covidcheck
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type (
+	// Entries is a slice of type Entry.
+	Entries struct {
+		Items []Entry
+		// Warnings are the rows of the source which couldn't be fully
+		// parsed.
+		Warnings []ParseWarning
+	}
+
+	// Entry is a stuct which represents the data to be displayed. Its ID
+	// is its Hash, which is stable while the site is updated.
+	Entry struct {
+		// Status is the status of the Entry - either New, Updated, Archived,
+		// or without a value - nil.
+		Status Status
+		// Location is the location as provided by the data.
+		ExposureLocation string
+		// Street is supposed to be the street address - the data
+		// is a little inconsistent - we've tried to fix that.
+		Street string
+		// Suburb is the suburb of the Entry.
+		Suburb string
+		// State is the state or territory of the Entry, such as ACT.
+		State State
+		// Date is a valid *time.Time entry used for querying or presenting.
+		Date *time.Time
+		// Arrival time is the exposure start time represented as a string.
+		ArrivalTime *time.Time
+		// Arrival time is the exposure finish time represented as a string.
+		DepartureTime *time.Time
+		// Contact is the contact category - either Close, Casual or Monitor.
+		Contact Contact
+		// ContactLabel is the contact category as the source published it,
+		// such as "Tier 1" in Victoria, before it was mapped onto Contact.
+		ContactLabel string
+		// Trust is the verification label of the Entry - either official,
+		// community or imported.
+		Trust string
+		// Venue is the venue of the Entry found in OpenStreetMap, when the
+		// Entry has been enriched.
+		Venue *Venue
+		// Point is the location of the Entry, when it has been geocoded by
+		// a Pipeline.
+		Point *Point
+		// Category is the venue category of the Entry, when it has been
+		// classified by a Pipeline.
+		Category string
+	}
+)
+
+const (
+	// TrustOfficial is the Trust of entries from an official data source.
+	TrustOfficial = "official"
+	// TrustCommunity is the Trust of unofficial, community reported entries.
+	TrustCommunity = "community"
+	// TrustImported is the Trust of entries read from a local file, which
+	// can't be verified as official.
+	TrustImported = "imported"
+)
+
+func (e *Entries) Len() int {
+	return len(e.Items)
+}
+
+func (e *Entries) Less(i, j int) bool {
+	//	sort.Sort(students)
+	//	fmt.Println(sort.IsSorted(students))
+	//	sort.Sort(sort.Reverse(students))
+	// https://gist.github.com/dnutiu/a899e48c95ff80fe98bada566e03251e
+
+	// Work out if the full start date comes before another, where entries
+	// without a date come last.
+
+	return compareTimes(e.Items[i].Date, e.Items[j].Date) > 0
+}
+
+func (e *Entries) Swap(i, j int) {
+	e.Items[i], e.Items[j] = e.Items[j], e.Items[i]
+}
+
+// SetTrust will label every Entry without a Trust with the input.
+func (e *Entries) SetTrust(trust string) {
+	for i := range e.Items {
+		if e.Items[i].Trust == "" {
+			e.Items[i].Trust = trust
+		}
+	}
+}
+
+// Dedupe will remove the entries with the same identifying fields as an
+// earlier Entry, keeping the first of each, and return how many were
+// removed. The locations and suburbs are compared by their DefaultAliases,
+// so the variants of a place are the same site. Official lists often repeat
+// rows, which would otherwise inflate the counts.
+func (e *Entries) Dedupe() int {
+	seen := map[string]bool{}
+	kept := e.Items[:0]
+	for _, entry := range e.Items {
+		key := entry.identity(DefaultAliases)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, entry)
+	}
+	removed := len(e.Items) - len(kept)
+	e.Items = kept
+	return removed
+}
+
+// MixedTrust will check whether the entries have more than one Trust.
+func (e *Entries) MixedTrust() bool {
+	for i := range e.Items {
+		if e.Items[i].Trust != e.Items[0].Trust {
+			return true
+		}
+	}
+	return false
+}
+
+// Add will add an Entry into the Entries - can be applied to RawResults
+// or RawFilteredResults, depending on where in the application.
+func (entries *Entries) Add(entry Entry) {
+	entries.Items = append(entries.Items, entry)
+}
+
+// dateField is the expression used to identify the date field in a record.
+var dateField = regexp.MustCompile(`^[0-9]+/[0-9]+/[0-9][0-9]+$`)
+
+// fieldTranslate will ensure the Entry is processed and displayed correctly,
+// as structural changes will impact this. The record is expected to be the
+// fields of a single CSV row, parsed with encoding/csv so quoted commas in
+// locations and streets are kept intact. The date field anchors the record,
+// because the leading columns have changed over time, and every other field
+// is found relative to it:
+//
+//	[...], Status, Location, Street, Suburb, State, Date, Arrival, Departure, Contact
+//
+// Records without a date are returned as an empty Entry.
+func fieldTranslate(record []string) Entry {
+
+	fields := make([]string, len(record))
+	for i, v := range record {
+		fields[i] = strings.TrimSpace(v)
+	}
+
+	d := -1
+	date := time.Time{}
+	for i, v := range fields {
+		datestring := strings.Split(v, " ")[0]
+		if !dateField.MatchString(datestring) {
+			continue
+		}
+		t, err := time.Parse("2/1/2006", datestring)
+		if err == nil {
+			d = i
+			date = t
+			break
+		}
+	}
+	if d == -1 {
+		return Entry{}
+	}
+
+	field := func(offset int) string {
+		if d+offset < 0 || d+offset >= len(fields) {
+			return ""
+		}
+		return fields[d+offset]
+	}
+
+	// Values which aren't valid are left empty, rather than dropping the
+	// whole record.
+	status, err := ParseStatus(field(-5))
+	if err != nil {
+		DefaultLogger.Debug("unrecognized field", "field", "status", "location", field(-4), "error", err)
+	}
+	state, err := ParseState(field(-1))
+	if err != nil {
+		DefaultLogger.Debug("unrecognized field", "field", "state", "location", field(-4), "error", err)
+	}
+	contact := MapContact("act", field(3))
+	if contact == ContactUnknown && field(3) != "" {
+		DefaultLogger.Debug("unrecognized field", "field", "contact", "location", field(-4), "value", field(3))
+	}
+	return Entry{
+		Status:           status,
+		ExposureLocation: field(-4),
+		Street:           field(-3),
+		Suburb:           field(-2),
+		State:            state,
+		Date:             &date,
+		ArrivalTime:      kitchenTime(field(1)),
+		DepartureTime:    kitchenTime(field(2)),
+		Contact:          contact,
+		ContactLabel:     field(3),
+	}
+}
+
+// kitchenTime will parse a time such as "2:15pm", returning the zero time
+// if it cannot be parsed.
+func kitchenTime(in string) *time.Time {
+	in = strings.ToUpper(strings.ReplaceAll(in, " ", ""))
+	t, err := time.Parse(time.Kitchen, in)
+	if err != nil {
+		return &time.Time{}
+	}
+	return &t
+}
+
+// newCSVReader will return a reader of the records of CSV data as it is
+// read. Rows are allowed to have a varying number of fields, and stray
+// quotes are tolerated.
+func newCSVReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return reader
+}
+
+// readError will return the error which stopped a CSV reader, or nil when
+// it reached the end of the data or a row which cannot be read at all, as
+// parsing stops there without failing.
+func readError(err error) error {
+	var parse *csv.ParseError
+	if err == io.EOF || errors.As(err, &parse) {
+		return nil
+	}
+	return err
+}
+
+// readCSV will parse the raw CSV data into records, stopping at the first
+// row which cannot be read at all.
+func readCSV(raw string) [][]string {
+	reader := newCSVReader(strings.NewReader(raw))
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			break
+		}
+		records = append(records, record)
+	}
+	return records
+}
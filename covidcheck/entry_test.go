@@ -0,0 +1,122 @@
+package covidcheck
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDataLengthStatic will take expected values as static content, and run
+// some basic validation directly from an existing data set from the
+// authoriative source. The check will validate the length of the row in the
+// CSV given addresses/locations can also contain ',', and not have a street
+// and/or location. To complicate things, the ',' is our delimiter.
+func TestDataLengthStatic(t *testing.T) {
+	t.Run("Validating static content constraints (1/3)", func(t *testing.T) {
+		var example = ",,\"7-Eleven Holt\",\"88 Hardwick Crescent\",\"Holt\",\"ACT\",\"01/09/2021 - Wednesday\",2:15pm,3:00pm,\"Monitor\""
+		if len(strings.Split(example, ",")) != 10 {
+			t.Fail()
+		}
+	})
+	t.Run("Validating static content constraints (2/3)", func(t *testing.T) {
+		var example = ",,\"ALDI Belconnen\",\"Westfield Belconnen, Benjamin Way\",\"Belconnen\",\"ACT\",\"01/09/2021 - Wednesday\",7:00pm,7:30pm,\"Monitor\""
+		if len(strings.Split(example, ",")) != 11 {
+			t.Fail()
+		}
+	})
+	t.Run("Validating static content constraints (3/3)", func(t *testing.T) {
+		var example = ",,\"Kaleen Plaza Pharmacy\",\"Shop 5, Kaleen Shopping Centre, Georgina Crescent\",\"Kaleen\",\"ACT\",\"01/09/2021 - Wednesday\",6:15pm,7:10pm,\"Casual\""
+		if len(strings.Split(example, ",")) != 12 {
+			t.Fail()
+		}
+	})
+}
+
+// TestFieldTranslate will parse the static content from TestDataLengthStatic
+// and check quoted fields containing commas are kept intact.
+func TestFieldTranslate(t *testing.T) {
+	examples := []string{
+		",,\"7-Eleven Holt\",\"88 Hardwick Crescent\",\"Holt\",\"ACT\",\"01/09/2021 - Wednesday\",2:15pm,3:00pm,\"Monitor\"",
+		",,\"ALDI Belconnen\",\"Westfield Belconnen, Benjamin Way\",\"Belconnen\",\"ACT\",\"01/09/2021 - Wednesday\",7:00pm,7:30pm,\"Monitor\"",
+		",,\"Kaleen Plaza Pharmacy\",\"Shop 5, Kaleen Shopping Centre, Georgina Crescent\",\"Kaleen\",\"ACT\",\"01/09/2021 - Wednesday\",6:15pm,7:10pm,\"Casual\"",
+	}
+	expected := []Entry{
+		{ExposureLocation: "7-Eleven Holt", Street: "88 Hardwick Crescent", Suburb: "Holt", State: "ACT", Contact: "Monitor"},
+		{ExposureLocation: "ALDI Belconnen", Street: "Westfield Belconnen, Benjamin Way", Suburb: "Belconnen", State: "ACT", Contact: "Monitor"},
+		{ExposureLocation: "Kaleen Plaza Pharmacy", Street: "Shop 5, Kaleen Shopping Centre, Georgina Crescent", Suburb: "Kaleen", State: "ACT", Contact: "Casual"},
+	}
+
+	records := readCSV(strings.Join(examples, "\n"))
+	if len(records) != len(examples) {
+		t.Fatalf("expected %d records, got %d", len(examples), len(records))
+	}
+
+	for i, record := range records {
+		t.Run(fmt.Sprintf("Translating static content (%d/%d)", i+1, len(records)), func(t *testing.T) {
+			e := fieldTranslate(record)
+			if e.ExposureLocation != expected[i].ExposureLocation || e.Street != expected[i].Street || e.Suburb != expected[i].Suburb || e.State != expected[i].State || e.Contact != expected[i].Contact {
+				t.Errorf("unexpected entry %+v", e)
+			}
+			if e.Date.Format("02/01/2006") != "01/09/2021" {
+				t.Errorf("unexpected date %v", e.Date)
+			}
+		})
+	}
+
+	t.Run("Ignoring records without a date", func(t *testing.T) {
+		e := fieldTranslate([]string{"Event Id", "Status", "Exposure Location", "Street", "Suburb", "State", "Date", "Arrival Time", "Departure Time", "Contact"})
+		if e.Suburb != "" || e.Date != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("Cleaning static content", func(t *testing.T) {
+		covid := &Client{RawCSV: "garbage\r\n" + strings.Join(examples, ",\r\n") + "!\r\n"}
+		covid.Clean()
+		covid.SetCSVData()
+		if len(covid.RawResults.Items) != 3 {
+			t.Errorf("expected 3 entries, got %d", len(covid.RawResults.Items))
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(covid.RawCSV, "\n"), "\n") {
+			if strings.HasSuffix(line, ",") || strings.HasSuffix(line, "!") || strings.HasSuffix(line, "\r") {
+				t.Errorf("unexpected line %q", line)
+			}
+		}
+	})
+}
+
+// TestDedupe will fetch static content with repeated rows, differing only in
+// case and whitespace, and check each exposure site is kept once.
+func TestDedupe(t *testing.T) {
+	data := actTestCSV + `3,"New","aldi  belconnen","Westfield Belconnen, Benjamin Way","BELCONNEN","ACT","04/10/2021 - Monday",7:00pm,7:30pm,"Close"
+4,"Archived","Kaleen Plaza Pharmacy","Shop 5, Kaleen Shopping Centre, Georgina Crescent","Kaleen","ACT","01/09/2021 - Wednesday",6:15pm,7:10pm,"Close"
+5,"Archived","Kaleen Plaza Pharmacy","Shop 5, Kaleen Shopping Centre, Georgina Crescent","Kaleen","ACT","01/09/2021 - Wednesday",6:15pm,7:20pm,"Close"
+`
+	dir, err := ioutil.TempDir("", "covid-check-dedupe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "act.csv")
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, _ := NewSource("act", SourceOptions{File: path})
+	entries, err := src.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries.Len() != 3 {
+		t.Fatalf("expected 3 entries, got %d", entries.Len())
+	}
+	if entries.Items[0].Contact != ContactCasual || entries.Items[2].DepartureTime.Minute() != 20 {
+		t.Errorf("expected the first of each site to be kept, got %+v", entries.Items)
+	}
+	if removed := entries.Dedupe(); removed != 0 {
+		t.Errorf("expected nothing left to remove, got %d", removed)
+	}
+}
@@ -0,0 +1,166 @@
+package covidcheck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the status of an exposure site in the official list, which is
+// empty for sites without one.
+type Status string
+
+const (
+	// StatusNone is the Status of exposure sites without a status.
+	StatusNone Status = ""
+	// StatusNew is the Status of exposure sites added recently.
+	StatusNew Status = "New"
+	// StatusUpdated is the Status of exposure sites changed recently.
+	StatusUpdated Status = "Updated"
+	// StatusArchived is the Status of exposure sites which are no longer
+	// current.
+	StatusArchived Status = "Archived"
+)
+
+// statuses are the valid values of Status.
+var statuses = []Status{StatusNone, StatusNew, StatusUpdated, StatusArchived}
+
+// ParseStatus will return the Status named by the value, ignoring case.
+func ParseStatus(value string) (Status, error) {
+	for _, s := range statuses {
+		if strings.EqualFold(strings.TrimSpace(value), string(s)) {
+			return s, nil
+		}
+	}
+	return StatusNone, fmt.Errorf("unknown status '%s', expected one of [New|Updated|Archived] or none", value)
+}
+
+// String will return the name of the Status.
+func (s Status) String() string {
+	return string(s)
+}
+
+// MarshalText will return the name of the Status.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s), nil
+}
+
+// UnmarshalText will parse the name of a Status, ignoring case.
+func (s *Status) UnmarshalText(text []byte) error {
+	status, err := ParseStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// Contact is the contact level of people at an exposure site, which is
+// empty when the source doesn't give one.
+type Contact string
+
+const (
+	// ContactUnknown is the Contact of exposure sites without a contact
+	// level.
+	ContactUnknown Contact = ""
+	// ContactClose is the Contact of close contacts, who should isolate.
+	ContactClose Contact = "Close"
+	// ContactCasual is the Contact of casual contacts, who should get
+	// tested.
+	ContactCasual Contact = "Casual"
+	// ContactMonitor is the Contact of people who should monitor for
+	// symptoms.
+	ContactMonitor Contact = "Monitor"
+)
+
+// contacts are the valid values of Contact.
+var contacts = []Contact{ContactUnknown, ContactClose, ContactCasual, ContactMonitor}
+
+// ParseContact will return the Contact named by the value, ignoring case and
+// a trailing "contact", so "close contact" is ContactClose.
+func ParseContact(value string) (Contact, error) {
+	name := strings.TrimSpace(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "contact"))
+	for _, c := range contacts {
+		if strings.EqualFold(name, string(c)) {
+			return c, nil
+		}
+	}
+	return ContactUnknown, fmt.Errorf("unknown contact level '%s', expected one of [Close|Casual|Monitor] or none", value)
+}
+
+// String will return the name of the Contact.
+func (c Contact) String() string {
+	return string(c)
+}
+
+// MarshalText will return the name of the Contact.
+func (c Contact) MarshalText() ([]byte, error) {
+	return []byte(c), nil
+}
+
+// UnmarshalText will parse the name of a Contact, ignoring case.
+func (c *Contact) UnmarshalText(text []byte) error {
+	contact, err := ParseContact(string(text))
+	if err != nil {
+		return err
+	}
+	*c = contact
+	return nil
+}
+
+// State is the Australian state or territory of an exposure site, which is
+// empty when the source doesn't give one.
+type State string
+
+const (
+	// StateUnknown is the State of exposure sites without a state.
+	StateUnknown State = ""
+	// StateACT is the Australian Capital Territory.
+	StateACT State = "ACT"
+	// StateNSW is New South Wales.
+	StateNSW State = "NSW"
+	// StateNT is the Northern Territory.
+	StateNT State = "NT"
+	// StateQLD is Queensland.
+	StateQLD State = "QLD"
+	// StateSA is South Australia.
+	StateSA State = "SA"
+	// StateTAS is Tasmania.
+	StateTAS State = "TAS"
+	// StateVIC is Victoria.
+	StateVIC State = "VIC"
+	// StateWA is Western Australia.
+	StateWA State = "WA"
+)
+
+// states are the valid values of State.
+var states = []State{StateUnknown, StateACT, StateNSW, StateNT, StateQLD, StateSA, StateTAS, StateVIC, StateWA}
+
+// ParseState will return the State abbreviated by the value, ignoring case.
+func ParseState(value string) (State, error) {
+	for _, s := range states {
+		if strings.EqualFold(strings.TrimSpace(value), string(s)) {
+			return s, nil
+		}
+	}
+	return StateUnknown, fmt.Errorf("unknown state '%s', expected one of [ACT|NSW|NT|QLD|SA|TAS|VIC|WA] or none", value)
+}
+
+// String will return the abbreviation of the State.
+func (s State) String() string {
+	return string(s)
+}
+
+// MarshalText will return the abbreviation of the State.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s), nil
+}
+
+// UnmarshalText will parse the abbreviation of a State, ignoring case.
+func (s *State) UnmarshalText(text []byte) error {
+	state, err := ParseState(string(text))
+	if err != nil {
+		return err
+	}
+	*s = state
+	return nil
+}
@@ -0,0 +1,74 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestEnums will parse and marshal static values of each enum, and check
+// invalid values are rejected.
+func TestEnums(t *testing.T) {
+	t.Run("Parsing values", func(t *testing.T) {
+		if s, err := ParseStatus(" archived "); err != nil || s != StatusArchived {
+			t.Errorf("expected Archived, got %s %v", s, err)
+		}
+		if c, err := ParseContact("Close contact"); err != nil || c != ContactClose {
+			t.Errorf("expected Close, got %s %v", c, err)
+		}
+		if s, err := ParseState("vic"); err != nil || s != StateVIC {
+			t.Errorf("expected VIC, got %s %v", s, err)
+		}
+		if c, err := ParseContact(""); err != nil || c != ContactUnknown {
+			t.Errorf("expected an empty contact to be unknown, got %s %v", c, err)
+		}
+		if _, err := ParseStatus("Removed"); err == nil {
+			t.Error("expected an error parsing an unknown status")
+		}
+		if _, err := ParseContact("Secondary"); err == nil {
+			t.Error("expected an error parsing an unknown contact level")
+		}
+		if _, err := ParseState("Victoria"); err == nil {
+			t.Error("expected an error parsing an unknown state")
+		}
+	})
+
+	t.Run("Marshalling values", func(t *testing.T) {
+		value := struct {
+			Status  Status  `json:"status"`
+			Contact Contact `json:"contact"`
+			State   State   `json:"state"`
+		}{}
+		if err := json.Unmarshal([]byte(`{"status":"new","contact":"CASUAL","state":"nsw"}`), &value); err != nil {
+			t.Fatal(err)
+		}
+		if value.Status != StatusNew || value.Contact != ContactCasual || value.State != StateNSW {
+			t.Errorf("unexpected values %+v", value)
+		}
+		data, _ := json.Marshal(value)
+		if string(data) != `{"status":"New","contact":"Casual","state":"NSW"}` {
+			t.Errorf("unexpected json %s", data)
+		}
+		if err := json.Unmarshal([]byte(`{"contact":"Secondary"}`), &value); err == nil {
+			t.Error("expected an error unmarshalling an unknown contact level")
+		}
+	})
+
+	t.Run("Validating json entries", func(t *testing.T) {
+		_, err := ReadJSON(strings.NewReader(`[{"location":"ALDI Belconnen","date":"2021-10-04","contact":"Secondary"}]`))
+		if err == nil || !strings.Contains(err.Error(), "unknown contact level 'Secondary'") {
+			t.Errorf("expected an unknown contact level error, got %v", err)
+		}
+	})
+
+	t.Run("Parsing csv entries", func(t *testing.T) {
+		e := fieldTranslate(readCSV(actTestCSV)[0])
+		if e.Status != StatusNew || e.State != StateACT || e.Contact != ContactCasual {
+			t.Errorf("unexpected values %+v", e)
+		}
+		e = fieldTranslate([]string{"Removed", "ALDI Belconnen", "", "Belconnen", "Canberra", "4/10/2021", "7:00pm", "7:30pm", "Secondary"})
+		if e.Status != StatusNone || e.State != StateUnknown || e.Contact != ContactUnknown {
+			t.Errorf("expected invalid values to be left empty, got %+v", e)
+		}
+	})
+}
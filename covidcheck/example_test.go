@@ -0,0 +1,134 @@
+package covidcheck_test
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fubarhouse/covid-check/v2/covidcheck"
+)
+
+// fetchFixture will fetch the exposure sites of the golden fixture, which is
+// a local copy of the ACT CSV file.
+func fetchFixture() covidcheck.Entries {
+	src, err := covidcheck.NewSource("act", covidcheck.SourceOptions{File: "testdata/golden/fixture.csv"})
+	if err != nil {
+		panic(err)
+	}
+	entries, err := src.Fetch()
+	if err != nil {
+		panic(err)
+	}
+	return entries
+}
+
+// ExampleNewSource fetches the exposure sites of a source. Without a File,
+// the official list is downloaded from the Endpoint of the source instead.
+func ExampleNewSource() {
+	src, err := covidcheck.NewSource("act", covidcheck.SourceOptions{File: "testdata/golden/fixture.csv"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	entries, err := src.Fetch()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, e := range entries.Items {
+		fmt.Printf("%s, %s (%s)\n", e.ExposureLocation, e.Suburb, e.Trust)
+	}
+	// Output:
+	// ALDI Belconnen, Belconnen (imported)
+	// Kaleen Plaza Pharmacy, Kaleen (imported)
+	// Café Ümami | Dickson, Dickson (imported)
+	// Queanbeyan Leagues Club, Queanbeyan (imported)
+}
+
+// ExampleFilter_Apply filters exposure sites by the same fields as the
+// command line flags.
+func ExampleFilter_Apply() {
+	filter := &covidcheck.Filter{State: "ACT", ExcludeStatus: []string{"Archived"}}
+	if err := filter.Validate(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	matches := filter.Apply(fetchFixture())
+	for _, e := range matches.Items {
+		fmt.Printf("%s: %s\n", e.Status, e.ExposureLocation)
+	}
+	// Output:
+	// New: ALDI Belconnen
+	// Updated: Café Ümami | Dickson
+}
+
+// ExampleParseExpression filters exposure sites with an expression, as the
+// -filter flag does.
+func ExampleParseExpression() {
+	now := time.Date(2021, 10, 5, 12, 0, 0, 0, time.UTC)
+	expression, err := covidcheck.ParseExpression(`(contact == close || contact == casual) && date >= 2021-10-01`, now)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	matches := (&covidcheck.Filter{Expression: expression}).Apply(fetchFixture())
+	for _, e := range matches.Items {
+		fmt.Println(e.ExposureLocation)
+	}
+	// Output:
+	// ALDI Belconnen
+}
+
+// ExampleClient_Render renders exposure sites as a Markdown table.
+func ExampleClient_Render() {
+	covid := &covidcheck.Client{}
+	entries := fetchFixture()
+	for i := range entries.Items[:2] {
+		covid.AddFiltered(&entries.Items[i])
+	}
+	covid.Render(os.Stdout, covidcheck.RenderParams{Markdown: true})
+	// Output:
+	// |  Status  |       Location        |                      Street                       |  Suburb   | State |         Date/Time         | Contact |
+	// |----------|-----------------------|---------------------------------------------------|-----------|-------|---------------------------|---------|
+	// | New      | ALDI Belconnen        | Westfield Belconnen, Benjamin Way                 | Belconnen | ACT   | 4-10-2021 7:00PM - 7:30PM | Casual  |
+	// | Archived | Kaleen Plaza Pharmacy | Shop 5, Kaleen Shopping Centre, Georgina Crescent | Kaleen    | ACT   | 1-9-2021 6:15PM - 7:10PM  | Close   |
+}
+
+// ExampleClient_Export exports exposure sites in a format for other
+// programs, where canonical output is byte-identical for unchanged data.
+func ExampleClient_Export() {
+	covid := &covidcheck.Client{}
+	entries := fetchFixture()
+	covid.AddFiltered(&entries.Items[0])
+	if err := covid.Export(os.Stdout, "csv", true); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// "Status","Exposure Location","Street","Suburb","State","Date","Arrival Time","Departure Time","Contact"
+	// "New","ALDI Belconnen","Westfield Belconnen, Benjamin Way","Belconnen","ACT","04/10/2021","7:00PM","7:30PM","Casual"
+}
+
+// ExampleDiff reports the exposure sites which changed between two fetches,
+// such as a snapshot and the current list.
+func ExampleDiff() {
+	previous := fetchFixture()
+	current := fetchFixture()
+	current.Items = current.Items[1:]
+	current.Items[0].Contact = covidcheck.ContactCasual
+	current.Add(covidcheck.Entry{ExposureLocation: "Dickson Library", Suburb: "Dickson", State: covidcheck.StateACT})
+
+	changes := covidcheck.Diff(previous, current)
+	for _, e := range changes.Added.Items {
+		fmt.Println("added:", e.ExposureLocation)
+	}
+	for _, e := range changes.Updated.Items {
+		fmt.Println("updated:", e.ExposureLocation, e.Contact)
+	}
+	for _, e := range changes.Removed.Items {
+		fmt.Println("removed:", e.ExposureLocation)
+	}
+	// Output:
+	// added: Dickson Library
+	// updated: Kaleen Plaza Pharmacy Casual
+	// removed: ALDI Belconnen
+}
@@ -0,0 +1,206 @@
+package covidcheck
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// canonicalDateFormat is the fixed date format used in canonical CSV.
+	canonicalDateFormat = "02/01/2006"
+	// canonicalTimeFormat is the fixed time format used in canonical CSV.
+	canonicalTimeFormat = time.Kitchen
+	// jsonDateFormat is the date format used in JSON exports.
+	jsonDateFormat = "2006-01-02"
+	// jsonTimeFormat is the time format used in JSON exports.
+	jsonTimeFormat = "15:04"
+)
+
+// exportRecord is the representation of an Entry in JSON exports. Fields
+// are declared in alphabetical order so the keys are always sorted.
+type exportRecord struct {
+	Contact      string `json:"contact"`
+	ContactLabel string `json:"contact_label,omitempty"`
+	Date         string `json:"date"`
+	EndTime      string `json:"end_time"`
+	Hash         string `json:"hash"`
+	Location     string `json:"location"`
+	Slug         string `json:"slug"`
+	StartTime    string `json:"start_time"`
+	State        string `json:"state"`
+	Status       string `json:"status"`
+	Street       string `json:"street"`
+	Suburb       string `json:"suburb"`
+	Trust        string `json:"trust"`
+}
+
+// Hash will return a sha256 hex digest which identifies the exposure site
+// by its normalized location, address, date and time window. Status and
+// contact are excluded so an entry keeps its identity when updated.
+func (e *Entry) Hash() string {
+	return fmt.Sprintf("%x", e.hashSum())
+}
+
+// Slug will return a short, shareable identifier of the exposure site,
+// which is the lowercase base32 of the start of its Hash.
+func (e *Entry) Slug() string {
+	sum := e.hashSum()
+	return strings.ToLower(base32.StdEncoding.EncodeToString(sum[:5]))
+}
+
+// hashSum will return the sha256 sum of the identifying fields, which are
+// never aliased so the Hash is the same wherever the site is read.
+func (e *Entry) hashSum() [sha256.Size]byte {
+	return sha256.Sum256([]byte(e.identity(nil)))
+}
+
+// identity will return the normalized identifying fields of the Entry,
+// where the location and suburb are the names of their aliases.
+func (e *Entry) identity(aliases AliasMap) string {
+	fields := []string{
+		normalizeField(aliases.Canonical(e.ExposureLocation)),
+		normalizeField(e.Street),
+		normalizeField(aliases.Canonical(e.Suburb)),
+		normalizeField(string(e.State)),
+		formatTime(e.Date, jsonDateFormat),
+		formatTime(e.ArrivalTime, jsonTimeFormat),
+		formatTime(e.DepartureTime, jsonTimeFormat),
+	}
+	return strings.Join(fields, "|")
+}
+
+// FindSlug will return the entries with the slug, which can be shortened
+// to any unambiguous prefix.
+func (entries *Entries) FindSlug(slug string) Entries {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	found := Entries{}
+	if slug == "" {
+		return found
+	}
+	for _, e := range entries.Items {
+		if strings.HasPrefix(e.Slug(), slug) {
+			found.Add(e)
+		}
+	}
+	return found
+}
+
+// normalizeField will lowercase the input and collapse any whitespace.
+func normalizeField(in string) string {
+	return strings.Join(strings.Fields(strings.ToLower(in)), " ")
+}
+
+// formatTime will format a time with the layout, returning an empty string
+// when the time is nil.
+func formatTime(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// Export will write the FilteredResults to w in the given format, which
+// can be "csv", "json", "html" or "geojson". When canonical is set, the rows
+// are sorted by their hash and fixed formats are used so that unchanged data
+// always produces byte-identical output which can be diffed meaningfully.
+func (x *Client) Export(w io.Writer, format string, canonical bool) error {
+	items := x.FilteredResults.Items
+	if canonical {
+		items = sortedByHash(items)
+	}
+
+	switch format {
+	case "csv":
+		if canonical {
+			return exportCanonicalCSV(w, items, true)
+		}
+		for i := range items {
+			if _, err := fmt.Fprint(w, rawCSVLine(&items[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		records := exportRecords(items)
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case "html":
+		return exportHTML(w, items, canonical)
+	case "geojson":
+		return exportGeoJSON(w, items)
+	}
+
+	return fmt.Errorf("unknown export format '%s'", format)
+}
+
+// sortedByHash will return a copy of the items sorted by their hash, the
+// order of canonical exports.
+func sortedByHash(items []Entry) []Entry {
+	sorted := make([]Entry, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Hash() < sorted[j].Hash()
+	})
+	return sorted
+}
+
+// exportRecords will convert the items to their JSON export representation.
+func exportRecords(items []Entry) []exportRecord {
+	records := []exportRecord{}
+	for i := range items {
+		records = append(records, exportRecord{
+			Contact:      string(items[i].Contact),
+			ContactLabel: items[i].ContactLabel,
+			Date:         formatTime(items[i].Date, jsonDateFormat),
+			EndTime:      formatTime(items[i].DepartureTime, jsonTimeFormat),
+			Hash:         items[i].Hash(),
+			Location:     items[i].ExposureLocation,
+			Slug:         items[i].Slug(),
+			StartTime:    formatTime(items[i].ArrivalTime, jsonTimeFormat),
+			State:        string(items[i].State),
+			Status:       string(items[i].Status),
+			Street:       items[i].Street,
+			Suburb:       items[i].Suburb,
+			Trust:        items[i].Trust,
+		})
+	}
+	return records
+}
+
+// canonicalCSVHeader is the header row of the canonical CSV format.
+const canonicalCSVHeader = "\"Status\",\"Exposure Location\",\"Street\",\"Suburb\",\"State\",\"Date\",\"Arrival Time\",\"Departure Time\",\"Contact\""
+
+// exportCanonicalCSV will write the items as CSV with every field quoted,
+// using fixed date and time formats, after a header row when header is set.
+func exportCanonicalCSV(w io.Writer, items []Entry, header bool) error {
+	if header {
+		fmt.Fprintln(w, canonicalCSVHeader)
+	}
+	for i := range items {
+		fields := []string{
+			string(items[i].Status),
+			items[i].ExposureLocation,
+			items[i].Street,
+			items[i].Suburb,
+			string(items[i].State),
+			formatTime(items[i].Date, canonicalDateFormat),
+			formatTime(items[i].ArrivalTime, canonicalTimeFormat),
+			formatTime(items[i].DepartureTime, canonicalTimeFormat),
+			string(items[i].Contact),
+		}
+		for n, field := range fields {
+			fields[n] = "\"" + strings.ReplaceAll(field, "\"", "\"\"") + "\""
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExportCanonical will check canonical exports are identical no matter
+// the order of the input data, and that they use the fixed formats.
+func TestExportCanonical(t *testing.T) {
+	date, _ := time.Parse("02/01/2006", "04/10/2021")
+	start, _ := time.Parse(time.Kitchen, "7:00PM")
+	end, _ := time.Parse(time.Kitchen, "7:30PM")
+
+	one := Entry{Status: "New", ExposureLocation: "ALDI Belconnen", Street: "Westfield Belconnen", Suburb: "Belconnen", State: "ACT", Date: &date, ArrivalTime: &start, DepartureTime: &end, Contact: "Casual"}
+	two := Entry{Status: "Archived", ExposureLocation: "Coles Kaleen", Street: "Georgina Crescent", Suburb: "Kaleen", State: "ACT", Date: &date, ArrivalTime: &start, DepartureTime: &end, Contact: "Close"}
+
+	forward := &Client{FilteredResults: Entries{Items: []Entry{one, two}}}
+	backward := &Client{FilteredResults: Entries{Items: []Entry{two, one}}}
+
+	for _, format := range []string{"csv", "json", "html"} {
+		t.Run("Comparing canonical "+format+" exports", func(t *testing.T) {
+			var a, b bytes.Buffer
+			if err := forward.Export(&a, format, true); err != nil {
+				t.Fatal(err)
+			}
+			if err := backward.Export(&b, format, true); err != nil {
+				t.Fatal(err)
+			}
+			if a.String() != b.String() {
+				t.Errorf("canonical exports differ:\n%s\n%s", a.String(), b.String())
+			}
+		})
+	}
+
+	t.Run("Validating canonical csv formats", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := forward.Export(&buf, "csv", true); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "\"04/10/2021\",\"7:00PM\",\"7:30PM\"") {
+			t.Errorf("unexpected canonical csv:\n%s", buf.String())
+		}
+	})
+
+	t.Run("Validating json records", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := forward.Export(&buf, "json", false); err != nil {
+			t.Fatal(err)
+		}
+		var records []map[string]string
+		if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 2 || records[0]["date"] != "2021-10-04" || records[0]["start_time"] != "19:00" || records[0]["hash"] != one.Hash() {
+			t.Fail()
+		}
+	})
+
+	t.Run("Hash ignores status and contact", func(t *testing.T) {
+		updated := one
+		updated.Status = "Updated"
+		updated.Contact = "Close"
+		if updated.Hash() != one.Hash() || one.Hash() == two.Hash() {
+			t.Fail()
+		}
+	})
+
+	t.Run("Finding slugs", func(t *testing.T) {
+		if len(one.Slug()) != 8 || one.Slug() == two.Slug() {
+			t.Errorf("unexpected slugs %s and %s", one.Slug(), two.Slug())
+		}
+		entries := Entries{Items: []Entry{one, two}}
+		found := entries.FindSlug(strings.ToUpper(one.Slug()[:5]))
+		if found.Len() != 1 || found.Items[0].Hash() != one.Hash() {
+			t.Fail()
+		}
+		if none := entries.FindSlug(""); none.Len() != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("Rejecting unknown formats", func(t *testing.T) {
+		if err := forward.Export(&bytes.Buffer{}, "xml", false); err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,424 @@
+package covidcheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expression is a parsed filter expression, such as
+// `suburb == "Belconnen" && contact != "Monitor" && date >= 2021-10-01`,
+// which is evaluated against each Entry.
+type Expression interface {
+	// Match will check whether the Entry matches the expression.
+	Match(e *Entry) bool
+	// String will format the expression.
+	String() string
+}
+
+// expressionKind is the type of value of an expression field.
+type expressionKind int
+
+const (
+	kindString expressionKind = iota
+	kindDate
+	kindTime
+	kindNumber
+)
+
+// expressionField is a field of an Entry which can be used in expressions.
+type expressionField struct {
+	Kind  expressionKind
+	Value func(e *Entry) interface{}
+}
+
+// expressionFields are the fields which can be used in expressions. String
+// fields return a string, date and time fields a *time.Time and number
+// fields a float64.
+var expressionFields = map[string]expressionField{
+	"status":   {kindString, func(e *Entry) interface{} { return string(e.Status) }},
+	"location": {kindString, func(e *Entry) interface{} { return e.ExposureLocation }},
+	"street":   {kindString, func(e *Entry) interface{} { return e.Street }},
+	"suburb":   {kindString, func(e *Entry) interface{} { return e.Suburb }},
+	"state":    {kindString, func(e *Entry) interface{} { return string(e.State) }},
+	"contact":  {kindString, func(e *Entry) interface{} { return string(e.Contact) }},
+	"label":    {kindString, func(e *Entry) interface{} { return e.ContactLabel }},
+	"trust":    {kindString, func(e *Entry) interface{} { return e.Trust }},
+	"category": {kindString, func(e *Entry) interface{} { return e.VenueCategory() }},
+	"slug":     {kindString, func(e *Entry) interface{} { return e.Slug() }},
+	"date":     {kindDate, func(e *Entry) interface{} { return e.Date }},
+	"start":    {kindTime, func(e *Entry) interface{} { return e.ArrivalTime }},
+	"end":      {kindTime, func(e *Entry) interface{} { return e.DepartureTime }},
+	"risk":     {kindNumber, func(e *Entry) interface{} { return e.Risk() }},
+}
+
+// expressionFieldNames lists the expressionFields for error messages.
+const expressionFieldNames = "status, location, street, suburb, state, contact, label, trust, category, slug, date, start, end and risk"
+
+// expressionOperators are the comparison operators, longest first so they
+// are tokenized greedily.
+var expressionOperators = []string{"==", "!=", "<=", ">=", "!~", "~", "<", ">"}
+
+// token is a lexical token of an expression.
+type token struct {
+	// Kind is "ident", "string", "literal", "op", "&&", "||", "!", "(",
+	// ")" or "end".
+	Kind  string
+	Value string
+	Pos   int
+}
+
+// tokenize will split an expression into tokens.
+func tokenize(in string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(in)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, token{Kind: string(r), Pos: i})
+			i++
+		case strings.HasPrefix(string(runes[i:]), "&&") || strings.HasPrefix(string(runes[i:]), "||"):
+			tokens = append(tokens, token{Kind: string(runes[i : i+2]), Pos: i})
+			i += 2
+		case r == '"' || r == '\'':
+			j := i + 1
+			var b strings.Builder
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("filter: unterminated string at %d", i)
+			}
+			tokens = append(tokens, token{Kind: "string", Value: b.String(), Pos: i})
+			i = j + 1
+		default:
+			if op := operatorAt(runes[i:]); op != "" {
+				tokens = append(tokens, token{Kind: "op", Value: op, Pos: i})
+				i += len(op)
+				continue
+			}
+			if r == '!' {
+				tokens = append(tokens, token{Kind: "!", Pos: i})
+				i++
+				continue
+			}
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || strings.ContainsRune("-_/:.", runes[j])) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("filter: unexpected '%c' at %d", r, i)
+			}
+			word := string(runes[i:j])
+			kind := "literal"
+			if _, ok := expressionFields[strings.ToLower(word)]; ok {
+				kind = "ident"
+			}
+			tokens = append(tokens, token{Kind: kind, Value: word, Pos: i})
+			i = j
+		}
+	}
+	return append(tokens, token{Kind: "end", Pos: len(runes)}), nil
+}
+
+// operatorAt will return the comparison operator at the start of the input,
+// or an empty string when there isn't one.
+func operatorAt(in []rune) string {
+	for _, op := range expressionOperators {
+		if strings.HasPrefix(string(in), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// parser is a recursive descent parser of expressions.
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+// ParseExpression will parse a filter expression. Comparisons are a field
+// name, an operator and a value, and can be combined with && and ||,
+// negated with ! and grouped with parentheses. Strings are compared without
+// case with == and !=, or by what they contain with ~ and !~. Dates, times
+// and risk scores are compared with ==, !=, <, <=, > and >=, where dates are
+// formatted as for -date and times such as 9:00PM or 21:00. Relative dates
+// such as "today" are resolved against now.
+func ParseExpression(in string, now time.Time) (Expression, error) {
+	tokens, err := tokenize(in)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, now: now}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Kind != "end" {
+		return nil, fmt.Errorf("filter: unexpected '%s' at %d", t.text(), t.Pos)
+	}
+	return expr, nil
+}
+
+// text will return the token as it was written, for error messages.
+func (t token) text() string {
+	switch t.Kind {
+	case "string":
+		return strconv.Quote(t.Value)
+	case "ident", "literal", "op":
+		return t.Value
+	case "end":
+		return "end of filter"
+	}
+	return t.Kind
+}
+
+// peek will return the current token.
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next will return the current token and advance to the next.
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.Kind != "end" {
+		p.pos++
+	}
+	return t
+}
+
+// or will parse a sequence of and expressions joined by ||.
+func (p *parser) or() (Expression, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().Kind == "||" {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpression{Op: "||", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// and will parse a sequence of unary expressions joined by &&.
+func (p *parser) and() (Expression, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().Kind == "&&" {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpression{Op: "&&", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// unary will parse a negation, a parenthesized expression or a comparison.
+func (p *parser) unary() (Expression, error) {
+	switch t := p.peek(); t.Kind {
+	case "!":
+		p.next()
+		expr, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpression{Expr: expr}, nil
+	case "(":
+		p.next()
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.Kind != ")" {
+			return nil, fmt.Errorf("filter: expected ')' at %d, found '%s'", t.Pos, t.text())
+		}
+		return expr, nil
+	}
+	return p.comparison()
+}
+
+// comparison will parse a field, an operator and a value.
+func (p *parser) comparison() (Expression, error) {
+	t := p.next()
+	if t.Kind != "ident" {
+		return nil, fmt.Errorf("filter: expected a field at %d, found '%s' (fields are %s)", t.Pos, t.text(), expressionFieldNames)
+	}
+	name := strings.ToLower(t.Value)
+	field := expressionFields[name]
+
+	op := p.next()
+	if op.Kind != "op" {
+		return nil, fmt.Errorf("filter: expected an operator after %s at %d, found '%s'", name, op.Pos, op.text())
+	}
+	value := p.next()
+	if value.Kind != "string" && value.Kind != "literal" && value.Kind != "ident" {
+		return nil, fmt.Errorf("filter: expected a value after %s %s at %d, found '%s'", name, op.Value, value.Pos, value.text())
+	}
+
+	if field.Kind != kindString && strings.Contains(op.Value, "~") {
+		return nil, fmt.Errorf("filter: %s can't be compared with %s, which is for text", name, op.Value)
+	}
+
+	c := &comparison{Field: name, Op: op.Value, Value: value.Value, kind: field.Kind, get: field.Value}
+	var err error
+	switch field.Kind {
+	case kindString:
+		if strings.ContainsAny(op.Value, "<>") {
+			return nil, fmt.Errorf("filter: %s is text, which can't be compared with %s", name, op.Value)
+		}
+	case kindDate:
+		c.number, err = p.dateValue(value.Value)
+	case kindTime:
+		c.number, err = timeValue(value.Value)
+	case kindNumber:
+		c.number, err = strconv.ParseFloat(value.Value, 64)
+		if err != nil {
+			err = fmt.Errorf("filter: %s is a number: could not parse '%s'", name, value.Value)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// dateValue will parse a date value as a number of days.
+func (p *parser) dateValue(value string) (float64, error) {
+	t, err := ParseDate(value, p.now)
+	if err != nil {
+		return 0, fmt.Errorf("filter: %s", err.Error())
+	}
+	return float64(day(t).Unix() / 86400), nil
+}
+
+// timeValue will parse a time of day value as a number of minutes.
+func timeValue(value string) (float64, error) {
+	t, err := parseFirst(strings.ToUpper(value), []string{time.Kitchen, "3PM", "15:04"})
+	if err != nil {
+		return 0, fmt.Errorf("filter: times are formatted as 9:00PM or 21:00: could not parse '%s'", value)
+	}
+	return float64(t.Hour()*60 + t.Minute()), nil
+}
+
+// comparison is an expression comparing a field of the Entry to a value.
+type comparison struct {
+	Field string
+	Op    string
+	Value string
+
+	kind   expressionKind
+	get    func(e *Entry) interface{}
+	number float64
+}
+
+// Match will compare the field of the Entry to the value. Dates and times
+// which are unknown are only matched by !=.
+func (c *comparison) Match(e *Entry) bool {
+	if c.kind == kindString {
+		field := strings.ToLower(c.get(e).(string))
+		value := strings.ToLower(c.Value)
+		switch c.Op {
+		case "==":
+			return field == value
+		case "!=":
+			return field != value
+		case "~":
+			return strings.Contains(field, value)
+		case "!~":
+			return !strings.Contains(field, value)
+		}
+		return false
+	}
+
+	var n float64
+	switch v := c.get(e).(type) {
+	case float64:
+		n = v
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return c.Op == "!="
+		}
+		if c.kind == kindDate {
+			n = float64(day(*v).Unix() / 86400)
+		} else {
+			n = float64(v.Hour()*60 + v.Minute())
+		}
+	}
+	switch c.Op {
+	case "==":
+		return n == c.number
+	case "!=":
+		return n != c.number
+	case "<":
+		return n < c.number
+	case "<=":
+		return n <= c.number
+	case ">":
+		return n > c.number
+	case ">=":
+		return n >= c.number
+	}
+	return false
+}
+
+// String will format the comparison.
+func (c *comparison) String() string {
+	if c.kind == kindString {
+		return fmt.Sprintf("%s %s %s", c.Field, c.Op, strconv.Quote(c.Value))
+	}
+	return fmt.Sprintf("%s %s %s", c.Field, c.Op, c.Value)
+}
+
+// binaryExpression is two expressions joined by && or ||.
+type binaryExpression struct {
+	Op    string
+	Left  Expression
+	Right Expression
+}
+
+// Match will check the Entry against both expressions.
+func (b *binaryExpression) Match(e *Entry) bool {
+	if b.Op == "||" {
+		return b.Left.Match(e) || b.Right.Match(e)
+	}
+	return b.Left.Match(e) && b.Right.Match(e)
+}
+
+// String will format the expressions, parenthesized to keep their
+// precedence.
+func (b *binaryExpression) String() string {
+	return fmt.Sprintf("(%s %s %s)", b.Left.String(), b.Op, b.Right.String())
+}
+
+// notExpression is a negated expression.
+type notExpression struct {
+	Expr Expression
+}
+
+// Match will check the Entry doesn't match the expression.
+func (n *notExpression) Match(e *Entry) bool {
+	return !n.Expr.Match(e)
+}
+
+// String will format the negated expression.
+func (n *notExpression) String() string {
+	return "!" + n.Expr.String()
+}
@@ -0,0 +1,73 @@
+package covidcheck
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExpression will parse filter expressions and check they match static
+// entries as expected, and that mistakes are reported.
+func TestExpression(t *testing.T) {
+	now := time.Date(2021, 10, 10, 12, 0, 0, 0, time.UTC)
+	early := time.Date(2021, 9, 20, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+	start, _ := time.Parse(time.Kitchen, "7:00PM")
+	end, _ := time.Parse(time.Kitchen, "7:30PM")
+
+	entries := Entries{Items: []Entry{
+		{ExposureLocation: "ALDI Belconnen", Suburb: "Belconnen", Contact: "Close", Date: &late, ArrivalTime: &start, DepartureTime: &end},
+		{ExposureLocation: "Coles Belconnen", Suburb: "Belconnen", Contact: "Monitor", Date: &late, ArrivalTime: &start, DepartureTime: &end},
+		{ExposureLocation: "Bus Route 2", Suburb: "Kaleen", Contact: "Casual", Date: &early},
+	}}
+
+	examples := map[string]int{
+		`suburb == "Belconnen" && contact != "Monitor" && date >= 2021-10-01`: 1,
+		`suburb == belconnen || contact == casual`:                            3,
+		`!(suburb == "belconnen")`:                                            1,
+		`location ~ coles || location !~ "aldi"`:                              2,
+		`date < 01/10/2021`:                                                   1,
+		`date >= yesterday`:                                                   0,
+		`start >= 19:00 && end <= 7:30pm`:                                     2,
+		`start != 9:00PM`:                                                     3,
+		`contact == close || contact == casual && suburb == kaleen`:           2,
+		`category == transport`:                                               1,
+		`risk > 0 && risk <= 1`:                                               3,
+	}
+	for in, expected := range examples {
+		t.Run(in, func(t *testing.T) {
+			expr, err := ParseExpression(in, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			covid := &Client{RawResults: entries}
+			covid.Query(&Filter{Expression: expr}, QueryParams{})
+			if len(covid.FilteredResults.Items) != expected {
+				t.Errorf("expected %d results, got %d", expected, len(covid.FilteredResults.Items))
+			}
+			if again, err := ParseExpression(expr.String(), now); err != nil || again.String() != expr.String() {
+				t.Errorf("expected %s to parse again, got %v", expr, err)
+			}
+		})
+	}
+
+	t.Run("Reporting mistakes", func(t *testing.T) {
+		mistakes := map[string]string{
+			`suburb = "Belconnen"`:    "unexpected '='",
+			`venue == aldi`:           "expected a field at 0",
+			`suburb ==`:               "expected a value after suburb ==",
+			`suburb < belconnen`:      "can't be compared with <",
+			`date ~ 2021`:             "for text",
+			`date > soon`:             "could not parse 'soon'",
+			`risk > high`:             "risk is a number",
+			`(suburb == kaleen`:       "expected ')'",
+			`suburb == "kaleen`:       "unterminated string",
+			`suburb == kaleen kaleen`: "unexpected 'kaleen' at 17",
+		}
+		for in, expected := range mistakes {
+			if _, err := ParseExpression(in, now); err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected %q to fail with %q, got %v", in, expected, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package covidcheck
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// faultsEnv is the environment variable which enables fault injection in
+// the fetch path, such as "latency=2s,error=0.5,truncate=0.1,malformed=0.1".
+// It is only meant for testing how covid-check copes with unreliable
+// sources, and should never be set in production.
+const faultsEnv = "COVID_CHECK_FAULTS"
+
+// faultTransport is a http.RoundTripper which injects faults into the
+// responses of the underlying transport. Each probability is between 0
+// and 1.
+type faultTransport struct {
+	// Transport sends the requests which aren't failed outright.
+	Transport http.RoundTripper
+	// Latency is the delay added before each request.
+	Latency time.Duration
+	// Error is the probability of responding 500 without sending the
+	// request.
+	Error float64
+	// Truncate is the probability of cutting the body of a response in
+	// half, ending with an unexpected EOF.
+	Truncate float64
+	// Malformed is the probability of replacing the delimiters of the
+	// second half of the lines of a response, as a broken CSV export would.
+	Malformed float64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// parseFaults will parse a fault specification of comma separated
+// name=value pairs, where latency is a duration and the other faults
+// are probabilities.
+func parseFaults(spec string) (*faultTransport, error) {
+	f := &faultTransport{Transport: defaultTransport{}, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		i := strings.Index(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("faults are formatted as name=value,...: could not parse '%s'", pair)
+		}
+		name, value := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if name == "latency" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse fault latency '%s': %s", value, err.Error())
+			}
+			f.Latency = d
+			continue
+		}
+		p, err := strconv.ParseFloat(value, 64)
+		if err != nil || p < 0 || p > 1 {
+			return nil, fmt.Errorf("fault probabilities are between 0 and 1: could not parse '%s'", pair)
+		}
+		switch name {
+		case "error":
+			f.Error = p
+		case "truncate":
+			f.Truncate = p
+		case "malformed":
+			f.Malformed = p
+		default:
+			return nil, fmt.Errorf("unknown fault '%s', expected one of [latency|error|truncate|malformed]", name)
+		}
+	}
+	return f, nil
+}
+
+// roll will check whether a fault with the probability happens.
+func (f *faultTransport) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rand.Float64() < p
+}
+
+// RoundTrip will send the request with the faults injected.
+func (f *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if f.roll(f.Error) {
+		return &http.Response{
+			Status:     "500 Internal Server Error",
+			StatusCode: http.StatusInternalServerError,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("injected fault")),
+			Request:    req,
+		}, nil
+	}
+
+	truncate := f.roll(f.Truncate)
+	malformed := !truncate && f.roll(f.Malformed)
+	resp, err := f.Transport.RoundTrip(req)
+	if err != nil || (!truncate && !malformed) {
+		return resp, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	if truncate {
+		resp.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body[:len(body)/2]), errReader{io.ErrUnexpectedEOF}))
+		return resp, nil
+	}
+	lines := strings.Split(string(body), "\n")
+	for i := len(lines) / 2; i < len(lines); i++ {
+		lines[i] = strings.NewReplacer(",", ";", "\"", "").Replace(lines[i])
+	}
+	resp.Body = ioutil.NopCloser(strings.NewReader(strings.Join(lines, "\n")))
+	return resp, nil
+}
+
+// errReader is an io.Reader which always fails with its error.
+type errReader struct {
+	err error
+}
+
+// Read will return the error.
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// fetchClient is the http.Client the data of every source is downloaded
+// with, which injects the faults of the faultsEnv environment variable
+// when it is set.
+var fetchClient = newFetchClient()
+
+// newFetchClient will return the http.Client of the fetch path, which
+// decodes compressed responses.
+func newFetchClient() *http.Client {
+	spec := os.Getenv(faultsEnv)
+	if spec == "" {
+		return &http.Client{Transport: &encodingTransport{Transport: defaultTransport{}}}
+	}
+	faults, err := parseFaults(spec)
+	if err != nil {
+		DefaultLogger.Warn("ignoring "+faultsEnv, "error", err)
+		return &http.Client{Transport: &encodingTransport{Transport: defaultTransport{}}}
+	}
+	DefaultLogger.Warn("injecting faults into downloads", "faults", spec)
+	return &http.Client{Transport: &encodingTransport{Transport: faults}}
+}
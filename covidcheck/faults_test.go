@@ -0,0 +1,137 @@
+package covidcheck
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFaults will inject faults into downloads from a static server, and
+// check retries, the cache and the source parsers cope with them.
+func TestFaults(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, vicTestCSV)
+	}))
+	defer server.Close()
+
+	client, policy := fetchClient, DefaultRetryPolicy
+	defer func() { fetchClient, DefaultRetryPolicy = client, policy }()
+	waits := 0
+	DefaultRetryPolicy = &RetryPolicy{Retries: 2, Wait: time.Second, Sleep: func(time.Duration) { waits++ }}
+	inject := func(spec string) *faultTransport {
+		faults, err := parseFaults(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The first roll of this seed is below 0.5 and the second above.
+		faults.rand = rand.New(rand.NewSource(6))
+		fetchClient = &http.Client{Transport: faults}
+		requests, waits = 0, 0
+		return faults
+	}
+
+	t.Run("Parsing specifications", func(t *testing.T) {
+		faults, err := parseFaults("latency=250ms, error=0.5,truncate=1,malformed=0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if faults.Latency != 250*time.Millisecond || faults.Error != 0.5 || faults.Truncate != 1 || faults.Malformed != 0 {
+			t.Errorf("unexpected faults %+v", faults)
+		}
+		for _, spec := range []string{"error", "error=2", "latency=soon", "timeout=1"} {
+			if _, err := parseFaults(spec); err == nil {
+				t.Errorf("expected an error parsing %s", spec)
+			}
+		}
+	})
+
+	t.Run("Retrying server errors", func(t *testing.T) {
+		inject("error=1")
+		if _, err := download("vic", server.URL, nil); err == nil || !strings.Contains(err.Error(), "500") {
+			t.Errorf("expected a 500 error, got %v", err)
+		}
+		if requests != 0 || waits != 2 {
+			t.Errorf("expected 2 retries without reaching the server, got %d retries and %d requests", waits, requests)
+		}
+
+		inject("error=0.5")
+		DefaultRetryPolicy.Retries = 20
+		defer func() { DefaultRetryPolicy.Retries = 2 }()
+		data, err := download("vic", server.URL, nil)
+		if err != nil || string(data) != vicTestCSV {
+			t.Errorf("expected the retries to succeed, got %q %v", data, err)
+		}
+		if requests != 1 || waits != 1 {
+			t.Errorf("expected a retry before reaching the server, got %d retries and %d requests", waits, requests)
+		}
+	})
+
+	t.Run("Failing the ACT page", func(t *testing.T) {
+		inject("error=1")
+		src := &actSource{Endpoint: server.URL}
+		if _, err := src.Fetch(); err == nil || !strings.Contains(err.Error(), "failed to fetch data: 500") {
+			t.Errorf("expected the page error to be returned, got %v", err)
+		}
+	})
+
+	t.Run("Adding latency", func(t *testing.T) {
+		inject("latency=50ms")
+		started := time.Now()
+		if _, err := download("vic", server.URL, nil); err != nil {
+			t.Fatal(err)
+		}
+		if time.Since(started) < 50*time.Millisecond {
+			t.Errorf("expected a delay of 50ms, took %s", time.Since(started))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if _, err := fetchClient.Do(req); err == nil {
+			t.Error("expected the request to be cancelled during the delay")
+		}
+	})
+
+	t.Run("Truncating bodies", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "covid-check-faults")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		cache := &Cache{Dir: dir, TTL: time.Hour}
+
+		inject("truncate=1")
+		if _, err := download("vic", server.URL, cache); err == nil {
+			t.Error("expected an error reading a truncated body")
+		}
+		if _, ok := cache.Get("vic " + server.URL); ok {
+			t.Error("expected a truncated body not to be cached")
+		}
+
+		inject("")
+		if _, err := download("vic", server.URL, cache); err != nil {
+			t.Fatal(err)
+		}
+		inject("error=1")
+		if data, err := download("vic", server.URL, cache); err != nil || string(data) != vicTestCSV {
+			t.Errorf("expected the cached data while the server fails, got %q %v", data, err)
+		}
+	})
+
+	t.Run("Malforming csv", func(t *testing.T) {
+		inject("malformed=1")
+		src := &vicSource{Endpoint: server.URL}
+		if _, err := src.Fetch(); err == nil || !strings.Contains(err.Error(), "could not parse VIC dataset") {
+			t.Errorf("expected a parse error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package covidcheck
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// feedRecord is a line of a ChangeFeed.
+type feedRecord struct {
+	Time   string       `json:"time"`
+	Source string       `json:"source,omitempty"`
+	Change string       `json:"change"`
+	Entry  exportRecord `json:"entry"`
+}
+
+// ChangeFeed is a file of changes with one JSON object per line, which is
+// only appended to so other programs can follow it. When it gets too large
+// or too old it is renamed with the time it was rotated, such as
+// changes.jsonl.20211005T090000Z, and a new file is started.
+type ChangeFeed struct {
+	// Path is the file the changes are appended to.
+	Path string
+	// Source is the name of the source of the changes.
+	Source string
+	// MaxSize is the size in bytes after which the file is rotated, which
+	// isn't limited when it is zero.
+	MaxSize int64
+	// MaxAge is the age of the first change in the file after which it is
+	// rotated, which isn't limited when it is zero.
+	MaxAge time.Duration
+	// Now returns the current time, which defaults to time.Now.
+	Now func() time.Time
+}
+
+// now will return the current time of the ChangeFeed.
+func (f *ChangeFeed) now() time.Time {
+	if f.Now == nil {
+		return time.Now()
+	}
+	return f.Now()
+}
+
+// Append will write a line for each added, updated and removed entry of the
+// changes, rotating the file first when it is due. The entries found by the
+// first poll of a Watcher aren't changes, so they are not written.
+func (f *ChangeFeed) Append(changes Changes) error {
+	if changes.Initial || changes.Len() == 0 {
+		return nil
+	}
+	now := f.now()
+	if err := f.rotate(now); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, group := range []struct {
+		Change  string
+		Entries Entries
+	}{{"added", changes.Added}, {"updated", changes.Updated}, {"removed", changes.Removed}} {
+		for _, record := range exportRecords(group.Entries.Items) {
+			if err := encoder.Encode(feedRecord{
+				Time:   now.UTC().Format(time.RFC3339),
+				Source: f.Source,
+				Change: group.Change,
+				Entry:  record,
+			}); err != nil {
+				file.Close()
+				return err
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// rotate will rename the file when it is larger than MaxSize, or its first
+// change is older than MaxAge.
+func (f *ChangeFeed) rotate(now time.Time) error {
+	info, err := os.Stat(f.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	due := f.MaxSize > 0 && info.Size() >= f.MaxSize
+	if !due && f.MaxAge > 0 {
+		first, err := f.firstTime()
+		if err != nil {
+			return err
+		}
+		due = !first.IsZero() && now.Sub(first) >= f.MaxAge
+	}
+	if !due {
+		return nil
+	}
+	return os.Rename(f.Path, f.Path+"."+now.UTC().Format(snapshotTimeFormat))
+}
+
+// firstTime will return the time of the first change in the file, which is
+// zero when the file is empty or can't be parsed.
+func (f *ChangeFeed) firstTime() (time.Time, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		return time.Time{}, scanner.Err()
+	}
+	record := feedRecord{}
+	if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, record.Time)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return t, nil
+}
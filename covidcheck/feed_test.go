@@ -0,0 +1,78 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestChangeFeed will append static changes to a change feed and check the
+// lines written and when the file is rotated.
+func TestChangeFeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV) {
+		entries.Add(fieldTranslate(record))
+	}
+	changes := Changes{Added: Entries{Items: entries.Items[:1]}, Removed: Entries{Items: entries.Items[1:]}}
+	now := time.Date(2021, 10, 5, 9, 0, 0, 0, time.UTC)
+	feed := &ChangeFeed{Path: filepath.Join(dir, "feed", "changes.jsonl"), Source: "act", MaxAge: 24 * time.Hour, Now: func() time.Time { return now }}
+
+	t.Run("Appending changes", func(t *testing.T) {
+		if err := feed.Append(Changes{Added: entries, Initial: true}); err != nil {
+			t.Fatal(err)
+		}
+		if err := feed.Append(changes); err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadFile(feed.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected a line for each change, got %s", data)
+		}
+		record := feedRecord{}
+		if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Change != "removed" || record.Source != "act" || record.Time != "2021-10-05T09:00:00Z" || record.Entry.Hash != entries.Items[1].Hash() {
+			t.Errorf("unexpected record %+v", record)
+		}
+	})
+
+	t.Run("Rotating by age", func(t *testing.T) {
+		now = now.Add(12 * time.Hour)
+		feed.Append(changes)
+		if matches, _ := filepath.Glob(feed.Path + ".*"); len(matches) != 0 {
+			t.Fatalf("expected no rotation yet, got %v", matches)
+		}
+		now = now.Add(12 * time.Hour)
+		feed.Append(changes)
+		if _, err := os.Stat(feed.Path + ".20211006T090000Z"); err != nil {
+			t.Fatal("expected the feed to be rotated")
+		}
+		if data, _ := ioutil.ReadFile(feed.Path); strings.Count(string(data), "\n") != 2 {
+			t.Errorf("expected a new feed, got %s", data)
+		}
+	})
+
+	t.Run("Rotating by size", func(t *testing.T) {
+		sized := &ChangeFeed{Path: filepath.Join(dir, "sized.jsonl"), MaxSize: 1, Now: feed.Now}
+		sized.Append(changes)
+		sized.Append(changes)
+		if matches, _ := filepath.Glob(sized.Path + ".*"); len(matches) != 1 {
+			t.Errorf("expected the feed to be rotated once, got %v", matches)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package covidcheck
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultFuzzyThreshold is the similarity between 0 and 1 a word must have
+// to a word of a field to match it with Filter.Fuzzy, unless the Filter sets
+// its own FuzzyThreshold. It allows one typo in a word of five letters.
+const DefaultFuzzyThreshold = 0.8
+
+// levenshtein will return the number of letters which are inserted, deleted
+// or replaced to turn a into b, where swapping two neighbouring letters is
+// counted as one typo rather than two.
+func levenshtein(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// min3 will return the smallest of three numbers.
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// similarity will return how alike two words are, from 0 for nothing in
+// common to 1 for the same word, by the Levenshtein distance between them.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// fuzzyWords will split the text into lower case words of letters and
+// digits.
+func fuzzyWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// fuzzyMatch will check whether every word of the pattern is found in the
+// value, either within one of its words or as a word which is at least as
+// similar as the threshold, so "Belconen" matches "Belconnen" and "coles
+// kalleen" matches "Coles Kaleen" in any order.
+func fuzzyMatch(pattern, value string, threshold float64) bool {
+	if strings.Contains(strings.ToLower(value), strings.ToLower(pattern)) {
+		return true
+	}
+	words := fuzzyWords(value)
+	patternWords := fuzzyWords(pattern)
+	if len(patternWords) == 0 {
+		return false
+	}
+	for _, p := range patternWords {
+		found := false
+		for _, w := range words {
+			if strings.Contains(w, p) || similarity(p, w) >= threshold {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
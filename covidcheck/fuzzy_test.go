@@ -0,0 +1,83 @@
+package covidcheck
+
+import (
+	"testing"
+)
+
+// TestFuzzy will check words with typos are matched and unrelated words
+// aren't.
+func TestFuzzy(t *testing.T) {
+	t.Run("Measuring distances", func(t *testing.T) {
+		for _, test := range []struct {
+			A, B     string
+			Distance int
+		}{
+			{"", "", 0},
+			{"kaleen", "", 6},
+			{"belconen", "belconnen", 1},
+			{"kalleen", "kaleen", 1},
+			{"woden", "wodne", 1},
+			{"woden", "nedow", 4},
+			{"holt", "holt", 0},
+		} {
+			if d := levenshtein([]rune(test.A), []rune(test.B)); d != test.Distance {
+				t.Errorf("expected a distance of %d between %q and %q, got %d", test.Distance, test.A, test.B, d)
+			}
+		}
+	})
+
+	t.Run("Matching words", func(t *testing.T) {
+		for _, test := range []struct {
+			Pattern, Value string
+			Match          bool
+		}{
+			{"Belconen", "Belconnen", true},
+			{"coles kalleen", "Coles Kaleen", true},
+			{"kalleen coles", "Coles Kaleen", true},
+			{"belcon", "Westfield Belconnen", true},
+			{"westfeild belconnen", "Westfield Belconnen", true},
+			{"coles kalleen", "ALDI Kaleen", false},
+			{"Holt", "Hall", false},
+			{"", "Kaleen", true},
+			{"!", "Kaleen", false},
+		} {
+			if match := fuzzyMatch(test.Pattern, test.Value, DefaultFuzzyThreshold); match != test.Match {
+				t.Errorf("expected %q matching %q to be %v", test.Pattern, test.Value, test.Match)
+			}
+		}
+		if fuzzyMatch("bel", "Belconnen", 1) != true || fuzzyMatch("Belconen", "Belconnen", 0.95) {
+			t.Error("expected the threshold to decide which typos match")
+		}
+	})
+
+	t.Run("Filtering entries", func(t *testing.T) {
+		entries := Entries{Items: []Entry{
+			{ExposureLocation: "Coles Kaleen", Suburb: "Kaleen"},
+			{ExposureLocation: "ALDI Belconnen", Suburb: "Belconnen"},
+		}}
+		for _, test := range []struct {
+			Filter  Filter
+			Matches int
+		}{
+			{Filter{Suburb: "Belconen"}, 0},
+			{Filter{Suburb: "Belconen", Fuzzy: true}, 1},
+			{Filter{ExposureLocation: "coles kalleen", Fuzzy: true}, 1},
+			{Filter{Suburb: "!Belconen", Fuzzy: true}, 1},
+			{Filter{Suburb: "Belconen", Fuzzy: true, FuzzyThreshold: 0.95}, 0},
+			{Filter{Suburb: "re:^belc", Fuzzy: true}, 1},
+			{Filter{Suburb: "nil", Fuzzy: true}, 0},
+		} {
+			covid := &Client{RawResults: entries}
+			covid.Query(&test.Filter, QueryParams{})
+			if covid.FilteredResults.Len() != test.Matches {
+				t.Errorf("expected %d matches of %+v, got %d", test.Matches, test.Filter, covid.FilteredResults.Len())
+			}
+		}
+	})
+
+	t.Run("Validating thresholds", func(t *testing.T) {
+		if err := (&Filter{Fuzzy: true, FuzzyThreshold: 1.5}).Validate(); err == nil {
+			t.Error("expected a threshold above 1 to be reported")
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NominatimEndpointURL is the OpenStreetMap Nominatim geocoding service.
+var NominatimEndpointURL = "https://nominatim.openstreetmap.org"
+
+// earthRadius is the mean radius of the Earth in kilometres.
+const earthRadius = 6371.0
+
+// Point is a location on the Earth in decimal degrees.
+type Point struct {
+	// Lat is the latitude of the Point.
+	Lat float64
+	// Lon is the longitude of the Point.
+	Lon float64
+}
+
+// ParsePoint will parse a point formatted as "lat,lon", and whether the
+// input was a valid point.
+func ParsePoint(in string) (Point, bool) {
+	parts := strings.Split(in, ",")
+	if len(parts) != 2 {
+		return Point{}, false
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return Point{}, false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return Point{}, false
+	}
+	return Point{Lat: lat, Lon: lon}, true
+}
+
+// Distance will return the great-circle distance between the points in
+// kilometres.
+func Distance(a, b Point) float64 {
+	rad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := rad(b.Lat - a.Lat)
+	dLon := rad(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(a.Lat))*math.Cos(rad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// ParseDistance will parse a distance such as "5km", "500m" or "2.5" into
+// kilometres, where a number without a unit is in kilometres.
+func ParseDistance(in string) (float64, error) {
+	v := strings.ToLower(strings.TrimSpace(in))
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(v, "km"):
+		v = strings.TrimSuffix(v, "km")
+	case strings.HasSuffix(v, "m"):
+		v = strings.TrimSuffix(v, "m")
+		scale = 0.001
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("could not parse distance '%s', expected a value such as 5km or 500m", in)
+	}
+	return d * scale, nil
+}
+
+// Geocoder will find the location of an address.
+type Geocoder interface {
+	// Geocode will return the Point of the address, or an error when it
+	// can't be found.
+	Geocode(address string) (Point, error)
+}
+
+// GeocodeEntry will find the location of the Entry, which is its Point or
+// the location of its Venue when it has been enriched, or else by its full
+// address and then by its suburb alone when the address can't be found.
+func GeocodeEntry(g Geocoder, e *Entry) (Point, error) {
+	if e.Point != nil {
+		return *e.Point, nil
+	}
+	if e.Venue != nil && e.Venue.Point != nil {
+		return *e.Venue.Point, nil
+	}
+	suburb := strings.TrimSpace(e.Suburb + " " + string(e.State))
+	addresses := []string{}
+	for _, address := range []string{strings.Join([]string{e.Street, suburb}, ", "), suburb} {
+		if address = strings.Trim(address, ", "); address != "" {
+			addresses = append(addresses, address+", Australia")
+		}
+	}
+
+	err := fmt.Errorf("no address found for %s", e.ExposureLocation)
+	for _, address := range addresses {
+		var p Point
+		if p, err = g.Geocode(address); err == nil {
+			return p, nil
+		}
+	}
+	return Point{}, err
+}
+
+// NominatimGeocoder is a Geocoder using the Nominatim API. Results are kept
+// in the Cache, and requests are made at most once a second as required by
+// the public service's usage policy.
+type NominatimGeocoder struct {
+	// Endpoint is the base URL of the Nominatim API.
+	Endpoint string
+	// Cache is an optional Cache for the geocoding results.
+	Cache *Cache
+	// UserAgent identifies the application to the service.
+	UserAgent string
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// nominatimPlace is a single result of a Nominatim search.
+type nominatimPlace struct {
+	Lat string `json:"lat"`
+	Lon string `json:"lon"`
+}
+
+// Geocode will search Nominatim for the address.
+func (g *NominatimGeocoder) Geocode(address string) (Point, error) {
+	key := "geocode " + g.Endpoint + " " + normalizeField(address)
+	data, ok := g.Cache.Get(key)
+	if !ok {
+		var err error
+		query := url.Values{}
+		query.Set("q", address)
+		if data, err = g.search(query); err != nil {
+			return Point{}, err
+		}
+		if err := g.Cache.Put(key, data); err != nil {
+			return Point{}, err
+		}
+	}
+
+	places := []nominatimPlace{}
+	if err := json.Unmarshal(data, &places); err != nil {
+		return Point{}, fmt.Errorf("could not parse geocoding result: %s", err.Error())
+	}
+	if len(places) == 0 {
+		return Point{}, fmt.Errorf("no location found for '%s'", address)
+	}
+	p, ok := ParsePoint(places[0].Lat + "," + places[0].Lon)
+	if !ok {
+		return Point{}, fmt.Errorf("invalid location found for '%s'", address)
+	}
+	return p, nil
+}
+
+// search will request the first Nominatim search result for the query.
+func (g *NominatimGeocoder) search(query url.Values) ([]byte, error) {
+	g.mu.Lock()
+	if wait := time.Second - time.Since(g.last); wait > 0 {
+		time.Sleep(wait)
+	}
+	g.last = time.Now()
+	g.mu.Unlock()
+
+	query.Set("format", "json")
+	query.Set("limit", "1")
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(g.Endpoint, "/")+"/search?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if g.UserAgent != "" {
+		req.Header.Set("User-Agent", g.UserAgent)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to geocode '%s': %d %s", query.Get("q"), resp.StatusCode, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,106 @@
+package covidcheck
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// staticGeocoder is a Geocoder with fixed locations for each address.
+type staticGeocoder map[string]Point
+
+func (g staticGeocoder) Geocode(address string) (Point, error) {
+	if p, ok := g[address]; ok {
+		return p, nil
+	}
+	return Point{}, fmt.Errorf("no location found for '%s'", address)
+}
+
+// TestGeo will check distances are parsed and calculated correctly, and
+// the distance filter uses the geocoded locations of entries.
+func TestGeo(t *testing.T) {
+	canberra := Point{Lat: -35.2809, Lon: 149.1300}
+	sydney := Point{Lat: -33.8688, Lon: 151.2093}
+
+	t.Run("Parsing points", func(t *testing.T) {
+		if p, ok := ParsePoint("-35.2809, 149.13"); !ok || p != canberra {
+			t.Fail()
+		}
+		if _, ok := ParsePoint("Canberra ACT"); ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Parsing distances", func(t *testing.T) {
+		examples := map[string]float64{"5km": 5, "500m": 0.5, "2.5": 2.5}
+		for in, expected := range examples {
+			if d, err := ParseDistance(in); err != nil || d != expected {
+				t.Errorf("expected %s to be %v, got %v %v", in, expected, d, err)
+			}
+		}
+		if _, err := ParseDistance("far"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("Calculating distances", func(t *testing.T) {
+		if d := Distance(canberra, sydney); math.Abs(d-248) > 2 {
+			t.Errorf("unexpected distance %v", d)
+		}
+	})
+
+	t.Run("Filtering by distance", func(t *testing.T) {
+		g := staticGeocoder{
+			"Benjamin Way, Belconnen ACT, Australia":   {Lat: -35.2385, Lon: 149.0660},
+			"Phillip ACT, Australia":                   {Lat: -35.3468, Lon: 149.0860},
+			"Monaro Street, Queanbeyan NSW, Australia": {Lat: -35.3533, Lon: 149.2343},
+		}
+		covid := &Client{RawResults: Entries{Items: []Entry{
+			{ExposureLocation: "ALDI Belconnen", Street: "Benjamin Way", Suburb: "Belconnen", State: "ACT"},
+			{ExposureLocation: "Westfield Woden", Street: "Keltie Street", Suburb: "Phillip", State: "ACT"},
+			{ExposureLocation: "Woolworths Queanbeyan", Street: "Monaro Street", Suburb: "Queanbeyan", State: "NSW"},
+			{ExposureLocation: "Somewhere", Street: "Nowhere", Suburb: "Unknown", State: "ACT"},
+		}}}
+		covid.Query(&Filter{Near: &Point{Lat: -35.3, Lon: 149.08}, Radius: 8, Geocoder: g}, QueryParams{})
+		if len(covid.FilteredResults.Items) != 2 || covid.FilteredResults.Items[1].ExposureLocation != "Westfield Woden" {
+			t.Errorf("unexpected results %v", covid.FilteredResults.Items)
+		}
+	})
+
+	t.Run("Geocoding with nominatim", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if r.URL.Path != "/search" || r.URL.Query().Get("q") != "Phillip ACT, Australia" || r.Header.Get("User-Agent") == "" {
+				t.Errorf("unexpected request %s", r.URL)
+			}
+			fmt.Fprint(w, `[{"lat": "-35.3468", "lon": "149.0860"}]`)
+		}))
+		defer server.Close()
+
+		dir, err := ioutil.TempDir("", "covid-check-geo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		g := &NominatimGeocoder{Endpoint: server.URL, Cache: &Cache{Dir: dir, TTL: time.Hour}}
+		for i := 0; i < 2; i++ {
+			p, err := g.Geocode("Phillip ACT, Australia")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Lat != -35.3468 || p.Lon != 149.0860 {
+				t.Errorf("unexpected point %v", p)
+			}
+		}
+		if requests != 1 {
+			t.Errorf("expected the second lookup to be cached, got %d requests", requests)
+		}
+	})
+}
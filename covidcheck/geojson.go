@@ -0,0 +1,103 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// geoJSONCollection is a GeoJSON FeatureCollection of exposure sites.
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// geoJSONFeature is an exposure site in a GeoJSON export, with the fields of
+// its JSON export as properties.
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	Geometry   *geoJSONGeometry  `json:"geometry"`
+	Properties geoJSONProperties `json:"properties"`
+}
+
+// geoJSONGeometry is the location of a geoJSONFeature.
+type geoJSONGeometry struct {
+	Type string `json:"type"`
+	// Coordinates are the longitude and latitude, in that order.
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// geoJSONProperties are the properties of a geoJSONFeature, which are the
+// fields of the JSON export and how precisely the site was located.
+type geoJSONProperties struct {
+	exportRecord
+	// Located is "point" when the site was geocoded, "suburb" when it is
+	// placed at the centre of the sites found in its suburb, or "none".
+	Located string `json:"located"`
+}
+
+// exportGeoJSON will write the items as a GeoJSON FeatureCollection. Sites
+// are placed at their Point or the Point of their Venue, and otherwise at
+// the centroid of the located sites in the same suburb. Sites which can't be
+// placed at all are kept with a null geometry, so no results go missing.
+func exportGeoJSON(w io.Writer, items []Entry) error {
+	centroids := suburbCentroids(items)
+	records := exportRecords(items)
+	collection := geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	for i := range items {
+		feature := geoJSONFeature{Type: "Feature", Properties: geoJSONProperties{exportRecord: records[i], Located: "none"}}
+		if p := entryPoint(&items[i]); p != nil {
+			feature.Geometry = &geoJSONGeometry{Type: "Point", Coordinates: [2]float64{p.Lon, p.Lat}}
+			feature.Properties.Located = "point"
+		} else if p, ok := centroids[suburbKey(&items[i])]; ok {
+			feature.Geometry = &geoJSONGeometry{Type: "Point", Coordinates: [2]float64{p.Lon, p.Lat}}
+			feature.Properties.Located = "suburb"
+		}
+		collection.Features = append(collection.Features, feature)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
+
+// entryPoint will return the location of the Entry, which is its Point or
+// the Point of its Venue, or nil when it hasn't been located.
+func entryPoint(e *Entry) *Point {
+	if e.Point != nil {
+		return e.Point
+	}
+	if e.Venue != nil && e.Venue.Point != nil {
+		return e.Venue.Point
+	}
+	return nil
+}
+
+// suburbKey will return the normalized suburb and state of the Entry, which
+// is empty when it has no suburb.
+func suburbKey(e *Entry) string {
+	if normalizeField(e.Suburb) == "" {
+		return ""
+	}
+	return normalizeField(e.Suburb) + "|" + normalizeField(string(e.State))
+}
+
+// suburbCentroids will return the average location of the located items in
+// each suburb, by suburbKey.
+func suburbCentroids(items []Entry) map[string]Point {
+	sums := map[string]Point{}
+	counts := map[string]int{}
+	for i := range items {
+		key, p := suburbKey(&items[i]), entryPoint(&items[i])
+		if key == "" || p == nil {
+			continue
+		}
+		sum := sums[key]
+		sums[key] = Point{Lat: sum.Lat + p.Lat, Lon: sum.Lon + p.Lon}
+		counts[key]++
+	}
+	centroids := map[string]Point{}
+	for key, sum := range sums {
+		n := float64(counts[key])
+		centroids[key] = Point{Lat: sum.Lat / n, Lon: sum.Lon / n}
+	}
+	return centroids
+}
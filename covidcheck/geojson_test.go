@@ -0,0 +1,63 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestExportGeoJSON will export static entries as GeoJSON, and check the
+// located entries are points, the others in their suburb are placed at its
+// centroid and the rest are kept without a geometry.
+func TestExportGeoJSON(t *testing.T) {
+	covid := &Client{}
+	for _, record := range readCSV(actTestCSV) {
+		e := fieldTranslate(record)
+		covid.AddFiltered(&e)
+	}
+	extra := covid.FilteredResults.Items[0]
+	extra.ExposureLocation = "Another venue"
+	covid.AddFiltered(&extra)
+	covid.FilteredResults.Items[0].Point = &Point{Lat: -35.2, Lon: 149.1}
+	covid.FilteredResults.Items[1].Suburb = "Nowhere"
+
+	var buf bytes.Buffer
+	if err := covid.Export(&buf, "geojson", false); err != nil {
+		t.Fatal(err)
+	}
+	collection := struct {
+		Type     string
+		Features []struct {
+			Type     string
+			Geometry *struct {
+				Type        string
+				Coordinates []float64
+			}
+			Properties map[string]string
+		}
+	}{}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 3 {
+		t.Fatalf("unexpected collection %s", buf.String())
+	}
+
+	want := []string{"point", "none", "suburb"}
+	for i, f := range collection.Features {
+		if f.Type != "Feature" || f.Properties["located"] != want[i] {
+			t.Errorf("expected feature %d to be located by %s, got %+v", i, want[i], f)
+		}
+		if (f.Geometry == nil) != (want[i] == "none") {
+			t.Errorf("unexpected geometry of feature %d: %+v", i, f.Geometry)
+		}
+		if f.Properties["location"] != covid.FilteredResults.Items[i].ExposureLocation || f.Properties["hash"] == "" {
+			t.Errorf("expected the export fields as properties, got %v", f.Properties)
+		}
+	}
+	for _, i := range []int{0, 2} {
+		if c := collection.Features[i].Geometry.Coordinates; len(c) != 2 || c[0] != 149.1 || c[1] != -35.2 {
+			t.Errorf("expected longitude then latitude, got %v", c)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package covidcheck
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update will rewrite the golden files with the current output, instead of
+// comparing against them.
+var update = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+// TestGolden will run the fixture dataset through every renderer and export
+// format, and compare the output against the golden files, so the formats
+// scripts depend on only change on purpose. Run go test -run TestGolden
+// -update to accept a change.
+func TestGolden(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "golden", "fixture.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	covid := &Client{}
+	for _, record := range readCSV(string(data)) {
+		e := fieldTranslate(record)
+		covid.AddFiltered(&e)
+	}
+
+	previous := covid.FilteredResults
+	previous.Items = previous.Items[1:]
+	current := Entries{Items: append([]Entry{}, covid.FilteredResults.Items...)}
+	current.Items[2].Contact = ContactClose
+	changes := Diff(previous, current)
+
+	renderers := map[string]func(w *bytes.Buffer) error{
+		"table.txt": func(w *bytes.Buffer) error {
+			covid.Render(w, RenderParams{Width: 30})
+			return nil
+		},
+		"table-columns.txt": func(w *bytes.Buffer) error {
+			covid.Render(w, RenderParams{Width: 12, Risk: true, Slug: true, Hours: true, Emoji: true, Truncate: true, Footnotes: true})
+			return nil
+		},
+		"table.md": func(w *bytes.Buffer) error {
+			covid.Render(w, RenderParams{Markdown: true})
+			return nil
+		},
+		"export.csv":           func(w *bytes.Buffer) error { return covid.Export(w, "csv", false) },
+		"export-canonical.csv": func(w *bytes.Buffer) error { return covid.Export(w, "csv", true) },
+		"export.json":          func(w *bytes.Buffer) error { return covid.Export(w, "json", true) },
+		"export.html":          func(w *bytes.Buffer) error { return covid.Export(w, "html", true) },
+		"export.geojson":       func(w *bytes.Buffer) error { return covid.Export(w, "geojson", true) },
+		"entry.txt": func(w *bytes.Buffer) error {
+			RenderEntry(w, &covid.FilteredResults.Items[2])
+			return nil
+		},
+		"changes.txt": func(w *bytes.Buffer) error {
+			RenderChanges(w, changes, 30)
+			return nil
+		},
+		"changes.csv":  func(w *bytes.Buffer) error { return ExportChangesCSV(w, changes) },
+		"changes.json": func(w *bytes.Buffer) error { return ExportChanges(w, changes) },
+		"notification.txt": func(w *bytes.Buffer) error {
+			_, err := w.WriteString(notificationText(changes, true) + "\n")
+			return err
+		},
+	}
+
+	for name, render := range renderers {
+		t.Run("Rendering "+name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := render(&buf); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", "golden", name)
+			if *update {
+				if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(path)
+			if os.IsNotExist(err) {
+				t.Fatalf("missing golden file %s, run go test -run TestGolden -update", path)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output differs from %s, run go test -run TestGolden -update if this is intended:\n%s", path, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// Guidance is the official health advice for people at an exposure site.
+type Guidance struct {
+	// Text is what people should do.
+	Text string `json:"text"`
+	// URL is where the full advice can be found.
+	URL string `json:"url,omitempty"`
+}
+
+// String will return the guidance text followed by its URL.
+func (g Guidance) String() string {
+	return strings.TrimSpace(g.Text + " " + g.URL)
+}
+
+// GuidanceMap is the Guidance for each lowercase contact level. A key can be
+// prefixed with a lowercase state such as "nsw/close", which is preferred
+// over the contact level alone for exposure sites in that state.
+type GuidanceMap map[string]Guidance
+
+// DefaultGuidance is the GuidanceMap used in the detail view, notifications
+// and reports, which can be replaced to change the advice everywhere.
+var DefaultGuidance = GuidanceMap{
+	"close": {
+		Text: "Quarantine for 14 days from the exposure, get tested immediately and again when told to, even without symptoms.",
+		URL:  "https://www.covid19.act.gov.au/",
+	},
+	"casual": {
+		Text: "Get tested immediately and quarantine until you receive a negative result.",
+		URL:  "https://www.covid19.act.gov.au/",
+	},
+	"monitor": {
+		Text: "Monitor for symptoms, and get tested and isolate if any develop.",
+		URL:  "https://www.covid19.act.gov.au/",
+	},
+	"nsw/close": {
+		Text: "Get tested immediately and self-isolate for 14 days from the exposure, regardless of the result.",
+		URL:  "https://www.nsw.gov.au/covid-19",
+	},
+	"nsw/casual": {
+		Text: "Get tested immediately and self-isolate until you receive a negative result.",
+		URL:  "https://www.nsw.gov.au/covid-19",
+	},
+	"nsw/monitor": {
+		Text: "Monitor for symptoms, and get tested and isolate if any develop.",
+		URL:  "https://www.nsw.gov.au/covid-19",
+	},
+}
+
+// LoadGuidance will read a JSON object of guidance keyed by contact level,
+// such as {"casual": {"text": "...", "url": "..."}}, which replaces the
+// DefaultGuidance of the contact levels it includes.
+func LoadGuidance(path string) (GuidanceMap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := GuidanceMap{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse guidance: %s", err.Error())
+	}
+
+	guidance := GuidanceMap{}
+	for k, v := range DefaultGuidance {
+		guidance[k] = v
+	}
+	for k, v := range file {
+		guidance[strings.ToLower(k)] = v
+	}
+	return guidance, nil
+}
+
+// For will return the Guidance for the contact level of the Entry, and
+// whether there is any.
+func (g GuidanceMap) For(e *Entry) (Guidance, bool) {
+	contact := strings.ToLower(string(e.Contact))
+	if guidance, ok := g[strings.ToLower(string(e.State))+"/"+contact]; ok && e.State != "" {
+		return guidance, true
+	}
+	guidance, ok := g[contact]
+	return guidance, ok && contact != ""
+}
+
+// Guidance will return the DefaultGuidance for the contact level of the
+// Entry, and whether there is any.
+func (e *Entry) Guidance() (Guidance, bool) {
+	return DefaultGuidance.For(e)
+}
+
+// entryGuidance will return the Guidance of each lowercase contact level of
+// the entries.
+func entryGuidance(entries ...Entries) map[string]Guidance {
+	found := map[string]Guidance{}
+	for _, group := range entries {
+		for i := range group.Items {
+			if guidance, ok := group.Items[i].Guidance(); ok {
+				found[strings.ToLower(string(group.Items[i].Contact))] = guidance
+			}
+		}
+	}
+	return found
+}
+
+// guidanceText will format the guidance of the contact levels of the
+// entries as lines of a notification message, most severe first.
+func guidanceText(entries ...Entries) string {
+	found := entryGuidance(entries...)
+	order := []string{}
+	for contact := range found {
+		order = append(order, contact)
+	}
+	rank := func(contact string) int {
+		for n, c := range []string{"close", "casual", "monitor"} {
+			if c == contact {
+				return n
+			}
+		}
+		return 3
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if rank(order[i]) != rank(order[j]) {
+			return rank(order[i]) < rank(order[j])
+		}
+		return order[i] < order[j]
+	})
+
+	var b strings.Builder
+	for _, contact := range order {
+		fmt.Fprintf(&b, "\n%s%s contacts: %s", strings.ToUpper(contact[:1]), contact[1:], found[contact].String())
+	}
+	return b.String()
+}
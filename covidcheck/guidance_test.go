@@ -0,0 +1,63 @@
+package covidcheck
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGuidance will look up the guidance of static entries and check it is
+// configurable and included where the advice is shown.
+func TestGuidance(t *testing.T) {
+	act := Entry{ExposureLocation: "ALDI Belconnen", State: "ACT", Contact: "Casual"}
+	nsw := Entry{ExposureLocation: "Coles Parramatta", State: "NSW", Contact: "Close"}
+
+	t.Run("Looking up guidance", func(t *testing.T) {
+		if g, ok := act.Guidance(); !ok || g != DefaultGuidance["casual"] {
+			t.Errorf("unexpected guidance %v", g)
+		}
+		if g, ok := nsw.Guidance(); !ok || g != DefaultGuidance["nsw/close"] {
+			t.Errorf("expected the nsw guidance, got %v", g)
+		}
+		if _, ok := (&Entry{State: "ACT"}).Guidance(); ok {
+			t.Error("expected no guidance without a contact level")
+		}
+	})
+
+	t.Run("Loading guidance", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "covid-check-guidance")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "guidance.json")
+		ioutil.WriteFile(path, []byte(`{"Casual": {"text": "Stay home.", "url": "https://example.org"}}`), 0644)
+		loaded, err := LoadGuidance(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g, _ := loaded.For(&act); g.String() != "Stay home. https://example.org" {
+			t.Errorf("unexpected guidance %v", g)
+		}
+		if _, ok := loaded.For(&nsw); !ok || DefaultGuidance["casual"].Text == "Stay home." {
+			t.Error("loading guidance changed the defaults")
+		}
+	})
+
+	t.Run("Formatting guidance", func(t *testing.T) {
+		text := guidanceText(Entries{Items: []Entry{act, nsw, act}})
+		if strings.Count(text, "\n") != 2 || !strings.HasPrefix(text, "\nClose contacts: ") || !strings.Contains(text, "\nCasual contacts: ") {
+			t.Errorf("unexpected guidance %q", text)
+		}
+
+		var b bytes.Buffer
+		RenderEntry(&b, &act)
+		if !strings.Contains(b.String(), "Guidance: "+DefaultGuidance["casual"].Text) {
+			t.Errorf("expected guidance in the detail view %q", b.String())
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package covidcheck
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// highlightOn and highlightOff underline the matched parts of a cell,
+	// without resetting the colour of the contact level around them.
+	highlightOn  = "\x1b[4m"
+	highlightOff = "\x1b[24m"
+)
+
+// highlightPatterns will return the inputs of the filter which are matched
+// against the column, being the filter of the field and the terms of the
+// arbitrary queries scoped to the column or to none.
+func (f *Filter) highlightPatterns(column string) []string {
+	patterns := map[string]string{
+		"status":   f.Status,
+		"location": f.ExposureLocation,
+		"street":   f.Street,
+		"suburb":   f.Suburb,
+		"state":    f.State,
+		"contact":  f.Contact,
+		"trust":    f.Trust,
+	}
+	pattern, ok := patterns[column]
+	if !ok {
+		return nil
+	}
+	found := []string{pattern}
+	for _, terms := range parseQueries(f.Queries) {
+		for _, term := range terms {
+			if !term.Negate && (term.Field == "" || term.Field == column) {
+				found = append(found, term.Pattern)
+			}
+		}
+	}
+	return found
+}
+
+// matchRanges will return the byte ranges of the value matched by the
+// pattern, which are the matched words when matched fuzzily. Negated
+// patterns, "nil" and invalid expressions match nothing to highlight.
+func matchRanges(pattern, value string, regex, fuzzy bool, threshold float64) [][]int {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "!") || strings.ToLower(pattern) == "nil" || pattern == "" {
+		return nil
+	}
+	literal := !regex && !strings.HasPrefix(pattern, regexPrefix)
+	expression := strings.TrimPrefix(pattern, regexPrefix)
+	if literal {
+		expression = regexp.QuoteMeta(pattern)
+	}
+	re, err := compilePattern(expression)
+	if err != nil {
+		return nil
+	}
+	ranges := [][]int{}
+	for _, r := range re.FindAllStringIndex(value, -1) {
+		if r[1] > r[0] {
+			ranges = append(ranges, r)
+		}
+	}
+	if len(ranges) > 0 || !fuzzy || !literal {
+		return ranges
+	}
+
+	// Words of the value are highlighted when they are like a word of the
+	// pattern.
+	patternWords := fuzzyWords(pattern)
+	start := -1
+	for i, r := range value + " " {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+		word := strings.ToLower(value[start:i])
+		for _, p := range patternWords {
+			if similarity(p, word) >= threshold {
+				ranges = append(ranges, []int{start, i})
+				break
+			}
+		}
+		start = -1
+	}
+	return ranges
+}
+
+// highlight will underline the parts of the value in the ranges. Each word
+// is underlined on its own, so the underlining of a cell wrapped over
+// several lines doesn't run into the borders of the table.
+func highlight(value string, ranges [][]int) string {
+	if len(ranges) == 0 {
+		return value
+	}
+	marked := make([]bool, len(value))
+	for _, r := range ranges {
+		for i := r[0]; i < r[1] && i < len(value); i++ {
+			marked[i] = value[i] != ' '
+		}
+	}
+	var b strings.Builder
+	on := false
+	for i := 0; i < len(value); i++ {
+		if marked[i] != on {
+			on = marked[i]
+			if on {
+				b.WriteString(highlightOn)
+			} else {
+				b.WriteString(highlightOff)
+			}
+		}
+		b.WriteByte(value[i])
+	}
+	if on {
+		b.WriteString(highlightOff)
+	}
+	return b.String()
+}
+
+// highlightCell will underline the parts of the value of the column which
+// were matched by the filter.
+func (f *Filter) highlightCell(column, value string) string {
+	threshold := f.FuzzyThreshold
+	if threshold == 0 {
+		threshold = DefaultFuzzyThreshold
+	}
+	// Only the filters naming places are matched fuzzily, not the queries
+	// after them.
+	fuzzy := f.Fuzzy && (column == "location" || column == "street" || column == "suburb")
+	ranges := [][]int{}
+	for n, pattern := range f.highlightPatterns(column) {
+		ranges = append(ranges, matchRanges(pattern, value, f.Regex, fuzzy && n == 0, threshold)...)
+	}
+	return highlight(value, ranges)
+}
@@ -0,0 +1,74 @@
+package covidcheck
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// TestHighlight will check the parts of cells matched by filters are
+// underlined, and tables with them stay aligned.
+func TestHighlight(t *testing.T) {
+	t.Run("Underlining matches", func(t *testing.T) {
+		for _, test := range []struct {
+			Filter  Filter
+			Column  string
+			Value   string
+			Expects string
+		}{
+			{Filter{Suburb: "kal"}, "suburb", "Kaleen", underlined("Kal") + "een"},
+			{Filter{Suburb: "kal"}, "location", "Kaleen Plaza", "Kaleen Plaza"},
+			{Filter{ExposureLocation: "coles kaleen"}, "location", "Coles Kaleen", underlined("Coles") + " " + underlined("Kaleen")},
+			{Filter{ExposureLocation: "re:^a.di"}, "location", "ALDI Aldi", underlined("ALDI") + " Aldi"},
+			{Filter{ExposureLocation: "plaza|shop", Regex: true}, "location", "Shop 5 Plaza", underlined("Shop") + " 5 " + underlined("Plaza")},
+			{Filter{ExposureLocation: "coles kalleen", Fuzzy: true}, "location", "Coles Kaleen", underlined("Coles") + " " + underlined("Kaleen")},
+			{Filter{Queries: []string{"bel"}, Fuzzy: true}, "suburb", "Belconnen", underlined("Bel") + "connen"},
+			{Filter{Queries: []string{"belconen"}, Fuzzy: true}, "suburb", "Belconnen", "Belconnen"},
+			{Filter{Suburb: "!kaleen"}, "suburb", "Kaleen", "Kaleen"},
+			{Filter{Suburb: "nil"}, "suburb", "", ""},
+			{Filter{Contact: "close"}, "contact", "Close", underlined("Close")},
+			{Filter{Contact: "close"}, "time", "1-9-2021 close", "1-9-2021 close"},
+			{Filter{Street: "["}, "street", "Shop [5]", "Shop " + underlined("[") + "5]"},
+			{Filter{Street: "[", Regex: true}, "street", "Shop [5]", "Shop [5]"},
+		} {
+			if v := test.Filter.highlightCell(test.Column, test.Value); v != test.Expects {
+				t.Errorf("expected %+v to highlight %s %q as %q, got %q", test.Filter, test.Column, test.Value, test.Expects, v)
+			}
+		}
+	})
+
+	t.Run("Rendering highlights", func(t *testing.T) {
+		covid := &Client{}
+		for _, record := range readCSV(actTestCSV) {
+			e := fieldTranslate(record)
+			covid.RawResults.Add(e)
+		}
+		covid.Query(&Filter{ExposureLocation: "kaleen plaza"}, QueryParams{})
+
+		var buf bytes.Buffer
+		covid.Render(&buf, RenderParams{Width: 8, Color: true, Highlight: true})
+		if !strings.Contains(buf.String(), underlined("Kaleen")) || !strings.Contains(buf.String(), underlined("Plaza")) {
+			t.Errorf("expected the location to be highlighted in %q", buf.String())
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		for _, line := range lines[:len(lines)-1] {
+			if w := tablewriter.DisplayWidth(line); w != tablewriter.DisplayWidth(lines[0]) {
+				t.Errorf("expected the table to stay aligned, got %q", line)
+			}
+		}
+
+		buf.Reset()
+		covid.Render(&buf, RenderParams{Width: 50, Color: true, Highlight: true, Markdown: true})
+		covid.Render(&buf, RenderParams{Width: 50})
+		if strings.Contains(buf.String(), highlightOn) {
+			t.Errorf("expected no highlights in %q", buf.String())
+		}
+	})
+}
+
+// underlined will return the text underlined like a highlight.
+func underlined(s string) string {
+	return highlightOn + s + highlightOff
+}
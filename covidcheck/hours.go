@@ -0,0 +1,169 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// HoursRange is a period a venue is open, in minutes after midnight. When
+// Close is before Open, the venue is open overnight.
+type HoursRange struct {
+	Open  int
+	Close int
+}
+
+// contains will check whether the minute of the day is within the range,
+// inclusive of the opening and closing times.
+func (r HoursRange) contains(minute int) bool {
+	if r.Close < r.Open {
+		return minute >= r.Open || minute <= r.Close
+	}
+	return minute >= r.Open && minute <= r.Close
+}
+
+// ParseHours will parse opening hours such as "08:00-21:00", several ranges
+// such as "11:30-14:30,17:00-22:00", or "24/7". A day prefix in the style of
+// OpenStreetMap, such as "Mo-Fr 08:00-21:00; Sa 09:00-17:00; Su off", is
+// accepted but the days are ignored, so every range applies to every day.
+func ParseHours(value string) ([]HoursRange, error) {
+	ranges := []HoursRange{}
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		span := fields[len(fields)-1]
+		if span == "off" || span == "closed" {
+			continue
+		}
+		if span == "24/7" {
+			ranges = append(ranges, HoursRange{Open: 0, Close: 24 * 60})
+			continue
+		}
+		i := strings.Index(span, "-")
+		if i < 0 {
+			return nil, fmt.Errorf("opening hours are formatted as HH:MM-HH:MM: could not parse '%s'", part)
+		}
+		opening, err := parseMinute(span[:i])
+		if err != nil {
+			return nil, err
+		}
+		closing, err := parseMinute(span[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, HoursRange{Open: opening, Close: closing})
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no opening hours found in '%s'", value)
+	}
+	return ranges, nil
+}
+
+// parseMinute will parse a time of day formatted as HH:MM, where "24:00" is
+// midnight at the end of the day, into minutes after midnight.
+func parseMinute(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("opening hours are formatted as HH:MM-HH:MM: could not parse '%s'", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// OpeningHours are the usual opening hours of venues, keyed by a lowercase
+// part of the venue name or a venue category.
+type OpeningHours map[string][]HoursRange
+
+// DefaultOpeningHours are the OpeningHours used to find implausible exposure
+// windows, which can be replaced to change the hours everywhere. Venues in
+// categories without hours, such as transport, are always plausible.
+var DefaultOpeningHours = OpeningHours{
+	"pharmacy":    {{Open: 7 * 60, Close: 22 * 60}},
+	"supermarket": {{Open: 6 * 60, Close: 24 * 60}},
+	"education":   {{Open: 6 * 60, Close: 20 * 60}},
+	"gym":         {{Open: 4 * 60, Close: 24 * 60}},
+	"dining":      {{Open: 5 * 60, Close: 24 * 60}},
+	"nightlife":   {{Open: 10 * 60, Close: 5 * 60}},
+}
+
+// LoadOpeningHours will read a JSON object of opening hours, such as
+// {"pharmacy": "08:00-21:00", "aldi belconnen": "08:30-20:00"}, which
+// replaces the DefaultOpeningHours of the keys it includes.
+func LoadOpeningHours(path string) (OpeningHours, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := map[string]string{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse opening hours: %s", err.Error())
+	}
+
+	hours := OpeningHours{}
+	for k, v := range DefaultOpeningHours {
+		hours[k] = v
+	}
+	for k, v := range file {
+		ranges, err := ParseHours(v)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse opening hours of %s: %s", k, err.Error())
+		}
+		hours[strings.ToLower(k)] = ranges
+	}
+	return hours, nil
+}
+
+// For will return the opening hours of the venue of the Entry, preferring
+// the longest key which is part of its name over the opening hours of its
+// enriched Venue, and those over its venue category.
+func (h OpeningHours) For(e *Entry) ([]HoursRange, bool) {
+	name := strings.ToLower(e.ExposureLocation)
+	best := ""
+	for k := range h {
+		if strings.Contains(name, k) && len(k) > len(best) {
+			best = k
+		}
+	}
+	if best != "" {
+		return h[best], true
+	}
+	if e.Venue != nil && e.Venue.OpeningHours != "" {
+		if ranges, err := ParseHours(e.Venue.OpeningHours); err == nil {
+			return ranges, true
+		}
+	}
+	ranges, ok := h[e.VenueCategory()]
+	return ranges, ok
+}
+
+// Plausible will check whether the exposure window of the Entry starts and
+// ends while its venue is usually open. Entries without a window or
+// without known opening hours are always plausible.
+func (h OpeningHours) Plausible(e *Entry) bool {
+	ranges, ok := h.For(e)
+	if !ok || e.ArrivalTime == nil || e.DepartureTime == nil || e.ArrivalTime.IsZero() || e.DepartureTime.IsZero() {
+		return true
+	}
+	open := func(t *time.Time) bool {
+		minute := t.Hour()*60 + t.Minute()
+		for _, r := range ranges {
+			if r.contains(minute) {
+				return true
+			}
+		}
+		return false
+	}
+	return open(e.ArrivalTime) && open(e.DepartureTime)
+}
+
+// PlausibleHours will check the exposure window of the Entry against the
+// DefaultOpeningHours.
+func (e *Entry) PlausibleHours() bool {
+	return DefaultOpeningHours.Plausible(e)
+}
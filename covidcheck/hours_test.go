@@ -0,0 +1,95 @@
+package covidcheck
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOpeningHours will check static exposure windows against opening hours
+// and check implausible windows are flagged.
+func TestOpeningHours(t *testing.T) {
+	at := func(kitchen string) *time.Time {
+		t, _ := time.Parse(time.Kitchen, kitchen)
+		return &t
+	}
+	date := time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+	pharmacy := Entry{ExposureLocation: "Kaleen Plaza Pharmacy", Date: &date, ArrivalTime: at("2:00AM"), DepartureTime: at("2:30AM")}
+	shopping := Entry{ExposureLocation: "ALDI Belconnen", Date: &date, ArrivalTime: at("7:00PM"), DepartureTime: at("7:30PM")}
+	pub := Entry{ExposureLocation: "The Old Canberra Inn", ArrivalTime: at("11:00PM"), DepartureTime: at("1:00AM")}
+	bus := Entry{ExposureLocation: "Bus Route 2", ArrivalTime: at("3:00AM"), DepartureTime: at("3:30AM")}
+
+	t.Run("Parsing hours", func(t *testing.T) {
+		ranges, err := ParseHours("Mo-Fr 08:00-12:00, 13:00-24:00; Sa 22:00-02:00")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ranges) != 3 || ranges[1].Close != 24*60 || !ranges[2].contains(60) || ranges[0].contains(12*60+30) {
+			t.Errorf("unexpected ranges %v", ranges)
+		}
+		if ranges, _ := ParseHours("24/7"); len(ranges) != 1 || !ranges[0].contains(0) {
+			t.Errorf("unexpected ranges %v", ranges)
+		}
+		for _, value := range []string{"", "8am", "08:00-25:00"} {
+			if _, err := ParseHours(value); err == nil {
+				t.Errorf("expected an error parsing %q", value)
+			}
+		}
+	})
+
+	t.Run("Checking windows", func(t *testing.T) {
+		if pharmacy.PlausibleHours() {
+			t.Error("expected 2am at a pharmacy to be implausible")
+		}
+		if !shopping.PlausibleHours() || !pub.PlausibleHours() || !bus.PlausibleHours() {
+			t.Error("expected the windows to be plausible")
+		}
+		if !(&Entry{ExposureLocation: "Kaleen Plaza Pharmacy"}).PlausibleHours() {
+			t.Error("expected entries without a window to be plausible")
+		}
+	})
+
+	t.Run("Loading hours", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "covid-check-hours")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "hours.json")
+		ioutil.WriteFile(path, []byte(`{"ALDI Belconnen": "08:30-18:00", "pharmacy": "24/7"}`), 0644)
+		loaded, err := LoadOpeningHours(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Plausible(&shopping) || !loaded.Plausible(&pharmacy) {
+			t.Error("expected the loaded hours to be used")
+		}
+		if DefaultOpeningHours.Plausible(&pharmacy) {
+			t.Error("loading hours changed the defaults")
+		}
+		ioutil.WriteFile(path, []byte(`{"pharmacy": "all day"}`), 0644)
+		if _, err := LoadOpeningHours(path); err == nil {
+			t.Error("expected an error loading invalid hours")
+		}
+	})
+
+	t.Run("Flagging windows", func(t *testing.T) {
+		covid := &Client{FilteredResults: Entries{Items: []Entry{pharmacy, shopping}}}
+		var b bytes.Buffer
+		covid.Render(&b, RenderParams{Width: 50, Hours: true})
+		if strings.Count(b.String(), "implausible") != 1 {
+			t.Errorf("expected one implausible window %q", b.String())
+		}
+
+		b.Reset()
+		RenderEntry(&b, &pharmacy)
+		if !strings.Contains(b.String(), "Warning:") {
+			t.Errorf("expected a warning in the detail view %q", b.String())
+		}
+	})
+}
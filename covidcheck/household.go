@@ -0,0 +1,84 @@
+package covidcheck
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Person is a member of a household with their own visit history.
+type Person struct {
+	// Name is the name of the person.
+	Name string
+	// Visits are the places the person has been.
+	Visits []Visit
+}
+
+// PersonExposures are the possible exposures of a Person.
+type PersonExposures struct {
+	// Person is the name of the person.
+	Person string
+	// Exposures are the visits of the person which overlap with an
+	// exposure site.
+	Exposures []Exposure
+	// Risk is the combined risk of the exposures.
+	Risk float64
+}
+
+// LoadPerson will load the visit history given as "name=path", or as a path
+// alone where the person is named after the file.
+func LoadPerson(spec string) (Person, error) {
+	name, path := "", spec
+	if i := strings.Index(spec, "="); i > 0 {
+		name, path = spec[:i], spec[i+1:]
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	visits, err := LoadVisits(path)
+	if err != nil {
+		return Person{}, err
+	}
+	return Person{Name: name, Visits: visits}, nil
+}
+
+// CheckHousehold will check the visits of each person against the exposure
+// sites, returning the exposures of each person in the same order.
+func CheckHousehold(people []Person, entries Entries) []PersonExposures {
+	results := []PersonExposures{}
+	for _, p := range people {
+		exposures := CheckVisits(p.Visits, entries)
+		results = append(results, PersonExposures{
+			Person:    p.Name,
+			Exposures: exposures,
+			Risk:      CombinedRisk(exposures),
+		})
+	}
+	return results
+}
+
+// ExposedEntries will return the exposure sites of the exposures, with each
+// exposure site only included once.
+func ExposedEntries(exposures []Exposure) Entries {
+	seen := map[string]bool{}
+	entries := Entries{}
+	for _, x := range exposures {
+		if hash := x.Entry.Hash(); !seen[hash] {
+			seen[hash] = true
+			entries.Add(x.Entry)
+		}
+	}
+	return entries
+}
+
+// CombinedRisk will return the chance of at least one of the exposures,
+// treating the risk score of each exposure site as an independent chance.
+func CombinedRisk(exposures []Exposure) float64 {
+	entries := ExposedEntries(exposures)
+	none := 1.0
+	for i := range entries.Items {
+		none *= 1 - entries.Items[i].Risk()
+	}
+	return math.Round((1-none)*100) / 100
+}
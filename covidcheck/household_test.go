@@ -0,0 +1,67 @@
+package covidcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCheckHousehold will check the visit histories of several people and
+// check their exposures and combined risk are reported separately.
+func TestCheckHousehold(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-household")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	alice := filepath.Join(dir, "alice.csv")
+	ioutil.WriteFile(alice, []byte("place,date\nALDI Belconnen,04/10/2021\nKaleen Plaza Pharmacy,01/09/2021\n"), 0644)
+	bob := filepath.Join(dir, "visits.csv")
+	ioutil.WriteFile(bob, []byte("place,date\nWestfield Woden,04/10/2021\n"), 0644)
+
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV) {
+		entries.Add(fieldTranslate(record))
+	}
+
+	t.Run("Naming people", func(t *testing.T) {
+		p, err := LoadPerson(alice)
+		if err != nil || p.Name != "alice" || len(p.Visits) != 2 {
+			t.Errorf("unexpected person %v %v", p, err)
+		}
+		p, err = LoadPerson("bob=" + bob)
+		if err != nil || p.Name != "bob" {
+			t.Errorf("unexpected person %v %v", p, err)
+		}
+	})
+
+	t.Run("Checking each person", func(t *testing.T) {
+		a, _ := LoadPerson(alice)
+		b, _ := LoadPerson("bob=" + bob)
+		results := CheckHousehold([]Person{a, b}, entries)
+		if len(results) != 2 || len(results[0].Exposures) != 2 || len(results[1].Exposures) != 0 {
+			t.Fatalf("unexpected results %v", results)
+		}
+		if results[1].Risk != 0 {
+			t.Errorf("expected no risk for bob, got %v", results[1].Risk)
+		}
+
+		first := results[0].Exposures[0].Entry.Risk()
+		second := results[0].Exposures[1].Entry.Risk()
+		if results[0].Risk < first || results[0].Risk < second || results[0].Risk > 1 {
+			t.Errorf("unexpected combined risk %v of %v and %v", results[0].Risk, first, second)
+		}
+	})
+
+	t.Run("Counting exposure sites once", func(t *testing.T) {
+		twice := []Exposure{{Entry: entries.Items[0]}, {Entry: entries.Items[0]}}
+		if e := ExposedEntries(twice); e.Len() != 1 {
+			t.Fail()
+		}
+		if CombinedRisk(twice) != entries.Items[0].Risk() {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package covidcheck
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// templates are the templates of the HTML export.
+//
+//go:embed templates/report.html
+var templates embed.FS
+
+// htmlTemplate is the standalone page of the HTML export.
+var htmlTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).ParseFS(templates, "templates/report.html"))
+
+// exportHTML will write the items as a standalone HTML page, with a table
+// which can be sorted by clicking a column and searched as you type. The
+// time it was generated is left out when canonical is set.
+func exportHTML(w io.Writer, items []Entry, canonical bool) error {
+	generated := ""
+	if !canonical {
+		generated = time.Now().Format("2 January 2006 3:04PM")
+	}
+	return htmlTemplate.Execute(w, struct {
+		Records   []exportRecord
+		Generated string
+	}{exportRecords(items), generated})
+}
@@ -0,0 +1,36 @@
+package covidcheck
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestExportHTML will export static entries as a HTML page and check every
+// entry is a row of the table, with its values escaped.
+func TestExportHTML(t *testing.T) {
+	covid := &Client{}
+	for _, record := range readCSV(actTestCSV) {
+		e := fieldTranslate(record)
+		covid.AddFiltered(&e)
+	}
+	covid.FilteredResults.Items[0].ExposureLocation = "<script>alert(1)</script>"
+
+	var buf bytes.Buffer
+	if err := covid.Export(&buf, "html", false); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	if !strings.HasPrefix(page, "<!DOCTYPE html>") || !strings.Contains(page, "generated ") {
+		t.Errorf("expected a standalone page with the time it was generated, got %s", page)
+	}
+	if rows := strings.Count(page, "<tr class="); rows != covid.FilteredResults.Len() {
+		t.Errorf("expected %d rows, got %d", covid.FilteredResults.Len(), rows)
+	}
+	if strings.Contains(page, "<script>alert") || !strings.Contains(page, "&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Error("expected the values to be escaped")
+	}
+	if !strings.Contains(page, `<tr class="close">`) || !strings.Contains(page, "<td>2021-09-01</td>") {
+		t.Errorf("expected rows classed by contact level with iso dates, got %s", page)
+	}
+}
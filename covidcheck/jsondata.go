@@ -0,0 +1,210 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonStep is a step of a JSONPath, selecting the value of a key of an
+// object, the value at an index of an array, or every value of either.
+type jsonStep struct {
+	Key      string
+	Index    int
+	IsIndex  bool
+	Wildcard bool
+}
+
+// parseJSONPath will parse the subset of JSONPath used by mappings, being a
+// leading $ followed by .key, ['key'], [0], [*] or .* steps, such as
+// "$.data.sites[*]". The leading $ and dot can be left out, as in
+// "venue.name".
+func parseJSONPath(path string) ([]jsonStep, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
+	}
+
+	steps := []jsonStep{}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			rest = rest[end+1:]
+			if key == "" {
+				return nil, fmt.Errorf("could not parse json path '%s': expected a key after '.'", path)
+			}
+			steps = append(steps, jsonStep{Key: key, Wildcard: key == "*"})
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("could not parse json path '%s': expected ']'", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if inner == "*" {
+				steps = append(steps, jsonStep{Wildcard: true})
+			} else if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonStep{Key: inner[1 : len(inner)-1]})
+			} else if i, err := strconv.Atoi(inner); err == nil && i >= 0 {
+				steps = append(steps, jsonStep{Index: i, IsIndex: true})
+			} else {
+				return nil, fmt.Errorf("could not parse json path '%s': expected a key, an index or * in '[%s]'", path, inner)
+			}
+		default:
+			return nil, fmt.Errorf("could not parse json path '%s': unexpected '%c'", path, rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath will return the values selected by the steps from the value,
+// where the values of objects selected by wildcards are in the order of
+// their keys.
+func evalJSONPath(value interface{}, steps []jsonStep) []interface{} {
+	values := []interface{}{value}
+	for _, step := range steps {
+		next := []interface{}{}
+		for _, v := range values {
+			switch t := v.(type) {
+			case map[string]interface{}:
+				if step.Wildcard {
+					keys := []string{}
+					for key := range t {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, t[key])
+					}
+				} else if found, ok := t[step.Key]; ok && !step.IsIndex {
+					next = append(next, found)
+				}
+			case []interface{}:
+				if step.Wildcard {
+					next = append(next, t...)
+				} else if step.IsIndex && step.Index < len(t) {
+					next = append(next, t[step.Index])
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// jsonText will return the text of a JSON value, where numbers are written
+// as they were in the data and objects and arrays as JSON.
+func jsonText(value interface{}) string {
+	switch t := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// jsonFields will return the steps of the JSONPath of each mapped field,
+// which is the Name of a key of the record when it has no Path.
+func (m *ColumnMapping) jsonFields() (map[string][]jsonStep, error) {
+	fields := map[string][]jsonStep{}
+	for _, name := range mappingFields {
+		f, ok := m.Fields[name]
+		if !ok {
+			continue
+		}
+		if f.Path == "" {
+			if f.Name == "" {
+				return nil, fmt.Errorf("mapping field '%s' needs the name or path of a json field", name)
+			}
+			fields[name] = []jsonStep{{Key: f.Name}}
+			continue
+		}
+		steps, err := parseJSONPath(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = steps
+	}
+	return fields, nil
+}
+
+// ParseJSON will translate the records of JSON data read from r into
+// Entries with the mapping. The records are the values selected by the
+// Records path, where an array is read as its elements, and each field is
+// read from its Path in the record, or the key of its Name. Records which
+// aren't objects, or are without a date or suburb, are dropped with a
+// ParseWarning. JSON data can't be read in part, so it fails when reading
+// does.
+func (m *ColumnMapping) ParseJSON(r io.Reader) (Entries, error) {
+	records, err := parseJSONPath(m.Records)
+	if err != nil {
+		return Entries{}, err
+	}
+	fields, err := m.jsonFields()
+	if err != nil {
+		return Entries{}, err
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Entries{}, fmt.Errorf("could not read json data: %s", err.Error())
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return Entries{}, fmt.Errorf("could not parse json data: %s", err.Error())
+	}
+	values := evalJSONPath(root, records)
+	if len(values) == 1 {
+		if elements, ok := values[0].([]interface{}); ok {
+			values = elements
+		}
+	}
+
+	_, street := m.Fields["street"]
+	_, location := m.Fields["location"]
+	entries := Entries{}
+	for _, record := range values {
+		raw := jsonText(record)
+		if _, ok := record.(map[string]interface{}); !ok {
+			entries.warn("act", []string{raw}, "a record which isn't an object", true)
+			continue
+		}
+		e := m.translate(func(name string) string {
+			steps, ok := fields[name]
+			if !ok {
+				return ""
+			}
+			found := evalJSONPath(record, steps)
+			if len(found) == 0 {
+				return ""
+			}
+			return m.Fields[name].trim(jsonText(found[0]))
+		})
+		if reason, dropped := recordProblem(e); reason != "" && (dropped || street && location) {
+			entries.warn("act", []string{raw}, reason, dropped)
+			if dropped {
+				continue
+			}
+		}
+		entries.Add(e)
+	}
+	return entries, nil
+}
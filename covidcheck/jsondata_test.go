@@ -0,0 +1,105 @@
+package covidcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// actTestJSON is a small extract of exposure sites published as JSON.
+var actTestJSON = `{"meta": {"updated": "2021-10-05"}, "data": {"sites": [
+	{"id": 1, "status": "New", "venue": {"name": "ALDI Belconnen", "address": "Westfield Belconnen, Benjamin Way", "suburb": "Belconnen"}, "date": "2021-10-04", "times": ["7:00pm", "7:30pm"], "contact": "Casual"},
+	{"id": 2, "status": "Archived", "venue": {"name": "Kaleen Plaza Pharmacy", "address": "Georgina Crescent", "suburb": " 'Kaleen' "}, "date": "2021-09-01", "times": ["6:15pm", "7:10pm"], "contact": "Close"},
+	{"id": 3, "venue": {"name": "Undated"}, "contact": "Close"},
+	"not an object"
+]}}`
+
+// actTestJSONMapping maps the fields of actTestJSON.
+var actTestJSONMapping = &ColumnMapping{
+	Records: "$.data.sites",
+	Fields: map[string]FieldMapping{
+		"status":   {Name: "status"},
+		"location": {Path: "$.venue.name"},
+		"street":   {Path: "venue.address"},
+		"suburb":   {Path: "$.venue['suburb']", Trim: "'"},
+		"date":     {Name: "date", Format: "2006-01-02"},
+		"start":    {Path: "$.times[0]"},
+		"end":      {Path: "$.times[1]"},
+		"contact":  {Name: "contact"},
+	},
+}
+
+// TestJSONData will parse static JSON data with a mapping and check its
+// records are translated into entries.
+func TestJSONData(t *testing.T) {
+	t.Run("Parsing json paths", func(t *testing.T) {
+		for _, path := range []string{"", "$", "$.data.sites[*]", "data['sites'][0].venue", "$.*.sites", `$["data"]`} {
+			if _, err := parseJSONPath(path); err != nil {
+				t.Errorf("expected %q to parse, got %v", path, err)
+			}
+		}
+		for _, path := range []string{"$.", "$.data[", "$[-1]", "$[x]", "$data..sites"} {
+			if _, err := parseJSONPath(path); err == nil {
+				t.Errorf("expected %q to fail", path)
+			}
+		}
+	})
+
+	t.Run("Translating records", func(t *testing.T) {
+		if err := actTestJSONMapping.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := actTestJSONMapping.ParseJSON(strings.NewReader(actTestJSON))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries.Len() != 2 {
+			t.Fatalf("expected 2 entries, got %+v", entries.Items)
+		}
+		e := entries.Items[1]
+		if e.ExposureLocation != "Kaleen Plaza Pharmacy" || e.Suburb != "Kaleen" || e.Contact != ContactClose || e.Status != "Archived" {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		if e.Date == nil || e.Date.Format("02/01/2006") != "01/09/2021" || e.ArrivalTime.Format("3:04PM") != "6:15PM" || e.DepartureTime.Format("3:04PM") != "7:10PM" {
+			t.Errorf("unexpected date and times of %+v", e)
+		}
+		if len(entries.Warnings) != 2 || entries.Warnings[0].Reason != "an unparseable date" || !strings.Contains(entries.Warnings[0].Row, `""id"":3`) || entries.Warnings[1].Reason != "a record which isn't an object" {
+			t.Errorf("expected the undated record and the string to be dropped, got %+v", entries.Warnings)
+		}
+	})
+
+	t.Run("Reading whole documents", func(t *testing.T) {
+		m := &ColumnMapping{Fields: map[string]FieldMapping{"suburb": {Name: "Suburb"}, "date": {Name: "Date"}, "location": {Name: "Venue"}}}
+		entries, err := m.ParseJSON(strings.NewReader(`[{"Venue": "Coles", "Suburb": "Kaleen", "Date": "04/10/2021"}]`))
+		if err != nil || entries.Len() != 1 || entries.Items[0].ExposureLocation != "Coles" {
+			t.Errorf("expected the records of the array, got %+v and %v", entries.Items, err)
+		}
+		if _, err := m.ParseJSON(strings.NewReader(`[{"Venue": `)); err == nil {
+			t.Error("expected an error for truncated json")
+		}
+		m.Fields["street"] = FieldMapping{Column: 3}
+		if _, err := m.ParseJSON(strings.NewReader(`[]`)); err == nil || !strings.Contains(err.Error(), "street") {
+			t.Errorf("expected an error for a field mapped by column, got %v", err)
+		}
+	})
+
+	t.Run("Fetching json data", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, actTestJSON)
+		}))
+		defer server.Close()
+
+		src, _ := NewSource("act", SourceOptions{Endpoint: server.URL, Format: "json", Mapping: actTestJSONMapping})
+		entries, err := src.Fetch()
+		if err != nil || entries.Len() != 2 || entries.Items[0].Trust != TrustOfficial {
+			t.Errorf("expected 2 official entries, got %+v and %v", entries.Items, err)
+		}
+
+		src, _ = NewSource("act", SourceOptions{Endpoint: server.URL, Format: "json"})
+		if _, err := src.Fetch(); err == nil || !strings.Contains(err.Error(), "mapping") {
+			t.Errorf("expected an error without a mapping, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package covidcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Limits are guards on the work of a single run, so a source which is much
+// larger or slower than expected gives partial results, or fails with an
+// error, instead of using unbounded memory or time.
+type Limits struct {
+	// MaxRows is the most entries kept from each source, or 0 for no limit.
+	MaxRows int
+	// MaxFetchBytes is the most bytes read from each download, or 0 for no
+	// limit.
+	MaxFetchBytes int64
+	// Deadline is when downloads are abandoned, and no more retries are
+	// attempted, or the zero time for no deadline.
+	Deadline time.Time
+}
+
+// DefaultLimits are the Limits of every source, which can be replaced to
+// guard a run. There are no limits by default.
+var DefaultLimits = &Limits{}
+
+// LimitError is returned by a source when it reached one of its Limits.
+// When it is returned by a source along with entries, they are the entries
+// read before the limit was reached.
+type LimitError struct {
+	// Limit is the name of the limit which was reached.
+	Limit string
+	// Value is the value of the limit.
+	Value int64
+}
+
+// Error will describe the limit which was reached.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("the %s limit of %d was reached", e.Limit, e.Value)
+}
+
+// isLimit will check whether the error is caused by a LimitError.
+func isLimit(err error) bool {
+	var limit *LimitError
+	return errors.As(err, &limit)
+}
+
+// rows will cut the entries down to MaxRows, returning a LimitError if any
+// were removed. Nothing is removed by nil Limits.
+func (l *Limits) rows(entries *Entries) error {
+	if l == nil || l.MaxRows <= 0 || entries.Len() <= l.MaxRows {
+		return nil
+	}
+	entries.Items = entries.Items[:l.MaxRows]
+	return &LimitError{Limit: "max-rows", Value: int64(l.MaxRows)}
+}
+
+// do will send the request with the client, abandoning it at the Deadline
+// and failing the read of a body longer than MaxFetchBytes with a
+// LimitError.
+func (l *Limits) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if l == nil || (l.Deadline.IsZero() && l.MaxFetchBytes <= 0) {
+		return client.Do(req)
+	}
+	cancel := func() {}
+	if !l.Deadline.IsZero() {
+		var ctx context.Context
+		ctx, cancel = context.WithDeadline(req.Context(), l.Deadline)
+		req = req.WithContext(ctx)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, l.deadline(err)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, limits: l, remaining: l.MaxFetchBytes, cancel: cancel}
+	return resp, nil
+}
+
+// deadline will describe an error caused by the Deadline passing as such.
+func (l *Limits) deadline(err error) error {
+	if err == nil || err == io.EOF || !l.expired(0) {
+		return err
+	}
+	return fmt.Errorf("the max-runtime deadline was reached: %s", err.Error())
+}
+
+// expired will check whether the Deadline has passed, or will have once
+// the wait is over.
+func (l *Limits) expired(wait time.Duration) bool {
+	return l != nil && !l.Deadline.IsZero() && !time.Now().Add(wait).Before(l.Deadline)
+}
+
+// limitedBody is a response body which fails with a LimitError once more
+// than MaxFetchBytes are read, when it is positive, and cancels the request
+// when it is closed.
+type limitedBody struct {
+	io.ReadCloser
+	limits    *Limits
+	remaining int64
+	cancel    func()
+}
+
+// Read will read from the body, until the limit is exceeded.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	limit := b.limits.MaxFetchBytes
+	if limit <= 0 {
+		n, err := b.ReadCloser.Read(p)
+		return n, b.limits.deadline(err)
+	}
+	if b.remaining <= 0 {
+		// The body may end exactly at the limit.
+		if n, err := b.ReadCloser.Read(make([]byte, 1)); n == 0 && err != nil {
+			return 0, b.limits.deadline(err)
+		}
+		return 0, &LimitError{Limit: "max-fetch-bytes", Value: limit}
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, b.limits.deadline(err)
+}
+
+// Close will close the body and cancel the request.
+func (b *limitedBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
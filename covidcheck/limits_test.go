@@ -0,0 +1,116 @@
+package covidcheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLimits will serve datasets which are larger or slower than the
+// DefaultLimits allow, and check the sources give partial results with a
+// LimitError where their format allows, and fail otherwise.
+func TestLimits(t *testing.T) {
+	slow := make(chan struct{})
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer close(slow)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<script>Papa.parse(\"%s/data.csv\", {download: true});</script>", server.URL)
+	})
+	mux.HandleFunc("/data.csv", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, actTestCSV)
+	})
+	mux.HandleFunc("/nsw", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, nswTestData)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-slow:
+		case <-r.Context().Done():
+		}
+	})
+
+	limits, policy := DefaultLimits, DefaultRetryPolicy
+	defer func() { DefaultLimits, DefaultRetryPolicy = limits, policy }()
+	waits := 0
+	DefaultRetryPolicy = &RetryPolicy{Retries: 2, Wait: time.Second, Sleep: func(time.Duration) { waits++ }}
+	firstLine := int64(strings.Index(actTestCSV, "\n") + 1)
+
+	t.Run("Cutting off the ACT CSV file", func(t *testing.T) {
+		DefaultLimits = &Limits{MaxFetchBytes: firstLine + 10}
+		entries, err := (&actSource{Endpoint: server.URL}).Fetch()
+		if limit, ok := err.(*LimitError); !ok || limit.Limit != "max-fetch-bytes" {
+			t.Fatalf("expected a max-fetch-bytes LimitError, got %v", err)
+		}
+		if entries.Len() != 1 || entries.Items[0].ExposureLocation != "ALDI Belconnen" {
+			t.Errorf("expected the first complete line, got %+v", entries.Items)
+		}
+	})
+
+	t.Run("Reading a file which ends at the limit", func(t *testing.T) {
+		DefaultLimits = &Limits{MaxFetchBytes: int64(len(nswTestData))}
+		entries, err := (&nswSource{Endpoint: server.URL + "/nsw"}).Fetch()
+		if err != nil || entries.Len() != 2 {
+			t.Errorf("expected 2 entries, got %d and %v", entries.Len(), err)
+		}
+	})
+
+	t.Run("Failing a cut off NSW dataset", func(t *testing.T) {
+		DefaultLimits = &Limits{MaxFetchBytes: 20}
+		if _, err := (&nswSource{Endpoint: server.URL + "/nsw"}).Fetch(); err == nil || isLimit(err) {
+			t.Errorf("expected a parse error, got %v", err)
+		}
+	})
+
+	t.Run("Keeping the first rows", func(t *testing.T) {
+		DefaultLimits = &Limits{MaxRows: 1}
+		entries, err := (&nswSource{Endpoint: server.URL + "/nsw"}).Fetch()
+		if limit, ok := err.(*LimitError); !ok || limit.Limit != "max-rows" || limit.Value != 1 {
+			t.Fatalf("expected a max-rows LimitError, got %v", err)
+		}
+		if entries.Len() != 1 {
+			t.Errorf("expected 1 entry, got %d", entries.Len())
+		}
+	})
+
+	t.Run("Abandoning a slow download", func(t *testing.T) {
+		waits = 0
+		DefaultLimits = &Limits{Deadline: time.Now().Add(100 * time.Millisecond)}
+		_, err := download("vic", server.URL+"/slow", nil)
+		if err == nil || !strings.Contains(err.Error(), "max-runtime") {
+			t.Errorf("expected the deadline to be reached, got %v", err)
+		}
+		if waits != 0 {
+			t.Errorf("expected no retries past the deadline, got %d", waits)
+		}
+	})
+
+	t.Run("Merging sources which were cut short", func(t *testing.T) {
+		DefaultLimits = &Limits{MaxRows: 1}
+		multi := &multiSource{
+			Names:   []string{"act", "nsw"},
+			Sources: []DataSource{&actSource{Endpoint: server.URL}, &nswSource{Endpoint: server.URL + "/slow"}},
+		}
+		DefaultLimits.Deadline = time.Now().Add(100 * time.Millisecond)
+		entries, err := multi.Fetch()
+		failed, ok := err.(*FetchError)
+		if !ok || !failed.Partial() || len(failed.Errors) != 2 {
+			t.Fatalf("expected a partial FetchError, got %v", err)
+		}
+		if entries.Len() != 1 {
+			t.Errorf("expected the entry kept from act, got %d", entries.Len())
+		}
+	})
+
+	t.Run("Guarding nothing without limits", func(t *testing.T) {
+		var l *Limits
+		entries := Entries{Items: []Entry{{}, {}}}
+		if l.rows(&entries) != nil || entries.Len() != 2 || l.expired(time.Hour) {
+			t.Fail()
+		}
+	})
+}
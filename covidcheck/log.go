@@ -0,0 +1,150 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log message.
+type Level int
+
+const (
+	// LevelError is for failures which stop a fetch or a command.
+	LevelError Level = iota
+	// LevelWarn is for problems which were worked around, such as partial
+	// results.
+	LevelWarn
+	// LevelInfo is for what a run did, such as what was fetched and how
+	// long it took.
+	LevelInfo
+	// LevelDebug is for the detail of each request, row and query.
+	LevelDebug
+)
+
+// String will return the name of the level.
+func (l Level) String() string {
+	switch l {
+	case LevelError:
+		return "error"
+	case LevelWarn:
+		return "warning"
+	case LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// Logger writes leveled messages, with fields of key and value pairs, as
+// text lines or as JSON objects. Messages above its Level are dropped, and
+// a nil Logger drops every message.
+type Logger struct {
+	// Writer is where messages are written.
+	Writer io.Writer
+	// Level is the most verbose level which is written.
+	Level Level
+	// JSON writes each message as a JSON object instead of a line of text.
+	JSON bool
+	// Now returns the time of each message, which defaults to time.Now.
+	Now func() time.Time
+
+	mu sync.Mutex
+}
+
+// DefaultLogger is the Logger of the package and the command line, which
+// writes warnings and errors to stderr by default.
+var DefaultLogger = &Logger{Writer: os.Stderr, Level: LevelWarn}
+
+// Enabled will check whether messages of the level are written.
+func (l *Logger) Enabled(level Level) bool {
+	return l != nil && l.Writer != nil && level <= l.Level
+}
+
+// Error will log a message at LevelError with the key and value pairs.
+func (l *Logger) Error(msg string, fields ...interface{}) {
+	l.Log(LevelError, msg, fields...)
+}
+
+// Warn will log a message at LevelWarn with the key and value pairs.
+func (l *Logger) Warn(msg string, fields ...interface{}) {
+	l.Log(LevelWarn, msg, fields...)
+}
+
+// Info will log a message at LevelInfo with the key and value pairs.
+func (l *Logger) Info(msg string, fields ...interface{}) {
+	l.Log(LevelInfo, msg, fields...)
+}
+
+// Debug will log a message at LevelDebug with the key and value pairs.
+func (l *Logger) Debug(msg string, fields ...interface{}) {
+	l.Log(LevelDebug, msg, fields...)
+}
+
+// Log will write the message at the level, with fields given as
+// alternating keys and values. Errors, durations and other Stringers are
+// written as their strings.
+func (l *Logger) Log(level Level, msg string, fields ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	now := time.Now
+	if l.Now != nil {
+		now = l.Now
+	}
+	t := now()
+
+	var line string
+	if l.JSON {
+		record := map[string]interface{}{}
+		for i := 0; i+1 < len(fields); i += 2 {
+			record[fmt.Sprint(fields[i])] = logValue(fields[i+1])
+		}
+		record["time"] = t.Format(time.RFC3339)
+		record["level"] = level.String()
+		record["msg"] = msg
+		data, err := json.Marshal(record)
+		if err != nil {
+			data, _ = json.Marshal(map[string]string{"time": t.Format(time.RFC3339), "level": level.String(), "msg": msg})
+		}
+		line = string(data)
+	} else {
+		parts := []string{t.Format("2006-01-02 15:04:05"), level.String() + ": " + msg}
+		for i := 0; i+1 < len(fields); i += 2 {
+			parts = append(parts, fmt.Sprintf("%v=%s", fields[i], logText(logValue(fields[i+1]))))
+		}
+		line = strings.Join(parts, " ")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(l.Writer, line)
+}
+
+// logValue will return the value of a field as it is logged.
+func logValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+// logText will format a value for a text line, quoting strings which
+// contain spaces, quotes or equals signs.
+func logText(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
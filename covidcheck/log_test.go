@@ -0,0 +1,63 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLogger will log messages at every level, and check they are filtered
+// by the Level and written as text lines or JSON objects with their fields.
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	now := func() time.Time { return time.Date(2021, 10, 5, 12, 0, 0, 0, time.UTC) }
+	logger := &Logger{Writer: &buf, Level: LevelInfo, Now: now}
+
+	t.Run("Filtering by level", func(t *testing.T) {
+		buf.Reset()
+		logger.Debug("hidden")
+		logger.Info("shown")
+		logger.Warn("shown")
+		logger.Error("shown")
+		if n := strings.Count(buf.String(), "\n"); n != 3 || strings.Contains(buf.String(), "hidden") {
+			t.Errorf("expected 3 messages, got %s", buf.String())
+		}
+	})
+
+	t.Run("Writing text", func(t *testing.T) {
+		buf.Reset()
+		logger.Warn("source failed", "source", "act", "error", errors.New("failed to fetch data: 500"), "duration", 1500*time.Millisecond, "rows", 2, "empty", "")
+		want := `2021-10-05 12:00:00 warning: source failed source=act error="failed to fetch data: 500" duration=1.5s rows=2 empty=""` + "\n"
+		if buf.String() != want {
+			t.Errorf("expected %q, got %q", want, buf.String())
+		}
+	})
+
+	t.Run("Writing JSON", func(t *testing.T) {
+		buf.Reset()
+		logger.JSON = true
+		defer func() { logger.JSON = false }()
+		logger.Info("fetched exposure sites", "source", "act", "entries", 4, "duration", time.Second)
+		record := map[string]interface{}{}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record["level"] != "info" || record["msg"] != "fetched exposure sites" || record["time"] != "2021-10-05T12:00:00Z" {
+			t.Errorf("unexpected record %v", record)
+		}
+		if record["source"] != "act" || record["entries"] != float64(4) || record["duration"] != "1s" {
+			t.Errorf("unexpected fields %v", record)
+		}
+	})
+
+	t.Run("Dropping everything without a logger", func(t *testing.T) {
+		var l *Logger
+		l.Error("dropped")
+		if l.Enabled(LevelError) {
+			t.Fail()
+		}
+	})
+}
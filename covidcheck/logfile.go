@@ -0,0 +1,129 @@
+package covidcheck
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LogFile is an io.Writer appending to a file, which is renamed with the
+// time it was rotated, such as covid-check.log.20211005T090000Z, when it gets
+// too large or too old. Only the newest rotated files are kept, so a
+// long-running instance doesn't fill the disk.
+type LogFile struct {
+	// Path is the file which is written to.
+	Path string
+	// MaxSize is the size in bytes after which the file is rotated, which
+	// isn't limited when it is zero.
+	MaxSize int64
+	// MaxAge is the time after which the file is rotated, which isn't
+	// limited when it is zero.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files which are kept, where
+	// every rotated file is kept when it is zero.
+	MaxBackups int
+	// Now returns the current time, which defaults to time.Now.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	started time.Time
+}
+
+// now will return the current time of the LogFile.
+func (l *LogFile) now() time.Time {
+	if l.Now == nil {
+		return time.Now()
+	}
+	return l.Now()
+}
+
+// Write will append p to the file, rotating it first when it is due.
+func (l *LogFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		if err := l.open(); err != nil {
+			return 0, err
+		}
+	}
+	now := l.now()
+	if (l.MaxSize > 0 && l.size > 0 && l.size+int64(len(p)) > l.MaxSize) || (l.MaxAge > 0 && now.Sub(l.started) >= l.MaxAge) {
+		if err := l.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// Close will close the file.
+func (l *LogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// open will open the file for appending. The age of an existing file is
+// counted from when it was last modified, as its creation time isn't known.
+func (l *LogFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file, l.size, l.started = f, info.Size(), l.now()
+	if info.Size() > 0 {
+		l.started = info.ModTime()
+	}
+	return nil
+}
+
+// rotate will rename the file, remove the oldest rotated files beyond
+// MaxBackups and open a new file.
+func (l *LogFile) rotate(now time.Time) error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if err := os.Rename(l.Path, l.Path+"."+now.UTC().Format(snapshotTimeFormat)); err != nil {
+		return err
+	}
+
+	if l.MaxBackups > 0 {
+		backups, err := filepath.Glob(l.Path + ".*")
+		if err != nil {
+			return err
+		}
+		sort.Strings(backups)
+		for len(backups) > l.MaxBackups {
+			if err := os.Remove(backups[0]); err != nil {
+				return err
+			}
+			backups = backups[1:]
+		}
+	}
+
+	if err := l.open(); err != nil {
+		return err
+	}
+	l.started = now
+	return nil
+}
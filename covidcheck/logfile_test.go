@@ -0,0 +1,60 @@
+package covidcheck
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLogFile will write to log files and check when they are rotated and
+// how many rotated files are kept.
+func TestLogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2021, 10, 5, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	t.Run("Rotating by size", func(t *testing.T) {
+		l := &LogFile{Path: filepath.Join(dir, "size", "covid-check.log"), MaxSize: 20, MaxBackups: 2, Now: clock}
+		defer l.Close()
+		for i := 0; i < 5; i++ {
+			now = now.Add(time.Second)
+			if _, err := fmt.Fprintf(l, "line %d of the log\n", i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		backups, _ := filepath.Glob(l.Path + ".*")
+		if len(backups) != 2 || filepath.Base(backups[1]) != "covid-check.log.20211005T090005Z" {
+			t.Errorf("expected the two newest rotated files to be kept, got %v", backups)
+		}
+		if data, _ := ioutil.ReadFile(l.Path); string(data) != "line 4 of the log\n" {
+			t.Errorf("expected only the last line in the log, got %q", data)
+		}
+	})
+
+	t.Run("Rotating by age", func(t *testing.T) {
+		l := &LogFile{Path: filepath.Join(dir, "age", "covid-check.log"), MaxAge: time.Hour, Now: clock}
+		defer l.Close()
+		fmt.Fprintln(l, "first")
+		now = now.Add(30 * time.Minute)
+		fmt.Fprintln(l, "second")
+		if backups, _ := filepath.Glob(l.Path + ".*"); len(backups) != 0 {
+			t.Fatalf("expected no rotation yet, got %v", backups)
+		}
+		now = now.Add(30 * time.Minute)
+		fmt.Fprintln(l, "third")
+		if backups, _ := filepath.Glob(l.Path + ".*"); len(backups) != 1 {
+			t.Errorf("expected the log to be rotated, got %v", backups)
+		}
+		if data, _ := ioutil.ReadFile(l.Path); string(data) != "third\n" {
+			t.Errorf("unexpected log %q", data)
+		}
+	})
+}
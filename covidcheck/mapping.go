@@ -0,0 +1,334 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FieldMapping is where an Entry field is read from in each CSV row, and
+// how its value is read.
+type FieldMapping struct {
+	// Column is the position of the column, counting from 1.
+	Column int `json:"column,omitempty"`
+	// Name is the name of the column in the header row, which is used
+	// instead of Column when set.
+	Name string `json:"name,omitempty"`
+	// Format is the layout of a date or time, written as the reference
+	// time of Go's time package is, such as "02/01/2006 - Monday" or
+	// "3:04pm". Dates are read from their first word as day/month/year,
+	// and times as "7:00pm", by default.
+	Format string `json:"format,omitempty"`
+	// Trim are the characters trimmed from both ends of the value, such as
+	// stray quotes, along with any whitespace.
+	Trim string `json:"trim,omitempty"`
+	// Path is the JSONPath of the field in each record of JSON data, such as
+	// "$.venue.name", which is used instead of the key of its Name.
+	Path string `json:"path,omitempty"`
+}
+
+// trim will trim the Trim characters and whitespace from the value.
+func (f FieldMapping) trim(value string) string {
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(value), f.Trim))
+}
+
+// ColumnMapping declares which CSV column each Entry field is read from,
+// instead of finding them relative to the date as fieldTranslate does, for
+// files whose columns have been reordered. It also declares where the fields
+// of JSON data are read from.
+type ColumnMapping struct {
+	// Header is whether the first row is a header, which is skipped. It is
+	// implied when any field is mapped by its Name.
+	Header bool `json:"header,omitempty"`
+	// Records is the JSONPath of the records of JSON data, such as
+	// "$.data.sites", which is the whole document by default.
+	Records string `json:"records,omitempty"`
+	// Fields are the mappings of each field, keyed by status, location,
+	// street, suburb, state, date, start, end or contact.
+	Fields map[string]FieldMapping `json:"fields"`
+}
+
+// mappingFields are the Entry fields a ColumnMapping can map.
+var mappingFields = []string{"status", "location", "street", "suburb", "state", "date", "start", "end", "contact"}
+
+// headerNames are the words of the column names in a header row which
+// identify each field, checked in order so each column maps to one field.
+// Other columns, such as Event Id, aren't read.
+var headerNames = []struct {
+	Field string
+	Words []string
+}{
+	{"status", []string{"status"}},
+	{"location", []string{"location", "venue", "place", "site"}},
+	{"street", []string{"street", "address"}},
+	{"suburb", []string{"suburb", "town"}},
+	{"state", []string{"state"}},
+	{"date", []string{"date"}},
+	{"start", []string{"arrival", "start", "from"}},
+	{"end", []string{"departure", "end", "until"}},
+	{"contact", []string{"contact"}},
+}
+
+// detectMapping will return a ColumnMapping of the columns named by the
+// first record of CSV data, or nil when it isn't a header row or doesn't
+// name the date and suburb columns.
+func detectMapping(record []string) *ColumnMapping {
+	if !headerRow(record) {
+		return nil
+	}
+
+	m := &ColumnMapping{Header: true, Fields: map[string]FieldMapping{}}
+	for i, name := range record {
+		words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+	find:
+		for _, h := range headerNames {
+			if _, ok := m.Fields[h.Field]; ok {
+				continue
+			}
+			for _, word := range words {
+				for _, w := range h.Words {
+					if word == w {
+						m.Fields[h.Field] = FieldMapping{Column: i + 1}
+						break find
+					}
+				}
+			}
+		}
+	}
+	if m.Validate() != nil {
+		return nil
+	}
+	return m
+}
+
+// LoadMapping will read a JSON object of a ColumnMapping, such as
+// {"header": true, "fields": {"date": {"column": 7, "format": "02/01/2006"}}},
+// and check every field it maps is known and has a column or path.
+func LoadMapping(path string) (*ColumnMapping, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ColumnMapping{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("could not parse mapping: %s", err.Error())
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate will check every field of the mapping is known and has a column
+// or path, that its paths can be parsed, and that the date and suburb which
+// every Entry needs are mapped.
+func (m *ColumnMapping) Validate() error {
+	names := []string{}
+	for name := range m.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := m.Fields[name]
+		known := false
+		for _, field := range mappingFields {
+			known = known || field == name
+		}
+		if !known {
+			return fmt.Errorf("unknown mapping field '%s', expected one of [%s]", name, strings.Join(mappingFields, "|"))
+		}
+		if f.Column < 1 && f.Name == "" && f.Path == "" {
+			return fmt.Errorf("mapping field '%s' needs a column from 1, the name of one or a json path", name)
+		}
+		if f.Path != "" {
+			if _, err := parseJSONPath(f.Path); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := parseJSONPath(m.Records); err != nil {
+		return err
+	}
+	for _, name := range []string{"date", "suburb"} {
+		if _, ok := m.Fields[name]; !ok {
+			return fmt.Errorf("mapping is missing the '%s' field", name)
+		}
+	}
+	return nil
+}
+
+// header will check whether the first row is a header.
+func (m *ColumnMapping) header() bool {
+	if m.Header {
+		return true
+	}
+	for _, f := range m.Fields {
+		if f.Name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse will translate the rows of the raw CSV data into Entries with the
+// mapping. Rows which are too short, or without a date or suburb, are
+// dropped with a ParseWarning, and it fails when a named column isn't in
+// the header.
+func (m *ColumnMapping) Parse(raw string) (Entries, error) {
+	return m.ParseReader(strings.NewReader(raw))
+}
+
+// ParseReader will translate the rows of CSV data into Entries like Parse,
+// as they are read from r, reading text which isn't UTF-8 as Windows-1252.
+// When reading fails part way, the Entries of the rows read before are
+// returned with the error.
+func (m *ColumnMapping) ParseReader(r io.Reader) (Entries, error) {
+	reader := newCSVReader(newUTF8Reader(r))
+	var header []string
+	if m.header() {
+		record, err := reader.Read()
+		if err := readError(err); err != nil {
+			return Entries{}, err
+		}
+		header = record
+	}
+	p, err := m.parser(header)
+	if err != nil {
+		return Entries{}, err
+	}
+
+	entries := Entries{}
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			return entries, readError(err)
+		}
+		if e, ok := p.entry(record, &entries); ok {
+			entries.Add(e)
+		}
+	}
+}
+
+// mappingParser translates the records of CSV data with a ColumnMapping,
+// once the columns of the named fields are found in the header.
+type mappingParser struct {
+	mapping *ColumnMapping
+	// index is the position of the column of each mapped field.
+	index map[string]int
+	// warnAll is whether rows are warned about when a problem doesn't
+	// drop them, as the street and location can be told apart.
+	warnAll bool
+}
+
+// parser will find the columns of the mapped fields, by their position or
+// their name in the header, which is nil without a header row.
+func (m *ColumnMapping) parser(header []string) (*mappingParser, error) {
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[normalizeField(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	index := map[string]int{}
+	for _, name := range mappingFields {
+		f, ok := m.Fields[name]
+		if !ok {
+			continue
+		}
+		index[name] = f.Column - 1
+		if f.Name != "" {
+			i, ok := columns[normalizeField(f.Name)]
+			if !ok {
+				return nil, fmt.Errorf("column '%s' of the %s mapping is not in the header", f.Name, name)
+			}
+			index[name] = i
+		}
+	}
+
+	// Without both, the street and location can't be told apart anyway.
+	_, street := m.Fields["street"]
+	_, location := m.Fields["location"]
+	return &mappingParser{mapping: m, index: index, warnAll: street && location}, nil
+}
+
+// entry will translate the record into an Entry, or add a warning to the
+// entries about why the record was dropped.
+func (p *mappingParser) entry(record []string, entries *Entries) (Entry, bool) {
+	short := false
+	value := func(name string) string {
+		i, ok := p.index[name]
+		if !ok {
+			return ""
+		}
+		if i >= len(record) {
+			short = true
+			return ""
+		}
+		return p.mapping.Fields[name].trim(record[i])
+	}
+	e := p.mapping.translate(value)
+	if short {
+		entries.warn("act", record, "too few fields", true)
+		return e, false
+	}
+	if reason, dropped := recordProblem(e); reason != "" && (dropped || p.warnAll) {
+		entries.warn("act", record, reason, dropped)
+		if dropped {
+			return e, false
+		}
+	}
+	return e, true
+}
+
+// translate will build an Entry from the value of each mapped field, where
+// the Date is nil when it can't be parsed.
+func (m *ColumnMapping) translate(value func(name string) string) Entry {
+	status, err := ParseStatus(value("status"))
+	if err != nil {
+		DefaultLogger.Debug("unrecognized field", "field", "status", "location", value("location"), "error", err)
+	}
+	state, err := ParseState(value("state"))
+	if err != nil {
+		DefaultLogger.Debug("unrecognized field", "field", "state", "location", value("location"), "error", err)
+	}
+	e := Entry{
+		Status:           status,
+		ExposureLocation: value("location"),
+		Street:           value("street"),
+		Suburb:           value("suburb"),
+		State:            state,
+		ArrivalTime:      m.parseTime("start", value("start")),
+		DepartureTime:    m.parseTime("end", value("end")),
+		Contact:          MapContact("act", value("contact")),
+		ContactLabel:     value("contact"),
+	}
+
+	date, layout := value("date"), m.Fields["date"].Format
+	if layout == "" {
+		date, layout = strings.Split(date, " ")[0], "2/1/2006"
+	}
+	if t, err := time.Parse(layout, date); err == nil {
+		e.Date = &t
+	}
+	return e
+}
+
+// parseTime will parse the value of a mapped time field with its Format, or
+// as a time such as "7:00pm" by default.
+func (m *ColumnMapping) parseTime(name, value string) *time.Time {
+	layout := m.Fields[name].Format
+	if layout == "" {
+		return kitchenTime(value)
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return &time.Time{}
+	}
+	return &t
+}
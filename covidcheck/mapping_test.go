@@ -0,0 +1,133 @@
+package covidcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestColumnMapping will read a CSV file with reordered columns through a
+// mapping, and check the fields are read from the declared columns with
+// their transforms.
+func TestColumnMapping(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-mapping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	path := write("act.json", `{
+		"fields": {
+			"date": {"name": "Exposure Date", "format": "2006-01-02"},
+			"start": {"name": "From", "format": "15:04"},
+			"end": {"name": "To", "format": "15:04"},
+			"location": {"column": 1, "trim": "'"},
+			"street": {"column": 2},
+			"suburb": {"column": 3},
+			"state": {"column": 4},
+			"contact": {"column": 9}
+		}
+	}`)
+	m, err := LoadMapping(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv := "Location,Street,Suburb,State,Exposure Date,From,To,Notes,Contact\n" +
+		"'ALDI Belconnen',\"Westfield Belconnen, Benjamin Way\",Belconnen,ACT,2021-10-04,19:00,19:30,,Casual contact\n" +
+		"Kaleen Plaza Pharmacy,Georgina Crescent,Kaleen,ACT,04/10/2021,18:15,19:10,,Close\n" +
+		"Dickson Library,Antill Street\n"
+	entries, err := m.Parse(csv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Reading the mapped columns", func(t *testing.T) {
+		if entries.Len() != 1 {
+			t.Fatalf("expected 1 entry, got %d", entries.Len())
+		}
+		e := entries.Items[0]
+		if e.ExposureLocation != "ALDI Belconnen" || e.Street != "Westfield Belconnen, Benjamin Way" || e.Suburb != "Belconnen" || e.State != StateACT {
+			t.Errorf("unexpected fields %+v", e)
+		}
+		if !e.Date.Equal(time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)) || e.ArrivalTime.Format("15:04") != "19:00" || e.DepartureTime.Format("15:04") != "19:30" {
+			t.Errorf("unexpected date and times %v %v %v", e.Date, e.ArrivalTime, e.DepartureTime)
+		}
+		if e.Contact != ContactCasual || e.ContactLabel != "Casual contact" {
+			t.Errorf("unexpected contact %s (%s)", e.Contact, e.ContactLabel)
+		}
+	})
+
+	t.Run("Dropping rows which don't fit", func(t *testing.T) {
+		reasons := []string{}
+		for _, w := range entries.Warnings {
+			reasons = append(reasons, w.Reason)
+		}
+		if strings.Join(reasons, "; ") != "an unparseable date; too few fields" || entries.Dropped() != 2 {
+			t.Errorf("unexpected warnings %+v", entries.Warnings)
+		}
+	})
+
+	t.Run("Fetching through the source", func(t *testing.T) {
+		src, err := NewSource("act", SourceOptions{File: write("act.csv", csv), Mapping: m})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := src.Fetch()
+		if err != nil || entries.Len() != 1 || entries.Items[0].Trust != TrustImported {
+			t.Errorf("expected the mapped entry, got %+v and %v", entries.Items, err)
+		}
+	})
+
+	t.Run("Mapping columns by the header row", func(t *testing.T) {
+		header := "Contact,Date,Arrival Time,Departure Time,Exposure Location,Street,Suburb,State,Status,Event Id\n"
+		src, err := NewSource("act", SourceOptions{File: write("header.csv", header+
+			`Close,04/10/2021 - Monday,6:15pm,7:10pm,Kaleen Plaza Pharmacy,Georgina Crescent,Kaleen,ACT,New,2`+"\n")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := src.Fetch()
+		if err != nil || entries.Len() != 1 {
+			t.Fatalf("expected 1 entry, got %+v and %v", entries.Items, err)
+		}
+		e := entries.Items[0]
+		if e.ExposureLocation != "Kaleen Plaza Pharmacy" || e.Suburb != "Kaleen" || e.Status != StatusNew || e.Contact != ContactClose || e.DepartureTime.Format("15:04") != "19:10" {
+			t.Errorf("unexpected fields %+v", e)
+		}
+
+		if m := detectMapping([]string{"Event Id", "Status", "Exposure Location", "Street"}); m != nil {
+			t.Errorf("expected no mapping without a date and suburb, got %+v", m)
+		}
+		if m := detectMapping(readCSV(actTestCSV)[0]); m != nil {
+			t.Errorf("expected no mapping without a header row, got %+v", m)
+		}
+	})
+
+	t.Run("Failing on a missing header", func(t *testing.T) {
+		if _, err := m.Parse("Location,Street\nALDI,Belconnen\n"); err == nil || !strings.Contains(err.Error(), "Exposure Date") {
+			t.Errorf("expected the missing column to be named, got %v", err)
+		}
+	})
+
+	t.Run("Validating mappings", func(t *testing.T) {
+		for _, data := range []string{
+			`{`,
+			`{"fields": {"date": {"column": 1}}}`,
+			`{"fields": {"date": {"column": 1}, "suburb": {}}}`,
+			`{"fields": {"date": {"column": 1}, "suburb": {"column": 2}, "venue": {"column": 3}}}`,
+		} {
+			if _, err := LoadMapping(write("bad.json", data)); err == nil {
+				t.Errorf("expected an error loading %s", data)
+			}
+		}
+	})
+}
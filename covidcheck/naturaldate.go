@@ -0,0 +1,118 @@
+package covidcheck
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// naturalDateLayouts are the layouts of dates with a year, which are read day
+// first as Australian dates are, and with English month names.
+var naturalDateLayouts = []string{
+	"2/1/2006",
+	"2/1/06",
+	"2-1-2006",
+	"2.1.2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2 2006",
+	"January 2 2006",
+	"Mon 2 Jan 2006",
+	"Monday 2 January 2006",
+}
+
+// naturalDayLayouts are the layouts of dates without a year, which are the
+// latest such day up to today.
+var naturalDayLayouts = []string{
+	"2/1",
+	"2 Jan",
+	"2 January",
+	"Jan 2",
+	"January 2",
+	"Mon 2 Jan",
+	"Monday 2 January",
+}
+
+// ordinalSuffix matches the suffix of ordinal days, such as the "th" of
+// "28th".
+var ordinalSuffix = regexp.MustCompile(`(\d)(st|nd|rd|th)\b`)
+
+// agoPattern matches ages such as "3 days ago" and "a week ago".
+var agoPattern = regexp.MustCompile(`^(\d+|a|an|one) (day|week)s? ago$`)
+
+// parseNaturalDate will parse the dates people type, relative to now:
+// "tomorrow", weekdays such as "tuesday" or "last tuesday", ages such as
+// "3 days ago", and dates with month names such as "Sep 28" or "28th
+// September 2021", or without leading zeros such as "1/9/2021". Dates
+// without a year are the latest such day up to today, as exposure sites are
+// in the past. Whether the value could be parsed is returned.
+func parseNaturalDate(value string, now time.Time) (time.Time, bool) {
+	today := day(now)
+	v := strings.ToLower(strings.Join(strings.Fields(value), " "))
+	v = ordinalSuffix.ReplaceAllString(strings.NewReplacer(",", " ", " of ", " ").Replace(v), "$1")
+	v = strings.Join(strings.Fields(v), " ")
+
+	switch v {
+	case "today", "now":
+		return today, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "last week":
+		return today.AddDate(0, 0, -7), true
+	}
+
+	if m := agoPattern.FindStringSubmatch(v); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			n = 1
+		}
+		if m[2] == "week" {
+			n *= 7
+		}
+		return today.AddDate(0, 0, -n), true
+	}
+
+	if weekday, last, ok := parseWeekday(v); ok {
+		back := (int(today.Weekday()) - int(weekday) + 7) % 7
+		if last && back == 0 {
+			back = 7
+		}
+		return today.AddDate(0, 0, -back), true
+	}
+
+	if t, err := parseFirst(v, naturalDateLayouts); err == nil {
+		return t, true
+	}
+	if t, err := parseFirst(v, naturalDayLayouts); err == nil {
+		t = time.Date(today.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		if t.After(today) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseWeekday will parse a weekday such as "tuesday", "tue", "this tuesday"
+// or "last tuesday", and whether it was given as "last".
+func parseWeekday(v string) (time.Weekday, bool, bool) {
+	last := false
+	switch {
+	case strings.HasPrefix(v, "last "):
+		v, last = strings.TrimPrefix(v, "last "), true
+	case strings.HasPrefix(v, "this "):
+		v = strings.TrimPrefix(v, "this ")
+	}
+	if len(v) < 3 {
+		return time.Sunday, false, false
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if name := strings.ToLower(d.String()); strings.HasPrefix(name, v) {
+			return d, last, true
+		}
+	}
+	return time.Sunday, false, false
+}
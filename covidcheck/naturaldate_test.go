@@ -0,0 +1,51 @@
+package covidcheck
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNaturalDates will parse the dates people type against a fixed time,
+// which is a Thursday, and check each is the expected day.
+func TestNaturalDates(t *testing.T) {
+	now := time.Date(2021, 10, 14, 18, 30, 0, 0, time.Local)
+	date := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	examples := map[string]time.Time{
+		"tomorrow":                   date(2021, 10, 15),
+		"Last Week":                  date(2021, 10, 7),
+		"3 days ago":                 date(2021, 10, 11),
+		"a week ago":                 date(2021, 10, 7),
+		"2 weeks ago":                date(2021, 9, 30),
+		"tuesday":                    date(2021, 10, 12),
+		"last tue":                   date(2021, 10, 12),
+		"thursday":                   date(2021, 10, 14),
+		"last thursday":              date(2021, 10, 7),
+		"this friday":                date(2021, 10, 8),
+		"Sep 28":                     date(2021, 9, 28),
+		"28 september":               date(2021, 9, 28),
+		"28th of September":          date(2021, 9, 28),
+		"September 28th, 2020":       date(2020, 9, 28),
+		"Tuesday 28 September":       date(2021, 9, 28),
+		"Dec 25":                     date(2020, 12, 25),
+		"1/9/2021":                   date(2021, 9, 1),
+		"1/9/21":                     date(2021, 9, 1),
+		"28/9":                       date(2021, 9, 28),
+		"  02  Oct  2021 ":           date(2021, 10, 2),
+		"Wed 1 Sep 2021":             date(2021, 9, 1),
+		"Wednesday 1 September 2021": date(2021, 9, 1),
+	}
+	for value, expected := range examples {
+		if d, err := ParseDate(value, now); err != nil || !d.Equal(expected) {
+			t.Errorf("expected %s to be %v, got %v %v", value, expected, d, err)
+		}
+	}
+
+	for _, value := range []string{"2021/10/01", "9/28/2021", "last", "tu", "sometime", "31 Feb", "3 years ago"} {
+		if d, err := ParseDate(value, now); err == nil {
+			t.Errorf("expected an error for %s, got %v", value, d)
+		}
+	}
+}
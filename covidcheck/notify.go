@@ -0,0 +1,382 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Notifier is a destination which is sent a message about the changes found
+// by a Watcher, so people can be alerted without watching a terminal.
+type Notifier interface {
+	// Notify will send a message about the new and updated entries.
+	Notify(changes Changes) error
+}
+
+// NewNotifier will construct a Notifier from a specification such as
+// "webhook=https://...", "slack=https://...", "discord=https://..." or
+// "desktop".
+func NewNotifier(spec string, emoji bool) (Notifier, error) {
+	name, value := spec, ""
+	if i := strings.Index(spec, "="); i >= 0 {
+		name, value = spec[:i], spec[i+1:]
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(name))
+	if kind != "desktop" && value == "" {
+		return nil, fmt.Errorf("no url found in notifier '%s', expected %s=URL", spec, kind)
+	}
+
+	switch kind {
+	case "webhook":
+		return &WebhookNotifier{URL: value}, nil
+	case "slack":
+		return &SlackNotifier{Webhook: value, Emoji: emoji}, nil
+	case "discord":
+		return &DiscordNotifier{Webhook: value, Emoji: emoji}, nil
+	case "desktop":
+		return &DesktopNotifier{Emoji: emoji}, nil
+	}
+
+	return nil, fmt.Errorf("unknown notifier '%s', expected one of [webhook|slack|discord|desktop]", spec)
+}
+
+// NotifierName will return the name of the notification service of n.
+func NotifierName(n Notifier) string {
+	switch n.(type) {
+	case *MatrixNotifier:
+		return "matrix"
+	case *DiscordNotifier:
+		return "discord"
+	case *WebhookNotifier:
+		return "webhook"
+	case *SlackNotifier:
+		return "slack"
+	case *DesktopNotifier:
+		return "desktop"
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+// notificationText will format the new and updated entries as a plain text
+// message, with one line per exposure site. When emoji is set, each line
+// starts with the glyphs of the Entry.
+func notificationText(changes Changes, emoji bool) string {
+	var b strings.Builder
+	b.WriteString(notificationSummary(changes))
+	for _, group := range []struct {
+		Label   string
+		Entries Entries
+	}{{"New", changes.Added}, {"Updated", changes.Updated}} {
+		for i := range group.Entries.Items {
+			e := &group.Entries.Items[i]
+			b.WriteString("\n")
+			if glyph := e.Glyph(); emoji && glyph != "" {
+				b.WriteString(glyph + " ")
+			}
+			fmt.Fprintf(&b, "%s: %s", group.Label, notificationLine(e))
+		}
+	}
+	b.WriteString(reminderText(changes.Reminders))
+	if guidance := guidanceText(changes.Added, changes.Updated); guidance != "" {
+		b.WriteString("\n" + guidance)
+	}
+	return b.String()
+}
+
+// notificationSummary will return the first line of a notification message,
+// which counts the reminders when there are no new or updated entries.
+func notificationSummary(changes Changes) string {
+	if len(changes.Reminders) > 0 && changes.Added.Len()+changes.Updated.Len() == 0 {
+		return fmt.Sprintf("%d COVID-19 test reminders", len(changes.Reminders))
+	}
+	return fmt.Sprintf("%d new and %d updated COVID-19 exposure sites", changes.Added.Len(), changes.Updated.Len())
+}
+
+// reminderText will format the reminders as lines of a notification message.
+func reminderText(reminders []Reminder) string {
+	var b strings.Builder
+	for i := range reminders {
+		fmt.Fprintf(&b, "\nReminder: %s", reminders[i].Text())
+	}
+	return b.String()
+}
+
+// notificationLine will format a single Entry for a notification message.
+func notificationLine(e *Entry) string {
+	place := []string{}
+	for _, field := range []string{e.ExposureLocation, e.Street, e.Suburb + " " + string(e.State)} {
+		if field = strings.TrimSpace(field); field != "" {
+			place = append(place, field)
+		}
+	}
+	line := fmt.Sprintf("%s on %s %s - %s", strings.Join(place, ", "), formatTime(e.Date, canonicalDateFormat), formatTime(e.ArrivalTime, time.Kitchen), formatTime(e.DepartureTime, time.Kitchen))
+	if e.Contact != "" {
+		line += fmt.Sprintf(" (%s)", e.Contact)
+	}
+	return line
+}
+
+// MatrixNotifier is a Notifier which sends messages to a Matrix room, using
+// the client-server API of the homeserver.
+type MatrixNotifier struct {
+	// Homeserver is the base URL of the Matrix homeserver.
+	Homeserver string
+	// Token is the access token of the user sending the messages.
+	Token string
+	// Room is the ID of the room to send messages to, which the user
+	// must have joined.
+	Room string
+	// Emoji will start each line of the messages with the glyphs of
+	// the exposure site.
+	Emoji bool
+}
+
+// Notify will send the changes as a text message to the room.
+func (m *MatrixNotifier) Notify(changes Changes) error {
+	if m.Token == "" {
+		return fmt.Errorf("MATRIX_ACCESS_TOKEN must be set to notify matrix")
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"msgtype": "m.text",
+		"body":    notificationText(changes, m.Emoji),
+	})
+	if err != nil {
+		return err
+	}
+
+	// The transaction ID only has to be unique for the access token, so
+	// retried requests aren't sent twice.
+	txn := fmt.Sprintf("covid-check-%d", time.Now().UnixNano())
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", strings.TrimSuffix(m.Homeserver, "/"), url.PathEscape(m.Room), txn)
+
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return doNotify(req)
+}
+
+// discordColors are the embed colours for each lowercase contact level.
+var discordColors = map[string]int{
+	"close":   0xe74c3c,
+	"casual":  0xe67e22,
+	"monitor": 0xf1c40f,
+	"":        0x95a5a6,
+}
+
+// discordEmbedLimit is the maximum number of embeds in a Discord message.
+const discordEmbedLimit = 10
+
+// discordEmbed is a rich embed in a Discord webhook message.
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color"`
+	Fields      []discordEmbedField `json:"fields"`
+}
+
+// discordEmbedField is a field of a Discord embed.
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// DiscordNotifier is a Notifier which posts messages with an embed for each
+// exposure site to a Discord webhook.
+type DiscordNotifier struct {
+	// Webhook is the URL of the Discord webhook.
+	Webhook string
+	// Emoji will start the embed titles with the glyphs of the exposure
+	// site.
+	Emoji bool
+}
+
+// Notify will post the changes to the webhook, split into as many messages
+// as needed to stay within the embed limit.
+func (d *DiscordNotifier) Notify(changes Changes) error {
+	embeds := []discordEmbed{}
+	for _, group := range []struct {
+		Label   string
+		Entries Entries
+	}{{"New", changes.Added}, {"Updated", changes.Updated}} {
+		for i := range group.Entries.Items {
+			embed := discordEmbedFor(group.Label, &group.Entries.Items[i])
+			if glyph := group.Entries.Items[i].Glyph(); d.Emoji && glyph != "" {
+				embed.Title = glyph + " " + embed.Title
+			}
+			embeds = append(embeds, embed)
+		}
+	}
+
+	content := notificationSummary(changes) + reminderText(changes.Reminders)
+	for first := true; first || len(embeds) > 0; first = false {
+		n := len(embeds)
+		if n > discordEmbedLimit {
+			n = discordEmbedLimit
+		}
+		if err := postJSON(d.Webhook, map[string]interface{}{
+			"content": content,
+			"embeds":  embeds[:n],
+		}); err != nil {
+			return err
+		}
+		embeds = embeds[n:]
+		content = ""
+	}
+	return nil
+}
+
+// discordEmbedFor will build the embed for an Entry, coloured by its
+// contact level.
+func discordEmbedFor(label string, e *Entry) discordEmbed {
+	color, ok := discordColors[strings.ToLower(string(e.Contact))]
+	if !ok {
+		color = discordColors[""]
+	}
+	contact := e.Contact
+	if contact == "" {
+		contact = "Unknown"
+	}
+	fields := []discordEmbedField{
+		{Name: "Suburb", Value: strings.TrimSpace(e.Suburb + " " + string(e.State)), Inline: true},
+		{Name: "Date", Value: formatTime(e.Date, canonicalDateFormat), Inline: true},
+		{Name: "Time", Value: fmt.Sprintf("%s - %s", formatTime(e.ArrivalTime, time.Kitchen), formatTime(e.DepartureTime, time.Kitchen)), Inline: true},
+		{Name: "Contact", Value: string(contact), Inline: true},
+	}
+	if guidance, ok := e.Guidance(); ok {
+		fields = append(fields, discordEmbedField{Name: "Guidance", Value: guidance.String()})
+	}
+	return discordEmbed{
+		Title:       fmt.Sprintf("%s: %s", label, e.ExposureLocation),
+		Description: e.Street,
+		Color:       color,
+		Fields:      fields,
+	}
+}
+
+// WebhookNotifier is a Notifier which posts the changes as JSON to a URL,
+// for integrating with other services.
+type WebhookNotifier struct {
+	// URL is the address the changes are posted to.
+	URL string
+}
+
+// webhookMessage is the JSON body posted by a WebhookNotifier.
+type webhookMessage struct {
+	Text      string         `json:"text"`
+	Added     []exportRecord `json:"added"`
+	Updated   []exportRecord `json:"updated"`
+	Reminders []Reminder     `json:"reminders,omitempty"`
+}
+
+// Notify will post the changes to the URL.
+func (n *WebhookNotifier) Notify(changes Changes) error {
+	return postJSON(n.URL, webhookMessage{
+		Text:      notificationText(changes, false),
+		Added:     exportRecords(changes.Added.Items),
+		Updated:   exportRecords(changes.Updated.Items),
+		Reminders: changes.Reminders,
+	})
+}
+
+// SlackNotifier is a Notifier which posts messages to a Slack incoming
+// webhook.
+type SlackNotifier struct {
+	// Webhook is the URL of the Slack incoming webhook.
+	Webhook string
+	// Emoji will start each line of the messages with the glyphs of
+	// the exposure site.
+	Emoji bool
+}
+
+// Notify will post the changes as a text message to the webhook.
+func (n *SlackNotifier) Notify(changes Changes) error {
+	return postJSON(n.Webhook, map[string]string{"text": notificationText(changes, n.Emoji)})
+}
+
+// DesktopNotifier is a Notifier which shows a native desktop notification,
+// using notify-send on Linux and osascript on macOS.
+type DesktopNotifier struct {
+	// Emoji will start each line of the notifications with the glyphs of
+	// the exposure site.
+	Emoji bool
+	// Run will run the notification command, which defaults to running it
+	// with os/exec.
+	Run func(name string, args ...string) error
+}
+
+// Notify will show the changes as a desktop notification.
+func (n *DesktopNotifier) Notify(changes Changes) error {
+	run := n.Run
+	if run == nil {
+		run = func(name string, args ...string) error {
+			out, err := exec.Command(name, args...).CombinedOutput()
+			if err != nil {
+				return fmt.Errorf("%s failed: %s %s", name, err.Error(), strings.TrimSpace(string(out)))
+			}
+			return nil
+		}
+	}
+
+	lines := strings.SplitN(notificationText(changes, n.Emoji), "\n", 2)
+	title, body := lines[0], ""
+	if len(lines) > 1 {
+		body = lines[1]
+	}
+
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return run("notify-send", "--app-name=covid-check", title, body)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		return run("osascript", "-e", script)
+	}
+	return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+}
+
+// appleScriptString will quote the input as an AppleScript string.
+func appleScriptString(in string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(in) + "\""
+}
+
+// postJSON will post the body as JSON to the URL.
+func postJSON(url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doNotify(req)
+}
+
+// doNotify will send the notification request and check the response.
+func doNotify(req *http.Request) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("failed to notify %s: %d %s %s", req.URL.Host, resp.StatusCode, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNotify will send changes to fake notification services and check the
+// requests are formed as the services expect.
+func TestNotify(t *testing.T) {
+	date := time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+	start, _ := time.Parse(time.Kitchen, "7:00PM")
+	end, _ := time.Parse(time.Kitchen, "7:30PM")
+	changes := Changes{
+		Added: Entries{Items: []Entry{
+			{ExposureLocation: "ALDI Belconnen", Street: "Benjamin Way", Suburb: "Belconnen", State: "ACT", Date: &date, ArrivalTime: &start, DepartureTime: &end, Contact: "Casual"},
+		}},
+	}
+
+	t.Run("Formatting messages", func(t *testing.T) {
+		expected := "1 new and 0 updated COVID-19 exposure sites\nNew: ALDI Belconnen, Benjamin Way, Belconnen ACT on 04/10/2021 7:00PM - 7:30PM (Casual)\n\nCasual contacts: " + DefaultGuidance["casual"].String()
+		if text := notificationText(changes, false); text != expected {
+			t.Errorf("unexpected message %q", text)
+		}
+		if text := notificationText(changes, true); !strings.Contains(text, "\n🟨 New: ALDI Belconnen") {
+			t.Errorf("expected glyphs in message %q", text)
+		}
+	})
+
+	t.Run("Formatting reminders", func(t *testing.T) {
+		reminders := Changes{Reminders: []Reminder{{Location: "ALDI Belconnen", Contact: "Casual", Exposed: date, Day: 5}}}
+		expected := "1 COVID-19 test reminders\nReminder: get a COVID-19 test today, day 5 after Casual contact at ALDI Belconnen on 04/10/2021"
+		if text := notificationText(reminders, false); text != expected {
+			t.Errorf("unexpected message %q", text)
+		}
+	})
+
+	t.Run("Notifying matrix", func(t *testing.T) {
+		var path, auth string
+		var message map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.EscapedPath()
+			auth = r.Header.Get("Authorization")
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, &message)
+			w.Write([]byte(`{"event_id": "$1"}`))
+		}))
+		defer server.Close()
+
+		m := &MatrixNotifier{Homeserver: server.URL, Token: "secret", Room: "!abc:example.org"}
+		if err := m.Notify(changes); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(path, "/_matrix/client/v3/rooms/%21abc:example.org/send/m.room.message/") {
+			t.Errorf("unexpected path %s", path)
+		}
+		if auth != "Bearer secret" || message["msgtype"] != "m.text" || !strings.Contains(message["body"], "ALDI Belconnen") {
+			t.Fail()
+		}
+		if err := (&MatrixNotifier{Homeserver: server.URL, Room: "!abc:example.org"}).Notify(changes); err == nil {
+			t.Error("expected an error without a token")
+		}
+	})
+
+	t.Run("Notifying discord", func(t *testing.T) {
+		messages := []map[string]json.RawMessage{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			message := map[string]json.RawMessage{}
+			json.Unmarshal(body, &message)
+			messages = append(messages, message)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		many := Changes{}
+		for i := 0; i < 12; i++ {
+			many.Added.Add(changes.Added.Items[0])
+		}
+		if err := (&DiscordNotifier{Webhook: server.URL}).Notify(many); err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 2 {
+			t.Fatalf("expected the embeds to be split into 2 messages, got %d", len(messages))
+		}
+		embeds := []discordEmbed{}
+		json.Unmarshal(messages[0]["embeds"], &embeds)
+		if len(embeds) != discordEmbedLimit || embeds[0].Title != "New: ALDI Belconnen" || embeds[0].Color != discordColors["casual"] {
+			t.Errorf("unexpected embeds %v", embeds)
+		}
+
+		messages = messages[:0]
+		if err := (&DiscordNotifier{Webhook: server.URL}).Notify(Changes{Reminders: []Reminder{{Location: "ALDI Belconnen", Contact: "Casual", Exposed: date}}}); err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 1 || !strings.Contains(string(messages[0]["content"]), "Reminder: get a COVID-19 test now") {
+			t.Errorf("expected a reminder message without embeds, got %v", messages)
+		}
+	})
+
+	t.Run("Parsing notifiers", func(t *testing.T) {
+		examples := map[string]string{
+			"webhook=https://example.org/hook":         "webhook",
+			"slack=https://hooks.slack.com/services/x": "slack",
+			"discord=https://discord.com/api/webhooks": "discord",
+			"desktop": "desktop",
+		}
+		for spec, name := range examples {
+			n, err := NewNotifier(spec, false)
+			if err != nil || NotifierName(n) != name {
+				t.Errorf("expected %s to be a %s notifier, got %v %v", spec, name, n, err)
+			}
+		}
+		for _, spec := range []string{"webhook", "slack=", "pager=123"} {
+			if _, err := NewNotifier(spec, false); err == nil {
+				t.Errorf("expected an error for %s", spec)
+			}
+		}
+	})
+
+	t.Run("Notifying webhooks", func(t *testing.T) {
+		bodies := []map[string]json.RawMessage{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			message := map[string]json.RawMessage{}
+			json.Unmarshal(body, &message)
+			bodies = append(bodies, message)
+		}))
+		defer server.Close()
+
+		if err := (&WebhookNotifier{URL: server.URL}).Notify(changes); err != nil {
+			t.Fatal(err)
+		}
+		if err := (&SlackNotifier{Webhook: server.URL, Emoji: true}).Notify(changes); err != nil {
+			t.Fatal(err)
+		}
+		added := []map[string]string{}
+		json.Unmarshal(bodies[0]["added"], &added)
+		if len(added) != 1 || added[0]["location"] != "ALDI Belconnen" {
+			t.Errorf("unexpected webhook body %v", bodies[0])
+		}
+		if !strings.Contains(string(bodies[1]["text"]), "ALDI Belconnen") {
+			t.Errorf("unexpected slack body %v", bodies[1])
+		}
+	})
+
+	t.Run("Notifying the desktop", func(t *testing.T) {
+		var command []string
+		n := &DesktopNotifier{Run: func(name string, args ...string) error {
+			command = append([]string{name}, args...)
+			return nil
+		}}
+		err := n.Notify(changes)
+		switch runtime.GOOS {
+		case "linux":
+			if err != nil || command[0] != "notify-send" || command[2] != "1 new and 0 updated COVID-19 exposure sites" {
+				t.Errorf("unexpected command %v %v", command, err)
+			}
+		case "darwin":
+			if err != nil || command[0] != "osascript" {
+				t.Errorf("unexpected command %v %v", command, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package covidcheck
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stage is a post-processing step of a Pipeline, which adds to each Entry.
+// Stages which make requests should cache their results, as VenueStage and
+// GeocodeStage do through the Cache of their NominatimGeocoder.
+type Stage interface {
+	// Process will add to the Entry. It can be called concurrently for
+	// different entries.
+	Process(e *Entry) error
+}
+
+// PipelineStage is a Stage in a Pipeline.
+type PipelineStage struct {
+	// Name is the name of the Stage, used in errors.
+	Name string
+	// Stage is the Stage which is run.
+	Stage Stage
+	// Concurrency is the number of entries processed at once, which is
+	// one when it isn't set.
+	Concurrency int
+}
+
+// Pipeline is an ordered list of stages run over Entries, where each stage
+// processes every Entry before the next stage starts.
+type Pipeline struct {
+	Stages []PipelineStage
+}
+
+// ParsePipeline will build a Pipeline from a comma separated list of stage
+// names, each optionally followed by a concurrency such as "venue:2", using
+// the available stages.
+func ParsePipeline(spec string, available map[string]Stage) (*Pipeline, error) {
+	p := &Pipeline{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, concurrency := part, 1
+		if i := strings.Index(part, ":"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("stage concurrency must be a positive number: could not parse '%s'", part)
+			}
+			name, concurrency = part[:i], n
+		}
+		name = strings.ToLower(name)
+		stage, ok := available[name]
+		if !ok {
+			names := []string{}
+			for k := range available {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown stage '%s', expected one of [%s]", name, strings.Join(names, "|"))
+		}
+		p.Stages = append(p.Stages, PipelineStage{Name: name, Stage: stage, Concurrency: concurrency})
+	}
+	return p, nil
+}
+
+// Has will check whether the Pipeline includes the named stage.
+func (p *Pipeline) Has(name string) bool {
+	for _, s := range p.Stages {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Run will run each stage over the entries in order. A stage stops at its
+// first error, which is returned without running the remaining stages.
+func (p *Pipeline) Run(entries *Entries) error {
+	for _, s := range p.Stages {
+		start := time.Now()
+		if err := s.run(entries); err != nil {
+			return fmt.Errorf("%s: %s", s.Name, err.Error())
+		}
+		DefaultLogger.Debug("ran pipeline stage", "stage", s.Name, "entries", entries.Len(), "duration", time.Since(start))
+	}
+	return nil
+}
+
+// run will process the entries with the concurrency of the stage.
+func (s *PipelineStage) run(entries *Entries) error {
+	workers := s.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var first error
+	failed := make(chan struct{})
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := s.Stage.Process(&entries.Items[i]); err != nil {
+					once.Do(func() {
+						first = err
+						close(failed)
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range entries.Items {
+		select {
+		case jobs <- i:
+		case <-failed:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return first
+}
+
+// VenueStage is a Stage which looks up the Venue of each Entry.
+type VenueStage struct {
+	Lookup VenueLookup
+}
+
+// Process will set the Venue of the Entry, which is left unset when it
+// can't be found.
+func (s *VenueStage) Process(e *Entry) error {
+	venue, err := s.Lookup.LookupVenue(e)
+	if err != nil {
+		return err
+	}
+	e.Venue = venue
+	return nil
+}
+
+// GeocodeStage is a Stage which finds the location of each Entry.
+type GeocodeStage struct {
+	Geocoder Geocoder
+}
+
+// Process will set the Point of the Entry, which is left unset when its
+// address can't be found.
+func (s *GeocodeStage) Process(e *Entry) error {
+	if p, err := GeocodeEntry(s.Geocoder, e); err == nil {
+		e.Point = &p
+	}
+	return nil
+}
+
+// ClassifyStage is a Stage which records the venue category of each Entry,
+// so it is fixed by the stages before it.
+type ClassifyStage struct{}
+
+// Process will set the Category of the Entry.
+func (s *ClassifyStage) Process(e *Entry) error {
+	e.Category = e.VenueCategory()
+	return nil
+}
+
+// Enrich will look up the Venue of each of the entries, leaving it unset
+// for the venues which can't be found.
+func Enrich(l VenueLookup, entries *Entries) error {
+	p := &Pipeline{Stages: []PipelineStage{{Name: "venue", Stage: &VenueStage{Lookup: l}}}}
+	return p.Run(entries)
+}
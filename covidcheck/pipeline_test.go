@@ -0,0 +1,112 @@
+package covidcheck
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countingStage is a Stage which counts the entries it processes, and fails
+// on the entries at the location.
+type countingStage struct {
+	processed int32
+	running   int32
+	most      int32
+	mu        sync.Mutex
+	fail      string
+}
+
+func (s *countingStage) Process(e *Entry) error {
+	running := atomic.AddInt32(&s.running, 1)
+	defer atomic.AddInt32(&s.running, -1)
+	s.mu.Lock()
+	if running > s.most {
+		s.most = running
+	}
+	s.mu.Unlock()
+
+	atomic.AddInt32(&s.processed, 1)
+	if s.fail != "" && e.ExposureLocation == s.fail {
+		return errors.New("failed")
+	}
+	e.Status = "processed"
+	return nil
+}
+
+// TestPipeline will run stages over static entries and check they are
+// parsed, ordered and run as expected.
+func TestPipeline(t *testing.T) {
+	entries := func() Entries {
+		e := Entries{}
+		for i := 0; i < 20; i++ {
+			e.Add(Entry{ExposureLocation: "Bus Route 2"})
+		}
+		return e
+	}
+
+	t.Run("Parsing pipelines", func(t *testing.T) {
+		available := map[string]Stage{"classify": &ClassifyStage{}, "count": &countingStage{}}
+		p, err := ParsePipeline(" count:3, classify", available)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Stages) != 2 || p.Stages[0].Concurrency != 3 || p.Stages[1].Concurrency != 1 || !p.Has("classify") || p.Has("venue") {
+			t.Errorf("unexpected pipeline %+v", p)
+		}
+		if p, err := ParsePipeline("", available); err != nil || len(p.Stages) != 0 {
+			t.Error("expected an empty pipeline")
+		}
+		if _, err := ParsePipeline("venue", available); err == nil || !strings.Contains(err.Error(), "[classify|count]") {
+			t.Errorf("expected an unknown stage error, got %v", err)
+		}
+		if _, err := ParsePipeline("count:0", available); err == nil {
+			t.Error("expected a concurrency error")
+		}
+	})
+
+	t.Run("Running stages", func(t *testing.T) {
+		counter := &countingStage{}
+		items := entries()
+		p := &Pipeline{Stages: []PipelineStage{
+			{Name: "count", Stage: counter, Concurrency: 4},
+			{Name: "classify", Stage: &ClassifyStage{}},
+		}}
+		if err := p.Run(&items); err != nil {
+			t.Fatal(err)
+		}
+		if counter.processed != 20 || counter.most > 4 {
+			t.Errorf("expected 20 entries processed 4 at a time, got %d and %d", counter.processed, counter.most)
+		}
+		for _, e := range items.Items {
+			if e.Status != "processed" || e.Category != "transport" {
+				t.Fatalf("unexpected entry %+v", e)
+			}
+		}
+	})
+
+	t.Run("Stopping at errors", func(t *testing.T) {
+		counter := &countingStage{fail: "Bus Route 2"}
+		items := entries()
+		after := &countingStage{}
+		p := &Pipeline{Stages: []PipelineStage{{Name: "count", Stage: counter}, {Name: "after", Stage: after}}}
+		if err := p.Run(&items); err == nil || err.Error() != "count: failed" {
+			t.Errorf("expected the stage error, got %v", err)
+		}
+		if counter.processed == 20 || after.processed != 0 {
+			t.Errorf("expected the pipeline to stop, got %d and %d", counter.processed, after.processed)
+		}
+	})
+
+	t.Run("Geocoding entries", func(t *testing.T) {
+		items := Entries{Items: []Entry{{Suburb: "Phillip", State: "ACT"}, {Suburb: "Nowhere"}}}
+		g := staticGeocoder{"Phillip ACT, Australia": {Lat: -35.3468, Lon: 149.0860}}
+		if err := (&GeocodeStage{Geocoder: g}).Process(&items.Items[0]); err != nil || items.Items[0].Point == nil {
+			t.Error("expected the entry to be geocoded")
+		}
+		if err := (&GeocodeStage{Geocoder: g}).Process(&items.Items[1]); err != nil || items.Items[1].Point != nil {
+			t.Error("expected an unknown address to be skipped")
+		}
+	})
+}
@@ -0,0 +1,411 @@
+package covidcheck
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+
+	// MultiQuery is a bool slice which filtered results must validate against.
+	MultiQuery []bool
+
+	// MultiQueries is a struct with a MultiQuery to store filter results for
+	// an individual Entry. It is intended that a successful filter will have
+	// all items in Items value as true, otherwise the item will be omitted
+	// from the final result.
+	MultiQueries struct {
+		Items MultiQuery
+	}
+
+	// QueryParams are extra settings for Query operation which aren't associated
+	// to the Filter values.
+	QueryParams struct {
+		// PrintRAWCSV is a bool which will instruct the Query operation to print
+		// the values, rather than append them to the output list for rendering.
+		PrintRAWCSV bool
+	}
+
+	// Filter is the set of values which Entries are queried against. Every
+	// field which is set must match for an Entry to be included.
+	Filter struct {
+		// Status is the filter for the status field.
+		Status string
+		// ExposureLocation is the filter for the location field.
+		ExposureLocation string
+		// Street is the filter for the street field.
+		Street string
+		// Suburb is the filter for the suburb field.
+		Suburb string
+		// State is the filter for the state field.
+		State string
+		// Date is the filter for the date field, matching on the day.
+		Date *time.Time
+		// Since is the earliest day of the date field, inclusive.
+		Since *time.Time
+		// Until is the latest day of the date field, inclusive.
+		Until *time.Time
+		// ArrivalTime is the filter for the arrival time, matched against
+		// the time formatted like "9:00AM".
+		ArrivalTime string
+		// DepartureTime is the filter for the departure time, matched
+		// against the time formatted like "5:00PM".
+		DepartureTime string
+		// Contact is the filter for the contact field.
+		Contact string
+		// Trust is the filter for the trust label.
+		Trust string
+		// Expression is a filter expression which must also match, for
+		// combining conditions with boolean logic.
+		Expression Expression
+		// Regex will match the filters as case-insensitive regular
+		// expressions, instead of substrings. A single filter can opt in
+		// with the "re:" prefix.
+		Regex bool
+		// Fuzzy will match the location, street and suburb filters by the
+		// similarity of their words, so typos such as "Belconen" still
+		// match. Regular expressions are matched as they are.
+		Fuzzy bool
+		// FuzzyThreshold is the similarity between 0 and 1 of the words
+		// matched by Fuzzy, which is DefaultFuzzyThreshold when zero.
+		FuzzyThreshold float64
+		// ExcludeStatus are the values of the status field to filter out.
+		ExcludeStatus []string
+		// ExcludeLocation are the values of the location field to filter
+		// out.
+		ExcludeLocation []string
+		// ExcludeSuburb are the values of the suburb field to filter out.
+		ExcludeSuburb []string
+		// ExcludeContact are the values of the contact field to filter out.
+		ExcludeContact []string
+		// Queries are arbitrary queries which must all match anything in
+		// the Entry.
+		Queries []string
+		// NotQueries are arbitrary queries which must not match anything in
+		// the Entry.
+		NotQueries []string
+		// MinRisk is the minimum risk score of the Entry, calculated with
+		// the DefaultRiskModel.
+		MinRisk float64
+		// Near is the centre of a distance filter, which requires the
+		// Geocoder to locate each Entry.
+		Near *Point
+		// Radius is the maximum distance in kilometres from Near.
+		Radius float64
+		// Geocoder is used to locate each Entry for the Near filter.
+		Geocoder Geocoder
+	}
+)
+
+// regexPrefix opts a single filter into regex matching.
+const regexPrefix = "re:"
+
+// matchValue will check whether the value matches the pattern, which is a
+// case-insensitive substring unless regex is set or the pattern has the
+// "re:" prefix, when it is a case-insensitive regular expression. The
+// pattern "nil" matches empty values, and invalid expressions never match.
+func matchValue(pattern, value string, regex bool) bool {
+	if strings.HasPrefix(pattern, regexPrefix) {
+		pattern, regex = strings.TrimPrefix(pattern, regexPrefix), true
+	}
+	if strings.ToLower(pattern) == "nil" && value == "" {
+		return true
+	}
+	if regex {
+		re, err := compilePattern(pattern)
+		return err == nil && re.MatchString(value)
+	}
+	return strings.Contains(strings.ToLower(value), strings.ToLower(pattern))
+}
+
+// patterns are the compiled regular expressions, so they are only compiled
+// once for all of the entries.
+var patterns = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: map[string]*regexp.Regexp{}}
+
+// compilePattern will compile the pattern as a case-insensitive regular
+// expression.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patterns.Lock()
+	defer patterns.Unlock()
+	if re, ok := patterns.m[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.m[pattern] = re
+	return re, nil
+}
+
+// check will provide field validation, and will add the result to a
+// *MultiQueries if the validation passes. This will later be checked
+// before being added to the filtered results in Query. Prefixing the
+// input with "!" will negate it, so only values which don't match pass.
+func check(a, b interface{}, regex bool, mq *MultiQueries) bool {
+	found := false
+	if a == nil {
+		return false
+	}
+	switch v := a.(type) {
+	case string:
+		negate := len(v) > 1 && strings.HasPrefix(v, "!")
+		if negate {
+			v = v[1:]
+		}
+		// Note: time is also handled via string.
+		found = matchValue(v, b.(string), regex)
+		if negate {
+			found = !found
+		}
+	default:
+		DefaultLogger.Debug("no handler for the filter", "value", v)
+	}
+
+	if found {
+		mq.Items = append(mq.Items, true)
+		return true
+	}
+
+	mq.Items = append(mq.Items, false)
+	return false
+}
+
+// checkName will validate a field naming a place like check, where the
+// input also matches the values with the same name in the DefaultAliases,
+// and the words of the input are matched fuzzily with fuzzy unless it is a
+// regular expression or "nil".
+func checkName(pattern, value string, regex, fuzzy bool, threshold float64, mq *MultiQueries) bool {
+	negate := len(pattern) > 1 && strings.HasPrefix(pattern, "!")
+	if negate {
+		pattern = pattern[1:]
+	}
+	literal := !regex && !strings.HasPrefix(pattern, regexPrefix)
+	match := func(pattern, value string) bool {
+		if fuzzy && literal && strings.ToLower(pattern) != "nil" {
+			return fuzzyMatch(pattern, value, threshold)
+		}
+		return matchValue(pattern, value, regex)
+	}
+
+	found := match(pattern, value)
+	if canonical := DefaultAliases.Canonical(value); !found && value != "" {
+		if literal {
+			pattern = DefaultAliases.Canonical(pattern)
+		}
+		found = match(pattern, canonical)
+	}
+	if negate {
+		found = !found
+	}
+	mq.Items = append(mq.Items, found)
+	return found
+}
+
+// Validate will check the filters which are regular expressions compile,
+// so mistakes are reported instead of silently matching nothing.
+func (f *Filter) Validate() error {
+	fields := []struct {
+		Name   string
+		Values []string
+	}{
+		{"status", []string{f.Status}},
+		{"location", []string{f.ExposureLocation}},
+		{"street", []string{f.Street}},
+		{"suburb", []string{f.Suburb}},
+		{"state", []string{f.State}},
+		{"start-time", []string{f.ArrivalTime}},
+		{"end-time", []string{f.DepartureTime}},
+		{"contact", []string{f.Contact}},
+		{"trust", []string{f.Trust}},
+		{"exclude-status", f.ExcludeStatus},
+		{"exclude-location", f.ExcludeLocation},
+		{"exclude-suburb", f.ExcludeSuburb},
+		{"exclude-contact", f.ExcludeContact},
+	}
+	for _, queries := range []struct {
+		Name   string
+		Values []string
+	}{
+		{"query", f.Queries},
+		{"query-not", f.NotQueries},
+	} {
+		for _, query := range queries.Values {
+			terms, err := parseQuery(query)
+			if err != nil {
+				return fmt.Errorf("invalid -%s: %s", queries.Name, err.Error())
+			}
+			values := []string{}
+			for _, term := range terms {
+				values = append(values, term.Pattern)
+			}
+			fields = append(fields, struct {
+				Name   string
+				Values []string
+			}{queries.Name, values})
+		}
+	}
+	if f.FuzzyThreshold < 0 || f.FuzzyThreshold > 1 {
+		return fmt.Errorf("fuzzy-threshold must be between 0 and 1, not %v", f.FuzzyThreshold)
+	}
+	for _, field := range fields {
+		for _, value := range field.Values {
+			value = strings.TrimPrefix(value, "!")
+			if !f.Regex && !strings.HasPrefix(value, regexPrefix) {
+				continue
+			}
+			if _, err := compilePattern(strings.TrimPrefix(value, regexPrefix)); err != nil {
+				return fmt.Errorf("invalid regex in -%s '%s': %s", field.Name, value, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// Query will clear out the FilteredResults field and repopulate it by querying
+// each result against the input Filter object.
+func (x *Client) Query(e *Filter, params QueryParams) {
+	if fmt.Sprint(*e) == fmt.Sprint(x.Filter) {
+		return
+	}
+	x.Filter = *e
+	x.FilteredResults = Entries{}
+	start := time.Now()
+	defer func() {
+		DefaultLogger.Info("queried exposure sites", "entries", x.RawResults.Len(), "matches", x.FilteredResults.Len(), "duration", time.Since(start))
+	}()
+	threshold := e.FuzzyThreshold
+	if threshold == 0 {
+		threshold = DefaultFuzzyThreshold
+	}
+	queries, notQueries := parseQueries(e.Queries), parseQueries(e.NotQueries)
+	for _, dataEntry := range x.RawResults.Items {
+
+		mq := MultiQueries{}
+		match := true
+
+		if e.Status != "" {
+			if b := check(e.Status, string(dataEntry.Status), e.Regex, &mq); b {
+				match = true
+			}
+		}
+		if e.ExposureLocation != "" {
+			if b := checkName(e.ExposureLocation, dataEntry.ExposureLocation, e.Regex, e.Fuzzy, threshold, &mq); b {
+				match = true
+			}
+		}
+		if e.Street != "" {
+			if b := checkName(e.Street, dataEntry.Street, e.Regex, e.Fuzzy, threshold, &mq); b {
+				match = true
+			}
+		}
+		if e.Suburb != "" {
+			if b := checkName(e.Suburb, dataEntry.Suburb, e.Regex, e.Fuzzy, threshold, &mq); b {
+				match = true
+			}
+		}
+		if e.State != "" {
+			if b := check(e.State, string(dataEntry.State), e.Regex, &mq); b {
+				match = true
+			}
+		}
+		if e.Date != nil && !e.Date.IsZero() {
+			// The day is compared whole, so 1/10 doesn't match 11/10.
+			b := inRange(dataEntry.Date, e.Date, e.Date)
+			mq.Items = append(mq.Items, b)
+			if b {
+				match = true
+			}
+		}
+		if e.Since != nil || e.Until != nil {
+			mq.Items = append(mq.Items, inRange(dataEntry.Date, e.Since, e.Until))
+		}
+		if e.ArrivalTime != "" {
+			if b := check(e.ArrivalTime, formatTime(dataEntry.ArrivalTime, time.Kitchen), e.Regex, &mq); b {
+				match = true
+			}
+		}
+		if e.DepartureTime != "" {
+			if b := check(e.DepartureTime, formatTime(dataEntry.DepartureTime, time.Kitchen), e.Regex, &mq); b {
+				match = true
+			}
+		}
+		if e.Contact != "" {
+			if b := check(e.Contact, string(dataEntry.Contact), e.Regex, &mq); b {
+				match = true
+			}
+		}
+
+		if e.Trust != "" {
+			if b := check(e.Trust, dataEntry.Trust, e.Regex, &mq); b {
+				match = true
+			}
+		}
+
+		for _, exclude := range []struct {
+			Values []string
+			Field  string
+		}{
+			{e.ExcludeStatus, string(dataEntry.Status)},
+			{e.ExcludeContact, string(dataEntry.Contact)},
+		} {
+			for _, value := range exclude.Values {
+				check("!"+value, exclude.Field, e.Regex, &mq)
+			}
+		}
+		// Places are excluded by their aliases too, but never fuzzily.
+		for _, exclude := range []struct {
+			Values []string
+			Field  string
+		}{
+			{e.ExcludeLocation, dataEntry.ExposureLocation},
+			{e.ExcludeSuburb, dataEntry.Suburb},
+		} {
+			for _, value := range exclude.Values {
+				checkName("!"+value, exclude.Field, e.Regex, false, threshold, &mq)
+			}
+		}
+
+		if e.Expression != nil {
+			mq.Items = append(mq.Items, e.Expression.Match(&dataEntry))
+		}
+
+		if e.MinRisk > 0 {
+			mq.Items = append(mq.Items, dataEntry.Risk() >= e.MinRisk)
+		}
+
+		for _, terms := range queries {
+			mq.Items = append(mq.Items, matchQuery(terms, &dataEntry, e.Regex))
+		}
+		for _, terms := range notQueries {
+			mq.Items = append(mq.Items, !matchQuery(terms, &dataEntry, e.Regex))
+		}
+
+		for _, v := range mq.Items {
+			if !v {
+				match = false
+			}
+		}
+
+		// Geocoding is slow, so it is only done for otherwise matching
+		// entries.
+		if match && e.Near != nil && e.Geocoder != nil {
+			p, err := GeocodeEntry(e.Geocoder, &dataEntry)
+			match = err == nil && Distance(*e.Near, p) <= e.Radius
+		}
+
+		if match && !params.PrintRAWCSV {
+			x.FilteredResults.Items = append(x.FilteredResults.Items, dataEntry)
+		}
+
+		if match && params.PrintRAWCSV {
+			fmt.Print(rawCSVLine(&dataEntry))
+		}
+	}
+}
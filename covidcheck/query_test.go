@@ -0,0 +1,151 @@
+package covidcheck
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryResults(t *testing.T) {
+	covid, err := GenerateData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("Running query 1/3", func(t *testing.T) {
+		result := false
+		timeFilter, _ := time.Parse("02/01/2006", "28/09/2021")
+
+		covid.Query(&Filter{
+			ExposureLocation: "7-Eleven Holt",
+			Date:             &timeFilter,
+			Suburb:           "Holt",
+		}, QueryParams{
+			PrintRAWCSV: false,
+		})
+
+		if len(covid.FilteredResults.Items) > 0 {
+			result = true
+
+		}
+
+		if !result {
+			t.Fail()
+		}
+	})
+
+	t.Run("Running query 2/3", func(t *testing.T) {
+		result := false
+		timeFilter, _ := time.Parse("02/01/2006", "04/10/2021")
+		covid.Query(&Filter{
+			ExposureLocation: "ALDI Belconnen",
+			Date:             &timeFilter,
+			Suburb:           "Belconnen",
+		}, QueryParams{
+			PrintRAWCSV: false,
+		})
+
+		if len(covid.FilteredResults.Items) > 0 {
+			result = true
+
+		}
+		if !result {
+			t.Fail()
+		}
+	})
+
+	t.Run("Running query 3/3", func(t *testing.T) {
+		result := false
+		timeFilter, _ := time.Parse("02/01/2006", "09/10/2021")
+		covid.Query(&Filter{
+			ExposureLocation: "Coles Kaleen",
+			Date:             &timeFilter,
+			Suburb:           "Kaleen",
+		}, QueryParams{
+			PrintRAWCSV: false,
+		})
+
+		if len(covid.FilteredResults.Items) > 0 {
+			result = true
+
+		}
+		if !result {
+			t.Fail()
+		}
+	})
+}
+
+// TestQueryExclusions will query static entries with negated filters and
+// check the matching entries are filtered out.
+func TestQueryExclusions(t *testing.T) {
+	entries := Entries{Items: []Entry{
+		{ExposureLocation: "ALDI Belconnen", Suburb: "Belconnen", Status: "New", Contact: "Close"},
+		{ExposureLocation: "Coles Gungahlin", Suburb: "Gungahlin", Status: "Archived", Contact: "Casual"},
+		{ExposureLocation: "Coles Kaleen", Suburb: "Kaleen", Status: "", Contact: "Monitor"},
+	}}
+
+	examples := []struct {
+		Name     string
+		Filter   Filter
+		Expected int
+	}{
+		{"Negating a filter", Filter{Suburb: "!gungahlin"}, 2},
+		{"Negating an empty value", Filter{Status: "!nil"}, 2},
+		{"Negating a query", Filter{Queries: []string{"!coles"}}, 1},
+		{"Excluding a suburb", Filter{ExcludeSuburb: []string{"gungahlin", "kaleen"}}, 1},
+		{"Excluding a status and contact", Filter{ExcludeStatus: []string{"archived"}, ExcludeContact: []string{"close"}}, 1},
+		{"Excluding a location", Filter{Suburb: "n", ExcludeLocation: []string{"aldi"}}, 2},
+	}
+	for _, example := range examples {
+		t.Run(example.Name, func(t *testing.T) {
+			covid := &Client{RawResults: entries}
+			covid.Query(&example.Filter, QueryParams{})
+			if len(covid.FilteredResults.Items) != example.Expected {
+				t.Errorf("expected %d results, got %d", example.Expected, len(covid.FilteredResults.Items))
+			}
+		})
+	}
+}
+
+// TestQueryRegex will query static entries with substring and regex filters
+// and check regex is only used when opted into.
+func TestQueryRegex(t *testing.T) {
+	entries := Entries{Items: []Entry{
+		{ExposureLocation: "ALDI Belconnen", Suburb: "Belconnen"},
+		{ExposureLocation: "Coles (Kaleen)", Suburb: "Kaleen"},
+		{ExposureLocation: "Woolworths Bruce", Suburb: "Bruce"},
+	}}
+
+	examples := []struct {
+		Name     string
+		Filter   Filter
+		Expected int
+	}{
+		{"Matching substrings", Filter{ExposureLocation: "(kaleen)"}, 1},
+		{"Not matching substrings as regex", Filter{Suburb: "bel.*"}, 0},
+		{"Matching with -regex", Filter{Regex: true, Suburb: "^(belconnen|bruce)$"}, 2},
+		{"Matching with re:", Filter{ExposureLocation: "re:^aldi|^woolworths"}, 2},
+		{"Negating re:", Filter{ExposureLocation: "!re:^aldi"}, 2},
+		{"Matching queries with -regex", Filter{Regex: true, Queries: []string{"k.+n"}}, 1},
+	}
+	for _, example := range examples {
+		t.Run(example.Name, func(t *testing.T) {
+			covid := &Client{RawResults: entries}
+			covid.Query(&example.Filter, QueryParams{})
+			if len(covid.FilteredResults.Items) != example.Expected {
+				t.Errorf("expected %d results, got %d", example.Expected, len(covid.FilteredResults.Items))
+			}
+		})
+	}
+
+	t.Run("Validating regex", func(t *testing.T) {
+		if err := (&Filter{Suburb: "(kaleen"}).Validate(); err != nil {
+			t.Errorf("expected substrings not to be validated, got %s", err)
+		}
+		if err := (&Filter{Regex: true, Suburb: "(kaleen"}).Validate(); err == nil || !strings.Contains(err.Error(), "-suburb") {
+			t.Errorf("expected an invalid -suburb error, got %v", err)
+		}
+		if err := (&Filter{Queries: []string{"!re:[a-"}}).Validate(); err == nil {
+			t.Error("expected an invalid query error")
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package covidcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// queryTerm is a term of an arbitrary query, matching the field it is
+// scoped to, or any of the fields when it isn't scoped.
+type queryTerm struct {
+	// Field is the name of the field matched, or empty for any field.
+	Field string
+	// Pattern is matched against the field like a filter.
+	Pattern string
+	// Negate will only match entries the pattern doesn't.
+	Negate bool
+}
+
+// queryFields are the fields of an Entry which query terms can be scoped
+// to, with the text of each matched by the terms. The dates of entries are
+// matched as both 04/10/2021 and 4-10-2021, as they are written in tables.
+var queryFields = map[string]func(e *Entry) []string{
+	"status":   func(e *Entry) []string { return []string{string(e.Status)} },
+	"location": func(e *Entry) []string { return []string{e.ExposureLocation} },
+	"street":   func(e *Entry) []string { return []string{e.Street} },
+	"suburb":   func(e *Entry) []string { return []string{e.Suburb} },
+	"state":    func(e *Entry) []string { return []string{string(e.State)} },
+	"date": func(e *Entry) []string {
+		return []string{formatTime(e.Date, canonicalDateFormat), formatTime(e.Date, "2-1-2006")}
+	},
+	"start":   func(e *Entry) []string { return []string{formatTime(e.ArrivalTime, time.Kitchen)} },
+	"end":     func(e *Entry) []string { return []string{formatTime(e.DepartureTime, time.Kitchen)} },
+	"contact": func(e *Entry) []string { return []string{string(e.Contact)} },
+	"trust":   func(e *Entry) []string { return []string{e.Trust} },
+}
+
+// queryFieldOrder are the names of the queryFields in the order unscoped
+// terms are matched against them.
+var queryFieldOrder = queryFieldNames()
+
+// queryFieldNames will return the names of the queryFields, sorted.
+func queryFieldNames() []string {
+	names := []string{}
+	for name := range queryFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// splitQuery will split a query into its words, where double quotes keep a
+// phrase with spaces together as one word, such as location:"coles kaleen".
+func splitQuery(query string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	quoted, started := false, false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted, started = !quoted, true
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if started {
+				words = append(words, word.String())
+			}
+			word.Reset()
+			started = false
+		default:
+			word.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("could not parse query '%s': expected a closing '\"'", query)
+	}
+	if started {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// parseQuery will parse an arbitrary query into its terms, which must all
+// match. A term such as suburb:kaleen is scoped to a field, and either the
+// term or its pattern can be prefixed with "!" to negate it. A word whose
+// prefix isn't a field, such as 7:00pm or re:^aldi, is matched against any
+// field, although a prefix of letters which isn't a field or "re" is
+// reported as a mistake.
+func parseQuery(query string) ([]queryTerm, error) {
+	words, err := splitQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	terms := []queryTerm{}
+	for _, word := range words {
+		term := queryTerm{Pattern: word}
+		if len(term.Pattern) > 1 && strings.HasPrefix(term.Pattern, "!") {
+			term.Negate, term.Pattern = true, term.Pattern[1:]
+		}
+		if i := strings.Index(term.Pattern, ":"); i > 0 {
+			field := strings.ToLower(term.Pattern[:i])
+			if _, ok := queryFields[field]; ok {
+				term.Field, term.Pattern = field, term.Pattern[i+1:]
+			} else if isLetters(field) && term.Pattern[:i+1] != regexPrefix {
+				return nil, fmt.Errorf("unknown field '%s' in query '%s', expected one of [%s]", field, query, strings.Join(queryFieldOrder, "|"))
+			}
+		}
+		if len(term.Pattern) > 1 && strings.HasPrefix(term.Pattern, "!") {
+			term.Negate, term.Pattern = !term.Negate, term.Pattern[1:]
+		}
+		if term.Pattern == "" {
+			return nil, fmt.Errorf("could not parse query '%s': expected a value after '%s'", query, word)
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+// parseQueries will parse each of the queries, where a query which can't be
+// parsed, as Filter.Validate reports, is matched as a whole against any
+// field.
+func parseQueries(queries []string) [][]queryTerm {
+	parsed := [][]queryTerm{}
+	for _, query := range queries {
+		terms, err := parseQuery(query)
+		if err != nil {
+			terms = []queryTerm{{Pattern: query}}
+		}
+		parsed = append(parsed, terms)
+	}
+	return parsed
+}
+
+// isLetters will check whether the text is only ASCII letters.
+func isLetters(text string) bool {
+	for _, r := range text {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return text != ""
+}
+
+// matchQuery will check whether every term matches the Entry. A term which
+// isn't scoped matches when any field does, and the fields naming places
+// are also matched by their DefaultAliases.
+func matchQuery(terms []queryTerm, e *Entry, regex bool) bool {
+	for _, term := range terms {
+		fields := []string{term.Field}
+		if term.Field == "" {
+			fields = queryFieldOrder
+		}
+		found := false
+		for _, field := range fields {
+			for _, value := range queryFields[field](e) {
+				mq := MultiQueries{}
+				if field == "location" || field == "street" || field == "suburb" {
+					found = checkName(term.Pattern, value, regex, false, 0, &mq)
+				} else {
+					found = check(term.Pattern, value, regex, &mq)
+				}
+				if found {
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if found == term.Negate {
+			return false
+		}
+	}
+	return true
+}
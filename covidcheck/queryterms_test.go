@@ -0,0 +1,98 @@
+package covidcheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestQueryTerms will parse arbitrary queries into their terms, and check
+// each term is matched against the fields it is scoped to.
+func TestQueryTerms(t *testing.T) {
+	t.Run("Parsing terms", func(t *testing.T) {
+		for query, expected := range map[string][]queryTerm{
+			"kaleen":                        {{Pattern: "kaleen"}},
+			"location:coles suburb:kaleen":  {{Field: "location", Pattern: "coles"}, {Field: "suburb", Pattern: "kaleen"}},
+			`location:"coles kaleen"  ACT`:  {{Field: "location", Pattern: "coles kaleen"}, {Pattern: "ACT"}},
+			`"coles kaleen"`:                {{Pattern: "coles kaleen"}},
+			"!suburb:kaleen Suburb:!bruce":  {{Field: "suburb", Pattern: "kaleen", Negate: true}, {Field: "suburb", Pattern: "bruce", Negate: true}},
+			"7:00pm start:7:00pm re:^aldi":  {{Pattern: "7:00pm"}, {Field: "start", Pattern: "7:00pm"}, {Pattern: "re:^aldi"}},
+			"contact:re:^close$ 04/10/2021": {{Field: "contact", Pattern: "re:^close$"}, {Pattern: "04/10/2021"}},
+			"":                              {},
+		} {
+			terms, err := parseQuery(query)
+			if err != nil {
+				t.Errorf("could not parse %q: %s", query, err)
+				continue
+			}
+			if !reflect.DeepEqual(terms, expected) {
+				t.Errorf("expected %q to be parsed as %+v, got %+v", query, expected, terms)
+			}
+		}
+	})
+
+	t.Run("Reporting mistakes", func(t *testing.T) {
+		for query, reason := range map[string]string{
+			"lcation:coles":   "unknown field 'lcation'",
+			`location:"coles`: "expected a closing",
+			"suburb:":         "expected a value",
+		} {
+			if _, err := parseQuery(query); err == nil || !strings.Contains(err.Error(), reason) {
+				t.Errorf("expected %q to be reported as %q, got %v", query, reason, err)
+			}
+			if err := (&Filter{Queries: []string{query}}).Validate(); err == nil || !strings.Contains(err.Error(), "-query") {
+				t.Errorf("expected %q to be reported by Validate, got %v", query, err)
+			}
+		}
+		if err := (&Filter{NotQueries: []string{"suburb:re:(kaleen"}}).Validate(); err == nil || !strings.Contains(err.Error(), "-query-not") {
+			t.Errorf("expected the invalid regex to be reported, got %v", err)
+		}
+	})
+
+	t.Run("Matching fields", func(t *testing.T) {
+		date := time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+		arrival := time.Date(0, 1, 1, 19, 0, 0, 0, time.UTC)
+		entries := Entries{Items: []Entry{
+			{ExposureLocation: "Coles Kaleen", Street: "Gwydir Square", Suburb: "Kaleen", State: "ACT", Contact: ContactCasual, Date: &date, ArrivalTime: &arrival},
+			{ExposureLocation: "Kaleen Plaza Pharmacy", Street: "Georgina Crescent", Suburb: "Kaleen", State: "ACT", Contact: ContactClose, Date: &date},
+			{ExposureLocation: "Coles Belconnen", Street: "Westfield Belconnen", Suburb: "Belconnen", State: "ACT", Contact: ContactMonitor},
+			{ExposureLocation: "Glebe Park", Suburb: "Civic", State: "ACT"},
+		}}
+		for _, test := range []struct {
+			Queries, NotQueries []string
+			Matches             int
+		}{
+			{[]string{"location:coles suburb:kaleen"}, nil, 1},
+			{[]string{"coles kaleen"}, nil, 1},
+			{[]string{"coles"}, []string{"suburb:kaleen"}, 1},
+			{[]string{"kaleen"}, nil, 2},
+			{[]string{"location:kaleen"}, nil, 2},
+			{[]string{"street:kaleen"}, nil, 0},
+			{[]string{"suburb:!kaleen"}, nil, 2},
+			{[]string{"contact:close"}, nil, 1},
+			{[]string{"date:4-10-2021", "date:04/10/2021"}, nil, 2},
+			{[]string{"start:7:00pm"}, nil, 1},
+			{[]string{"suburb:city"}, nil, 1},
+			{[]string{"0xc"}, nil, 0},
+			{[]string{"{"}, nil, 0},
+			{nil, []string{"coles kaleen"}, 3},
+		} {
+			covid := &Client{RawResults: entries}
+			covid.Query(&Filter{Queries: test.Queries, NotQueries: test.NotQueries}, QueryParams{})
+			if covid.FilteredResults.Len() != test.Matches {
+				t.Errorf("expected %d matches of %q and not %q, got %d", test.Matches, test.Queries, test.NotQueries, covid.FilteredResults.Len())
+			}
+		}
+	})
+
+	t.Run("Highlighting scoped terms", func(t *testing.T) {
+		f := &Filter{Queries: []string{"location:coles kaleen !plaza"}}
+		if v := f.highlightCell("location", "Coles Kaleen Plaza"); v != underlined("Coles")+" "+underlined("Kaleen")+" Plaza" {
+			t.Errorf("unexpected location %q", v)
+		}
+		if v := f.highlightCell("street", "Coles Lane"); v != "Coles Lane" {
+			t.Errorf("expected the scoped term not to highlight other fields, got %q", v)
+		}
+	})
+}
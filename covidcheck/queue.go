@@ -0,0 +1,221 @@
+package covidcheck
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Notification is a message to a notifier which has been queued in a
+// NotificationQueue, until it is delivered or gives up.
+type Notification struct {
+	// ID identifies the notifier and the changes, so the same message is
+	// never queued or delivered twice.
+	ID string `json:"id"`
+	// Notifier is the name of the notifier the message is sent to.
+	Notifier string `json:"notifier"`
+	// Added are the new entries of the message.
+	Added []Entry `json:"added,omitempty"`
+	// Updated are the updated entries of the message.
+	Updated []Entry `json:"updated,omitempty"`
+	// Reminders are the test reminders of the message.
+	Reminders []Reminder `json:"reminders,omitempty"`
+	// Queued is when the message was queued.
+	Queued time.Time `json:"queued"`
+	// Attempts is the number of times sending the message has failed.
+	Attempts int `json:"attempts"`
+	// Next is when the message should next be sent.
+	Next time.Time `json:"next"`
+	// Error is the error of the last failed attempt.
+	Error string `json:"error,omitempty"`
+	// Delivered is when the message was sent, which is zero until it has
+	// been.
+	Delivered time.Time `json:"delivered,omitempty"`
+	// Failed is set once every attempt to send the message has failed, when
+	// Next is when it gave up.
+	Failed bool `json:"failed,omitempty"`
+}
+
+// Changes will return the changes the message is about.
+func (n *Notification) Changes() Changes {
+	return Changes{Added: Entries{Items: n.Added}, Updated: Entries{Items: n.Updated}, Reminders: n.Reminders}
+}
+
+// pending will check whether the message is still to be sent at now.
+func (n *Notification) pending(now time.Time) bool {
+	return n.Delivered.IsZero() && !n.Failed && !n.Next.After(now)
+}
+
+// notificationID will return the ID of a message about the changes to the
+// notifier, which changes with the status and contact level of each entry.
+func notificationID(notifier string, changes Changes) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", notifier)
+	for _, group := range []Entries{changes.Added, changes.Updated} {
+		for i := range group.Items {
+			e := &group.Items[i]
+			fmt.Fprintf(h, "%s|%s|%s\n", e.Hash(), e.Status, e.Contact)
+		}
+		fmt.Fprintln(h)
+	}
+	for i := range changes.Reminders {
+		fmt.Fprintf(h, "%s\n", changes.Reminders[i].key())
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// NotificationQueue is a JSON file of the messages sent to notifiers, so
+// messages which fail to send are retried with backoff on later polls
+// instead of being lost, and messages are never delivered twice.
+type NotificationQueue struct {
+	// Path is the path of the JSON file.
+	Path string
+	// Retry is the backoff between attempts, where Retries is the number
+	// of times a message is retried before it gives up.
+	Retry RetryPolicy
+	// Keep is how long delivered and failed messages are kept for, so they
+	// aren't queued again.
+	Keep time.Duration
+	// Now returns the current time, used to schedule the attempts.
+	Now func() time.Time
+}
+
+// DefaultNotificationRetry is the backoff between attempts to send a queued
+// message, which is waited out between polls rather than slept.
+var DefaultNotificationRetry = RetryPolicy{
+	Retries: 10,
+	Wait:    time.Minute,
+	MaxWait: time.Hour,
+}
+
+// DefaultNotificationKeep is how long delivered and failed messages are kept
+// in a queue.
+var DefaultNotificationKeep = 7 * 24 * time.Hour
+
+// NewNotificationQueue will return a NotificationQueue in the user state
+// directory, which is $XDG_STATE_HOME/covid-check on Linux.
+func NewNotificationQueue() (*NotificationQueue, error) {
+	dir, err := defaultStateDir()
+	if err != nil {
+		return nil, err
+	}
+	return &NotificationQueue{Path: filepath.Join(dir, "notifications.json"), Retry: DefaultNotificationRetry, Keep: DefaultNotificationKeep}, nil
+}
+
+// now will return the current time of the queue.
+func (q *NotificationQueue) now() time.Time {
+	if q.Now != nil {
+		return q.Now()
+	}
+	return time.Now()
+}
+
+// Load will return the messages in the queue, which is empty when the file
+// does not exist yet.
+func (q *NotificationQueue) Load() ([]Notification, error) {
+	data, err := ioutil.ReadFile(q.Path)
+	if os.IsNotExist(err) {
+		return []Notification{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	notifications := []Notification{}
+	if err := json.Unmarshal(data, &notifications); err != nil {
+		return nil, fmt.Errorf("could not parse notifications in %s: %s", q.Path, err.Error())
+	}
+	return notifications, nil
+}
+
+// Save will replace the messages in the queue, leaving out the delivered
+// and failed messages older than Keep. The file is replaced atomically, so
+// the queue isn't corrupted if covid-check is stopped while saving.
+func (q *NotificationQueue) Save(notifications []Notification) error {
+	keep := make([]Notification, 0, len(notifications))
+	for _, n := range notifications {
+		done := n.Delivered
+		if n.Failed {
+			done = n.Next
+		}
+		if q.Keep > 0 && !done.IsZero() && q.now().Sub(done) > q.Keep {
+			continue
+		}
+		keep = append(keep, n)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(q.Path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(keep, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(q.Path, append(data, '\n'), 0644)
+}
+
+// Enqueue will queue a message about the changes to the notifier, unless the
+// same message has already been queued. The ID of the message is returned.
+func (q *NotificationQueue) Enqueue(notifier string, changes Changes) (string, error) {
+	notifications, err := q.Load()
+	if err != nil {
+		return "", err
+	}
+	id := notificationID(notifier, changes)
+	for i := range notifications {
+		if notifications[i].ID == id {
+			return id, nil
+		}
+	}
+	now := q.now()
+	notifications = append(notifications, Notification{
+		ID:        id,
+		Notifier:  notifier,
+		Added:     changes.Added.Items,
+		Updated:   changes.Updated.Items,
+		Reminders: changes.Reminders,
+		Queued:    now,
+		Next:      now,
+	})
+	return id, q.Save(notifications)
+}
+
+// Flush will call send with each message which is due, marking it as
+// delivered when send succeeds. A failed message is retried after a backoff,
+// until it has been retried Retry.Retries times. The messages which were
+// attempted are returned, with the Error of those which failed.
+func (q *NotificationQueue) Flush(send func(n *Notification) error) ([]Notification, error) {
+	notifications, err := q.Load()
+	if err != nil {
+		return nil, err
+	}
+	now := q.now()
+	attempted := []Notification{}
+	for i := range notifications {
+		n := &notifications[i]
+		if !n.pending(now) {
+			continue
+		}
+		if err := send(n); err != nil {
+			n.Error = err.Error()
+			n.Attempts++
+			if n.Attempts > q.Retry.Retries {
+				n.Failed = true
+				n.Next = now
+			} else {
+				n.Next = now.Add(q.Retry.backoff(n.Attempts - 1))
+			}
+		} else {
+			n.Error = ""
+			n.Delivered = now
+		}
+		attempted = append(attempted, *n)
+	}
+	if len(attempted) == 0 {
+		return attempted, nil
+	}
+	return attempted, q.Save(notifications)
+}
@@ -0,0 +1,103 @@
+package covidcheck
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestNotificationQueue will queue static changes for two notifiers, and
+// check failed messages are retried with backoff and only delivered once.
+func TestNotificationQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2021, 10, 6, 12, 0, 0, 0, time.UTC)
+	queue := &NotificationQueue{
+		Path:  filepath.Join(dir, "state", "notifications.json"),
+		Retry: RetryPolicy{Retries: 1, Wait: time.Minute, MaxWait: time.Hour},
+		Keep:  24 * time.Hour,
+		Now:   func() time.Time { return now },
+	}
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV) {
+		entries.Add(fieldTranslate(record))
+	}
+	changes := Changes{Added: entries}
+
+	t.Run("Queueing messages", func(t *testing.T) {
+		slack, err := queue.Enqueue("slack", changes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _ := queue.Enqueue("slack", changes)
+		webhook, _ := queue.Enqueue("webhook", changes)
+		if slack != again || slack == webhook {
+			t.Errorf("expected ids to identify the notifier and changes, got %s %s %s", slack, again, webhook)
+		}
+		notifications, err := queue.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(notifications) != 2 || len(notifications[0].Changes().Added.Items) != 2 {
+			t.Errorf("unexpected notifications %+v", notifications)
+		}
+	})
+
+	t.Run("Retrying failed messages", func(t *testing.T) {
+		sent := map[string]int{}
+		send := func(n *Notification) error {
+			if n.Notifier == "webhook" {
+				return errors.New("offline")
+			}
+			sent[n.Notifier]++
+			return nil
+		}
+
+		attempted, err := queue.Flush(send)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attempted) != 2 || attempted[0].Error != "" || attempted[1].Error != "offline" || attempted[1].Attempts != 1 {
+			t.Fatalf("unexpected attempts %+v", attempted)
+		}
+		if next := attempted[1].Next.Sub(now); next < 30*time.Second || next > time.Minute {
+			t.Errorf("expected a backoff of up to a minute, got %s", next)
+		}
+		if attempted, _ := queue.Flush(send); len(attempted) != 0 {
+			t.Errorf("expected nothing to be due during the backoff, got %+v", attempted)
+		}
+
+		now = now.Add(time.Hour)
+		attempted, _ = queue.Flush(send)
+		if len(attempted) != 1 || !attempted[0].Failed || attempted[0].Attempts != 2 {
+			t.Errorf("expected the webhook to give up, got %+v", attempted)
+		}
+		if sent["slack"] != 1 {
+			t.Errorf("expected slack to be sent once, got %d", sent["slack"])
+		}
+		if _, err := queue.Enqueue("slack", changes); err != nil {
+			t.Fatal(err)
+		}
+		if attempted, _ := queue.Flush(send); len(attempted) != 0 {
+			t.Errorf("expected the delivered message not to be queued again, got %+v", attempted)
+		}
+	})
+
+	t.Run("Pruning old messages", func(t *testing.T) {
+		now = now.Add(48 * time.Hour)
+		notifications, _ := queue.Load()
+		if err := queue.Save(notifications); err != nil {
+			t.Fatal(err)
+		}
+		if notifications, _ := queue.Load(); len(notifications) != 0 {
+			t.Errorf("expected old messages to be pruned, got %+v", notifications)
+		}
+	})
+}
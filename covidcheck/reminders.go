@@ -0,0 +1,244 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReminderSchedule is the days after an exposure on which people should get
+// tested, for each lowercase contact level. Day 0 is as soon as the exposure
+// is found, and contact levels without days are not reminded.
+type ReminderSchedule map[string][]int
+
+// DefaultReminderSchedule follows the testing guidance for close and casual
+// contacts.
+var DefaultReminderSchedule = ReminderSchedule{
+	"close":  {0, 5, 12},
+	"casual": {0, 5},
+}
+
+// ParseReminderSchedule will parse a schedule such as "close=0,5,12;casual=0,5",
+// which replaces the days of the contact levels it names.
+func ParseReminderSchedule(value string) (ReminderSchedule, error) {
+	schedule := ReminderSchedule{}
+	for k, v := range DefaultReminderSchedule {
+		schedule[k] = v
+	}
+	for _, level := range strings.Split(value, ";") {
+		if strings.TrimSpace(level) == "" {
+			continue
+		}
+		i := strings.Index(level, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("reminder days are formatted as contact=days,...: could not parse '%s'", level)
+		}
+		contact := strings.ToLower(strings.TrimSpace(level[:i]))
+		days := []int{}
+		for _, d := range strings.Split(level[i+1:], ",") {
+			if strings.TrimSpace(d) == "" {
+				continue
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(d))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("reminder days are whole numbers of days: could not parse '%s'", d)
+			}
+			days = append(days, n)
+		}
+		schedule[contact] = days
+	}
+	return schedule, nil
+}
+
+// Reminder is a scheduled reminder to get tested after an exposure.
+type Reminder struct {
+	// Person is the name of the person exposed, if known.
+	Person string `json:"person,omitempty"`
+	// Hash identifies the exposure site.
+	Hash string `json:"hash"`
+	// Location is the name of the exposure site.
+	Location string `json:"location"`
+	// Contact is the contact level of the exposure site.
+	Contact Contact `json:"contact"`
+	// Exposed is the day of the exposure.
+	Exposed time.Time `json:"exposed"`
+	// Day is the number of days after the exposure the reminder is for.
+	Day int `json:"day"`
+	// Due is when the reminder should be sent.
+	Due time.Time `json:"due"`
+	// Sent is set once the reminder has been sent.
+	Sent bool `json:"sent"`
+}
+
+// key will return the identity of the reminder, so the same reminder is not
+// scheduled twice.
+func (r *Reminder) key() string {
+	return fmt.Sprintf("%s|%s|%d", r.Person, r.Hash, r.Day)
+}
+
+// Text will return the reminder as a line of a notification message.
+func (r *Reminder) Text() string {
+	line := fmt.Sprintf("get a COVID-19 test today, day %d after %s contact at %s on %s", r.Day, r.Contact, r.Location, r.Exposed.Format(canonicalDateFormat))
+	if r.Day == 0 {
+		line = fmt.Sprintf("get a COVID-19 test now after %s contact at %s on %s", r.Contact, r.Location, r.Exposed.Format(canonicalDateFormat))
+	}
+	if r.Person != "" {
+		line = r.Person + ": " + line
+	}
+	return line
+}
+
+// ReminderStore is a JSON file of scheduled reminders, which the check
+// subcommand adds to and watch mode sends when they are due.
+type ReminderStore struct {
+	// Path is the path of the JSON file.
+	Path string
+	// Now returns the current time, used to schedule and send reminders.
+	Now func() time.Time
+}
+
+// NewReminderStore will return a ReminderStore in the user state directory,
+// which is $XDG_STATE_HOME/covid-check on Linux.
+func NewReminderStore() (*ReminderStore, error) {
+	dir, err := defaultStateDir()
+	if err != nil {
+		return nil, err
+	}
+	return &ReminderStore{Path: filepath.Join(dir, "reminders.json")}, nil
+}
+
+// defaultStateDir will return the covid-check directory of the user state
+// directory, which is $XDG_STATE_HOME/covid-check on Linux.
+func defaultStateDir() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "covid-check"), nil
+}
+
+// now will return the current time of the store.
+func (s *ReminderStore) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// Load will return the reminders in the store, which is empty when the file
+// does not exist yet.
+func (s *ReminderStore) Load() ([]Reminder, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return []Reminder{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	reminders := []Reminder{}
+	if err := json.Unmarshal(data, &reminders); err != nil {
+		return nil, fmt.Errorf("could not parse reminders in %s: %s", s.Path, err.Error())
+	}
+	return reminders, nil
+}
+
+// Save will replace the reminders in the store.
+func (s *ReminderStore) Save(reminders []Reminder) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(reminders, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.Path, append(data, '\n'), 0644)
+}
+
+// Schedule will add reminders for the exposures of the person, on each day
+// of the schedule for their contact level which hasn't passed. Reminders
+// which were already scheduled are not added again, and the added
+// reminders are returned.
+func (s *ReminderStore) Schedule(person string, exposures []Exposure, schedule ReminderSchedule) ([]Reminder, error) {
+	reminders, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	scheduled := map[string]bool{}
+	for i := range reminders {
+		scheduled[reminders[i].key()] = true
+	}
+
+	now := s.now()
+	added := []Reminder{}
+	for _, x := range exposures {
+		for _, d := range schedule[strings.ToLower(string(x.Entry.Contact))] {
+			r := Reminder{
+				Person:   person,
+				Hash:     x.Entry.Hash(),
+				Location: x.Entry.ExposureLocation,
+				Contact:  x.Entry.Contact,
+				Exposed:  day(x.Visit.Date),
+				Day:      d,
+				Due:      now,
+			}
+			if d > 0 {
+				r.Due = r.Exposed.AddDate(0, 0, d)
+				if r.Due.Before(day(now)) {
+					continue
+				}
+			}
+			if scheduled[r.key()] {
+				continue
+			}
+			scheduled[r.key()] = true
+			added = append(added, r)
+		}
+	}
+	if len(added) == 0 {
+		return added, nil
+	}
+
+	reminders = append(reminders, added...)
+	sort.SliceStable(reminders, func(i, j int) bool {
+		return reminders[i].Due.Before(reminders[j].Due)
+	})
+	return added, s.Save(reminders)
+}
+
+// Fire will call send with the reminders which are due and haven't been
+// sent, and record them as sent when send succeeds.
+func (s *ReminderStore) Fire(send func([]Reminder) error) error {
+	reminders, err := s.Load()
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	due := []Reminder{}
+	for _, r := range reminders {
+		if !r.Sent && !r.Due.After(now) {
+			due = append(due, r)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	if err := send(due); err != nil {
+		return err
+	}
+	for i := range reminders {
+		if !reminders[i].Sent && !reminders[i].Due.After(now) {
+			reminders[i].Sent = true
+		}
+	}
+	return s.Save(reminders)
+}
@@ -0,0 +1,88 @@
+package covidcheck
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReminders will schedule reminders for static exposures and check they
+// are only due, and only sent, on the expected days.
+func TestReminders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-reminders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	exposed := time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2021, 10, 6, 12, 0, 0, 0, time.UTC)
+	store := &ReminderStore{Path: filepath.Join(dir, "state", "reminders.json"), Now: func() time.Time { return now }}
+	exposures := []Exposure{
+		{Visit: Visit{Place: "ALDI", Date: exposed}, Entry: Entry{ExposureLocation: "ALDI Belconnen", Contact: "Casual", Date: &exposed}},
+		{Visit: Visit{Place: "Coles", Date: exposed}, Entry: Entry{ExposureLocation: "Coles Kaleen", Contact: "Monitor", Date: &exposed}},
+	}
+
+	t.Run("Parsing schedules", func(t *testing.T) {
+		schedule, err := ParseReminderSchedule("casual=0,6; monitor=1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(schedule["casual"]) != 2 || schedule["casual"][1] != 6 || len(schedule["monitor"]) != 1 || len(schedule["close"]) != 3 {
+			t.Errorf("unexpected schedule %v", schedule)
+		}
+		if schedule, _ := ParseReminderSchedule("close="); len(schedule["close"]) != 0 {
+			t.Error("expected close contacts to have no reminders")
+		}
+		for _, value := range []string{"casual", "casual=five", "casual=-1"} {
+			if _, err := ParseReminderSchedule(value); err == nil {
+				t.Errorf("expected an error parsing %s", value)
+			}
+		}
+	})
+
+	t.Run("Scheduling reminders", func(t *testing.T) {
+		added, err := store.Schedule("alice", exposures, DefaultReminderSchedule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(added) != 2 || added[0].Day != 0 || !added[0].Due.Equal(now) || added[1].Day != 5 || !added[1].Due.Equal(exposed.AddDate(0, 0, 5)) {
+			t.Errorf("unexpected reminders %v", added)
+		}
+		if again, _ := store.Schedule("alice", exposures, DefaultReminderSchedule); len(again) != 0 {
+			t.Error("expected reminders not to be scheduled twice")
+		}
+		if passed, _ := store.Schedule("bob", exposures, ReminderSchedule{"casual": {1}}); len(passed) != 0 {
+			t.Error("expected passed days to be skipped")
+		}
+	})
+
+	t.Run("Firing reminders", func(t *testing.T) {
+		sent := []Reminder{}
+		send := func(due []Reminder) error {
+			sent = append(sent, due...)
+			return nil
+		}
+		if err := store.Fire(func([]Reminder) error { return errors.New("offline") }); err == nil {
+			t.Error("expected the send error")
+		}
+		store.Fire(send)
+		store.Fire(send)
+		if len(sent) != 1 || sent[0].Day != 0 {
+			t.Fatalf("expected only the day 0 reminder to be sent once, got %v", sent)
+		}
+		if text := sent[0].Text(); text != "alice: get a COVID-19 test now after Casual contact at ALDI Belconnen on 04/10/2021" {
+			t.Errorf("unexpected reminder %q", text)
+		}
+
+		now = exposed.AddDate(0, 0, 5)
+		store.Fire(send)
+		if len(sent) != 2 || !strings.Contains(sent[1].Text(), "day 5 after Casual contact") {
+			t.Errorf("expected the day 5 reminder, got %v", sent)
+		}
+	})
+}
@@ -0,0 +1,250 @@
+package covidcheck
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/olekukonko/tablewriter"
+)
+
+// RenderParams are the settings for the Render operation.
+type RenderParams struct {
+	// Width is the width of the table columns.
+	Width int
+	// Limit is the maximum number of rows to render, or 0 for all rows.
+	Limit int
+	// Risk will add a column with the risk score of each Entry.
+	Risk bool
+	// Slug will add a column with the shareable slug of each Entry.
+	Slug bool
+	// Hours will add a column flagging the entries with exposure windows
+	// outside the usual opening hours of the venue.
+	Hours bool
+	// Emoji will add a column with the glyphs of each Entry.
+	Emoji bool
+	// Truncate will cut values wider than Width with an ellipsis, instead
+	// of wrapping them over several lines.
+	Truncate bool
+	// Footnotes will number the truncated values, and list their full
+	// values below the table.
+	Footnotes bool
+	// Markdown will render a GitHub-flavored Markdown table, which can be
+	// pasted into issues, wikis and chat, instead of a text table.
+	Markdown bool
+	// Columns are the names of the columns to render in order, replacing
+	// the default columns and those added by Risk and Slug, or nil for the
+	// default columns.
+	Columns []string
+	// Color will colour the contact level of each row by how severe it is,
+	// for terminals. It has no effect on Markdown tables.
+	Color bool
+	// Highlight will underline the parts of each row matched by the Filter
+	// of the last Query, so it is clear why the row matched. Like Color,
+	// it is for terminals and has no effect on Markdown tables.
+	Highlight bool
+}
+
+// contactColors are the terminal colours of the contact levels in a table.
+var contactColors = map[Contact]tablewriter.Colors{
+	ContactClose:   {tablewriter.Bold, tablewriter.FgRedColor},
+	ContactCasual:  {tablewriter.FgYellowColor},
+	ContactMonitor: {tablewriter.FgGreenColor},
+}
+
+// markdownEscaper escapes the characters of a cell which would break a
+// Markdown table.
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\n", " ", "\r", "")
+
+// truncator will shorten table cells to a display width, keeping the full
+// values of what was cut as numbered footnotes when enabled.
+type truncator struct {
+	Width     int
+	Footnotes bool
+	Notes     []string
+}
+
+// cell will return the value cut to the display width on a rune boundary,
+// with an ellipsis and footnote marker when it was too wide.
+func (t *truncator) cell(value string) string {
+	if t.Width <= 0 || runewidth.StringWidth(value) <= t.Width {
+		return value
+	}
+	tail := "…"
+	if t.Footnotes {
+		t.Notes = append(t.Notes, value)
+		tail = fmt.Sprintf("…[%d]", len(t.Notes))
+	}
+	return runewidth.Truncate(value, t.Width, tail)
+}
+
+// Render will render the table displaying the data to the user. A trust
+// column is added when the results mix official and unofficial entries.
+func (x *Client) Render(w io.Writer, params RenderParams) {
+
+	selected := selectColumns(params.Columns)
+	if len(params.Columns) == 0 {
+		selected = selectColumns([]string{"status", "location", "street", "suburb", "state"})
+		selected = append(selected, column{Name: "time", Header: "Date/Time", Text: func(e *Entry) string {
+			return fmt.Sprintf("%v %v - %v", formatTime(e.Date, "2-1-2006"), formatTime(e.ArrivalTime, time.Kitchen), formatTime(e.DepartureTime, time.Kitchen))
+		}})
+		selected = append(selected, selectColumns([]string{"contact"})...)
+		if x.FilteredResults.MixedTrust() {
+			selected = append(selected, selectColumns([]string{"trust"})...)
+		}
+		if params.Risk {
+			selected = append(selected, selectColumns([]string{"risk"})...)
+		}
+	}
+	if params.Hours {
+		selected = append(selected, column{Name: "hours", Header: "Hours", Text: func(e *Entry) string {
+			if !e.PlausibleHours() {
+				return "implausible"
+			}
+			return ""
+		}})
+	}
+	if params.Slug && len(params.Columns) == 0 {
+		selected = append(selected, selectColumns([]string{"slug"})...)
+	}
+
+	table := tablewriter.NewWriter(w)
+	header := []string{}
+	contact := -1
+	for n, c := range selected {
+		header = append(header, c.Header)
+		if c.Name == "contact" {
+			contact = n
+		}
+	}
+	if params.Emoji {
+		header = append([]string{""}, header...)
+		if contact >= 0 {
+			contact++
+		}
+	}
+	table.SetHeader(header)
+	table.SetCaption(false, "COVID-19 Exposure Sites")
+	table.SetColWidth(params.Width)
+	if params.Markdown {
+		table.SetAutoFormatHeaders(false)
+		table.SetAutoWrapText(false)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+	}
+
+	cut := &truncator{Width: params.Width, Footnotes: params.Footnotes}
+	for i := range x.FilteredResults.Items {
+		item := &x.FilteredResults.Items[i]
+		if params.Limit != 0 && i >= params.Limit {
+			continue
+		}
+
+		s := []string{}
+		for _, c := range selected {
+			value := c.Text(item)
+			// Only the free text columns are cut, so dates stay whole.
+			if params.Truncate && (c.Name == "location" || c.Name == "street" || c.Name == "suburb") {
+				value = cut.cell(value)
+			}
+			if params.Highlight && !params.Markdown {
+				value = x.Filter.highlightCell(c.Name, value)
+			}
+			s = append(s, value)
+		}
+		if params.Emoji {
+			s = append([]string{item.Glyph()}, s...)
+		}
+		if params.Markdown {
+			for n := range s {
+				s[n] = markdownEscaper.Replace(s[n])
+			}
+		}
+		if color, ok := contactColors[item.Contact]; ok && contact >= 0 && params.Color && !params.Markdown {
+			colors := make([]tablewriter.Colors, len(s))
+			colors[contact] = color
+			table.Rich(s, colors)
+			continue
+		}
+		table.Append(s)
+	}
+
+	if len(x.FilteredResults.Items) == 0 {
+		fmt.Fprintln(w, "no results found")
+		return
+	}
+
+	table.Render()
+	for n, note := range cut.Notes {
+		fmt.Fprintf(w, "[%d] %s\n", n+1, note)
+	}
+
+}
+
+// RenderEntry will render every field of a single Entry to the user.
+func RenderEntry(w io.Writer, e *Entry) {
+	// The label of the source is shown when it differs from the Contact it
+	// was mapped onto.
+	contact := string(e.Contact)
+	if e.ContactLabel != "" && !strings.EqualFold(e.ContactLabel, contact) {
+		contact += " (" + e.ContactLabel + ")"
+	}
+	fields := [][]string{
+		{"Slug", e.Slug()},
+		{"Status", string(e.Status)},
+		{"Location", e.ExposureLocation},
+		{"Street", e.Street},
+		{"Suburb", e.Suburb},
+		{"State", string(e.State)},
+		{"Date", formatTime(e.Date, "Monday 02/01/2006")},
+		{"Time", fmt.Sprintf("%s - %s", formatTime(e.ArrivalTime, time.Kitchen), formatTime(e.DepartureTime, time.Kitchen))},
+		{"Contact", contact},
+		{"Trust", e.Trust},
+		{"Risk", fmt.Sprintf("%.2f", e.Risk())},
+	}
+
+	if v := e.Venue; v != nil {
+		fields = append(fields, []string{"Venue", strings.TrimSpace(v.Name + " (" + v.Tag + ")")})
+		if v.Point != nil {
+			fields = append(fields, []string{"Map", fmt.Sprintf("%.6f,%.6f", v.Point.Lat, v.Point.Lon)})
+		}
+		for _, field := range [][]string{{"Website", v.Website}, {"Phone", v.Phone}, {"Hours", v.OpeningHours}} {
+			if field[1] != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	if !e.PlausibleHours() {
+		fields = append(fields, []string{"Warning", "the time is outside the usual opening hours of the venue, so may be a data entry error"})
+	}
+	if guidance, ok := e.Guidance(); ok {
+		fields = append(fields, []string{"Guidance", guidance.Text}, []string{"Link", guidance.URL})
+	}
+	for _, field := range fields {
+		fmt.Fprintf(w, "%-9s %s\n", field[0]+":", field[1])
+	}
+}
+
+// contactGlyphs are the glyphs for each lowercase contact level.
+var contactGlyphs = map[string]string{
+	"close":   "🟥",
+	"casual":  "🟨",
+	"monitor": "🟩",
+}
+
+// Glyph will return the glyphs for the contact level and status of the
+// Entry, such as "🟥" for a close contact or "🟨🆕" for a new casual one.
+func (e *Entry) Glyph() string {
+	glyph := contactGlyphs[strings.ToLower(string(e.Contact))]
+	if strings.EqualFold(string(e.Status), "new") {
+		glyph += "🆕"
+	}
+	return glyph
+}
+
+// rawCSVLine will format an Entry as a line of the raw csv output.
+func rawCSVLine(dataEntry *Entry) string {
+	return fmt.Sprintf("\"%v\",\"%v\",\"%v\",\"%v\",\"%v\",\"%v\",\"%v\",\"%v\",\"%v\"\n", dataEntry.Status, dataEntry.ExposureLocation, dataEntry.Street, dataEntry.Suburb, dataEntry.State, formatTime(dataEntry.Date, "02/1/2006 - Monday"), formatTime(dataEntry.ArrivalTime, time.Kitchen), formatTime(dataEntry.DepartureTime, time.Kitchen), dataEntry.Contact)
+}
@@ -0,0 +1,124 @@
+package covidcheck
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// TestRender will render tables of static entries and check long values are
+// truncated on rune boundaries when requested.
+func TestRender(t *testing.T) {
+	t.Run("Truncating values", func(t *testing.T) {
+		cut := &truncator{Width: 10}
+		if v := cut.cell("Café Über Straße"); v != "Café Über…" || !utf8.ValidString(v) {
+			t.Errorf("unexpected value %q", v)
+		}
+		if v := cut.cell("Short"); v != "Short" {
+			t.Errorf("unexpected value %q", v)
+		}
+		if v := cut.cell("東京ラーメン横丁"); v != "東京ラー…" {
+			t.Errorf("expected wide runes to count twice, got %q", v)
+		}
+	})
+
+	t.Run("Rendering footnotes", func(t *testing.T) {
+		covid := &Client{}
+		for _, record := range readCSV(actTestCSV) {
+			e := fieldTranslate(record)
+			covid.AddFiltered(&e)
+		}
+
+		var buf bytes.Buffer
+		covid.Render(&buf, RenderParams{Width: 20, Truncate: true, Footnotes: true})
+		out := buf.String()
+		if !strings.Contains(out, "Westfield Belcon…[1]") {
+			t.Errorf("expected a footnote marker in %s", out)
+		}
+		if !strings.Contains(out, "[1] Westfield Belconnen, Benjamin Way") || !strings.Contains(out, "[3] Shop 5, Kaleen Shopping Centre, Georgina Crescent") {
+			t.Errorf("expected the full values as footnotes in %s", out)
+		}
+	})
+
+	t.Run("Rendering markdown", func(t *testing.T) {
+		covid := &Client{}
+		for _, record := range readCSV(actTestCSV) {
+			e := fieldTranslate(record)
+			covid.AddFiltered(&e)
+		}
+		covid.FilteredResults.Items[0].ExposureLocation = "ALDI | Belconnen"
+
+		var buf bytes.Buffer
+		covid.Render(&buf, RenderParams{Width: 10, Markdown: true})
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2+len(covid.FilteredResults.Items) || !strings.Contains(lines[0], " Status ") || !strings.HasPrefix(lines[1], "|---") {
+			t.Fatalf("unexpected markdown table %s", buf.String())
+		}
+		if !strings.Contains(lines[2], "ALDI \\| Belconnen") || !strings.Contains(lines[2], "Westfield Belconnen, Benjamin Way") {
+			t.Errorf("expected escaped cells without wrapping, got %s", lines[2])
+		}
+	})
+
+	t.Run("Rendering glyphs", func(t *testing.T) {
+		examples := map[string]Entry{
+			"🟥":  {Contact: "Close"},
+			"🟨🆕": {Contact: "casual", Status: "New"},
+			"🟩":  {Contact: "Monitor", Status: "Archived"},
+			"":   {},
+		}
+		for glyph, e := range examples {
+			if e.Glyph() != glyph {
+				t.Errorf("expected %q, got %q", glyph, e.Glyph())
+			}
+		}
+	})
+
+	t.Run("Rendering colours", func(t *testing.T) {
+		covid := &Client{}
+		for _, record := range readCSV(actTestCSV) {
+			e := fieldTranslate(record)
+			covid.AddFiltered(&e)
+		}
+
+		var buf bytes.Buffer
+		covid.Render(&buf, RenderParams{Width: 50, Color: true})
+		if !strings.Contains(buf.String(), "\033[33mCasual") || !strings.Contains(buf.String(), "\033[1;31mClose") {
+			t.Errorf("expected coloured contact levels in %q", buf.String())
+		}
+		buf.Reset()
+		covid.Render(&buf, RenderParams{Width: 50, Color: true, Markdown: true})
+		covid.Render(&buf, RenderParams{Width: 50})
+		if strings.Contains(buf.String(), "\033[") {
+			t.Errorf("expected no colours in %q", buf.String())
+		}
+	})
+
+	t.Run("Rendering entries without dates", func(t *testing.T) {
+		date := time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+		covid := &Client{RawResults: Entries{Items: []Entry{
+			{ExposureLocation: "Undated", Suburb: "Belconnen"},
+			{ExposureLocation: "Dated", Suburb: "Belconnen", Date: &date},
+		}}}
+		sort.Sort(&covid.RawResults)
+		if covid.RawResults.Items[1].ExposureLocation != "Undated" {
+			t.Errorf("expected entries without dates to sort last, got %+v", covid.RawResults.Items)
+		}
+		covid.Query(&Filter{Date: &date, ArrivalTime: "7:00PM"}, QueryParams{})
+		covid.FilteredResults = covid.RawResults
+
+		var buf bytes.Buffer
+		covid.Render(&buf, RenderParams{Width: 30})
+		RenderEntry(&buf, &covid.FilteredResults.Items[1])
+		for _, format := range []string{"csv", "json", "html", "geojson"} {
+			if err := covid.Export(&buf, format, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !strings.Contains(buf.String(), `"Undated","","Belconnen","","","",""`) {
+			t.Errorf("expected empty dates and times in the raw csv, got %s", buf.String())
+		}
+	})
+}
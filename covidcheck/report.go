@@ -0,0 +1,192 @@
+package covidcheck
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportSchemaVersion is the version of the Report schema, which is
+// incremented whenever a field is changed or removed.
+const ReportSchemaVersion = 1
+
+// Report is a structured record of a run, written as JSON so pipelines can
+// gate on the exposure conditions and archive the evidence of each run.
+type Report struct {
+	// SchemaVersion is the ReportSchemaVersion the Report was written with.
+	SchemaVersion int `json:"schema_version"`
+	// GeneratedAt is when the Report was generated, formatted as RFC3339.
+	GeneratedAt string `json:"generated_at"`
+	// Source is the name of the data source.
+	Source string `json:"source"`
+	// DatasetHash identifies the fetched data, and only changes when the
+	// exposure sites do.
+	DatasetHash string `json:"dataset_hash"`
+	// Filters are the filters which were set, by name.
+	Filters map[string]string `json:"filters"`
+	// Total is the number of exposure sites fetched.
+	Total int `json:"total"`
+	// Matched is the number of exposure sites matching the filters.
+	Matched int `json:"matched"`
+	// Matches are the exposure sites matching the filters, in the same
+	// representation as JSON exports.
+	Matches []exportRecord `json:"matches"`
+	// Guidance is the official advice for each lowercase contact level of
+	// the matching exposure sites.
+	Guidance map[string]Guidance `json:"guidance"`
+	// Alerts are the notifications which were sent.
+	Alerts []ReportAlert `json:"alerts"`
+	// Quality is the completeness of the fetched data.
+	Quality ReportQuality `json:"quality"`
+	// Errors are the failures of the sources which couldn't be fetched
+	// when several were given and the others were.
+	Errors []string `json:"errors"`
+}
+
+// ReportAlert is a notification which was sent during a run.
+type ReportAlert struct {
+	// Notifier is the name of the notification service.
+	Notifier string `json:"notifier"`
+	// Entries is the number of exposure sites in the notification.
+	Entries int `json:"entries"`
+	// Error is the reason the notification failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// ReportQuality counts the fetched exposure sites with missing fields,
+// which indicate the data wasn't parsed completely.
+type ReportQuality struct {
+	// MissingLocation is the number of sites without a location.
+	MissingLocation int `json:"missing_location"`
+	// MissingDate is the number of sites without a date.
+	MissingDate int `json:"missing_date"`
+	// MissingTimes is the number of sites without a time window.
+	MissingTimes int `json:"missing_times"`
+	// MissingContact is the number of sites without a contact level.
+	MissingContact int `json:"missing_contact"`
+	// ImplausibleHours is the number of sites with a time window outside
+	// the usual opening hours of the venue.
+	ImplausibleHours int `json:"implausible_hours"`
+}
+
+// NewReport will create the Report of a run, from the source name, all of
+// the fetched entries, the filter and the matching entries.
+func NewReport(source string, raw Entries, filter *Filter, matched Entries) *Report {
+	r := &Report{
+		SchemaVersion: ReportSchemaVersion,
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		Source:        source,
+		DatasetHash:   DatasetHash(raw),
+		Filters:       filterFields(filter),
+		Total:         raw.Len(),
+		Matched:       matched.Len(),
+		Matches:       exportRecords(matched.Items),
+		Guidance:      entryGuidance(matched),
+		Alerts:        []ReportAlert{},
+		Errors:        []string{},
+	}
+
+	unknown := func(t *time.Time) bool { return t == nil || t.IsZero() }
+	for i := range raw.Items {
+		e := &raw.Items[i]
+		if e.ExposureLocation == "" {
+			r.Quality.MissingLocation++
+		}
+		if unknown(e.Date) {
+			r.Quality.MissingDate++
+		}
+		if unknown(e.ArrivalTime) && unknown(e.DepartureTime) {
+			r.Quality.MissingTimes++
+		}
+		if e.Contact == "" {
+			r.Quality.MissingContact++
+		}
+		if !e.PlausibleHours() {
+			r.Quality.ImplausibleHours++
+		}
+	}
+
+	return r
+}
+
+// Write will write the Report as indented JSON to the path.
+func (r *Report) Write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, append(data, '\n'), 0644)
+}
+
+// DatasetHash will return a sha256 hex digest of the entries, which is the
+// same for the same exposure sites in any order.
+func DatasetHash(entries Entries) string {
+	hashes := make([]string, 0, entries.Len())
+	for i := range entries.Items {
+		hashes = append(hashes, entries.Items[i].Hash()+" "+normalizeField(string(entries.Items[i].Status))+" "+normalizeField(string(entries.Items[i].Contact)))
+	}
+	sort.Strings(hashes)
+	sum := sha256.Sum256([]byte(strings.Join(hashes, "\n")))
+	return fmt.Sprintf("%x", sum)
+}
+
+// filterFields will return the fields of the Filter which are set, named
+// after their flags.
+func filterFields(f *Filter) map[string]string {
+	fields := map[string]string{}
+	if f == nil {
+		return fields
+	}
+	set := func(name, value string) {
+		if value != "" {
+			fields[name] = value
+		}
+	}
+	set("status", f.Status)
+	set("location", f.ExposureLocation)
+	set("street", f.Street)
+	set("suburb", f.Suburb)
+	set("state", f.State)
+	if f.Date != nil && !f.Date.IsZero() {
+		set("date", f.Date.Format(dateFormat))
+	}
+	if f.Since != nil {
+		set("since", f.Since.Format(dateFormat))
+	}
+	if f.Until != nil {
+		set("until", f.Until.Format(dateFormat))
+	}
+	set("start-time", f.ArrivalTime)
+	set("end-time", f.DepartureTime)
+	set("contact", f.Contact)
+	set("trust", f.Trust)
+	set("exclude-status", strings.Join(f.ExcludeStatus, "|"))
+	set("exclude-location", strings.Join(f.ExcludeLocation, "|"))
+	set("exclude-suburb", strings.Join(f.ExcludeSuburb, "|"))
+	set("exclude-contact", strings.Join(f.ExcludeContact, "|"))
+	if f.Expression != nil {
+		set("filter", f.Expression.String())
+	}
+	if f.Regex {
+		set("regex", "true")
+	}
+	if f.Fuzzy {
+		set("fuzzy", "true")
+		if f.FuzzyThreshold != 0 {
+			set("fuzzy-threshold", fmt.Sprint(f.FuzzyThreshold))
+		}
+	}
+	set("query", strings.Join(f.Queries, "|"))
+	set("query-not", strings.Join(f.NotQueries, "|"))
+	if f.MinRisk > 0 {
+		set("min-risk", fmt.Sprint(f.MinRisk))
+	}
+	if f.Near != nil {
+		set("near", fmt.Sprintf("%v,%v", f.Near.Lat, f.Near.Lon))
+		set("radius", fmt.Sprintf("%vkm", f.Radius))
+	}
+	return fields
+}
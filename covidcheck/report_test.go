@@ -0,0 +1,65 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReport will write the report of a run and check it follows the
+// documented schema.
+func TestReport(t *testing.T) {
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV) {
+		entries.Add(fieldTranslate(record))
+	}
+	entries.Add(Entry{ExposureLocation: "Somewhere", Suburb: "Phillip"})
+
+	covid := &Client{RawResults: entries}
+	filter := &Filter{Contact: "casual"}
+	covid.Query(filter, QueryParams{})
+
+	t.Run("Hashing datasets", func(t *testing.T) {
+		reversed := Entries{Items: []Entry{entries.Items[2], entries.Items[1], entries.Items[0]}}
+		if DatasetHash(entries) != DatasetHash(reversed) {
+			t.Error("expected the hash to ignore the order of entries")
+		}
+		updated := Entries{Items: append([]Entry{}, entries.Items...)}
+		updated.Items[0].Contact = "Close"
+		if DatasetHash(entries) == DatasetHash(updated) {
+			t.Error("expected the hash to change with the contact level")
+		}
+	})
+
+	t.Run("Writing reports", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "covid-check-report")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "report.json")
+
+		if err := NewReport("act", entries, filter, covid.FilteredResults).Write(path); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadFile(path)
+		report := map[string]interface{}{}
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"schema_version", "generated_at", "source", "dataset_hash", "filters", "total", "matched", "matches", "alerts", "quality"} {
+			if _, ok := report[key]; !ok {
+				t.Errorf("missing %s in report", key)
+			}
+		}
+		if report["total"] != 3.0 || report["matched"] != 1.0 || report["filters"].(map[string]interface{})["contact"] != "casual" {
+			t.Errorf("unexpected report %s", data)
+		}
+		quality := report["quality"].(map[string]interface{})
+		if quality["missing_date"] != 1.0 || quality["missing_contact"] != 1.0 {
+			t.Errorf("unexpected quality %v", quality)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package covidcheck
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy is the configuration for retrying failed downloads, waiting
+// with jittered exponential backoff between each attempt.
+type RetryPolicy struct {
+	// Retries is the number of times a failed download is retried.
+	Retries int
+	// Wait is the base time to wait before the first retry, which doubles
+	// with each attempt.
+	Wait time.Duration
+	// MaxWait is the longest time to wait before a retry, including the
+	// times requested by Retry-After headers.
+	MaxWait time.Duration
+	// Sleep waits for the duration between attempts.
+	Sleep func(time.Duration)
+}
+
+// DefaultRetryPolicy is the RetryPolicy used to download the data of every
+// source, which can be replaced to change how failures are retried. It
+// doesn't retry by default.
+var DefaultRetryPolicy = &RetryPolicy{
+	Retries: 0,
+	Wait:    time.Second,
+	MaxWait: time.Minute,
+}
+
+var (
+	// jitterMu guards jitter, which isn't safe for concurrent use.
+	jitterMu sync.Mutex
+	// jitter is the random source used to spread out the retries.
+	jitter = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// retryable will check whether the response status is worth retrying,
+// which is when the server is overloaded or temporarily unavailable.
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Get will request the URL, retrying network errors and retryable statuses.
+// The last response or error is returned when every attempt fails.
+func (p *RetryPolicy) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.Do(req)
+}
+
+// Do will send the request, which must not have a body, retrying network
+// errors and retryable statuses.
+func (p *RetryPolicy) Do(req *http.Request) (*http.Response, error) {
+	sleep := time.Sleep
+	if p.Sleep != nil {
+		sleep = p.Sleep
+	}
+
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err := DefaultLimits.do(fetchClient, req)
+		if err != nil {
+			DefaultLogger.Debug("request failed", "url", req.URL.String(), "attempt", attempt+1, "duration", time.Since(start), "error", err)
+		} else {
+			DefaultLogger.Debug("requested", "url", req.URL.String(), "attempt", attempt+1, "status", resp.StatusCode, "duration", time.Since(start))
+		}
+		if attempt >= p.Retries || (err == nil && !retryable(resp.StatusCode)) {
+			return resp, err
+		}
+
+		wait := p.backoff(attempt)
+		if err == nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = after
+			}
+		}
+		if p.MaxWait > 0 && wait > p.MaxWait {
+			wait = p.MaxWait
+		}
+		// There's no point waiting for a retry which would start after the
+		// deadline of the run.
+		if DefaultLimits.expired(wait) {
+			return resp, err
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+		DefaultLogger.Info("retrying download", "url", req.URL.String(), "attempt", attempt+1, "wait", wait)
+		sleep(wait)
+	}
+}
+
+// backoff will return the time to wait before the retry after the attempt,
+// which is a random duration up to the doubled Wait so concurrent clients
+// don't retry in step.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	if p.Wait <= 0 {
+		return 0
+	}
+	limit := p.Wait << uint(attempt)
+	if limit <= 0 || (p.MaxWait > 0 && limit > p.MaxWait) {
+		limit = p.MaxWait
+	}
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return limit/2 + time.Duration(jitter.Int63n(int64(limit/2)+1))
+}
+
+// retryAfter will parse a Retry-After header, which is either a number of
+// seconds or a HTTP date.
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
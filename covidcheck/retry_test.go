@@ -0,0 +1,85 @@
+package covidcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRetryPolicy will serve intermittent failures and check downloads are
+// retried with backoff, honoring Retry-After headers.
+func TestRetryPolicy(t *testing.T) {
+	failures := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	waits := []time.Duration{}
+	p := &RetryPolicy{Retries: 3, Wait: time.Second, MaxWait: time.Minute, Sleep: func(d time.Duration) { waits = append(waits, d) }}
+
+	t.Run("Retrying failures", func(t *testing.T) {
+		failures = 2
+		resp, err := p.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 200 || len(waits) != 2 || waits[0] != 7*time.Second {
+			t.Errorf("unexpected status %d after waiting %v", resp.StatusCode, waits)
+		}
+	})
+
+	t.Run("Giving up", func(t *testing.T) {
+		failures, waits = 10, nil
+		resp, err := p.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable || len(waits) != 3 {
+			t.Errorf("unexpected status %d after waiting %v", resp.StatusCode, waits)
+		}
+	})
+
+	t.Run("Not retrying client errors", func(t *testing.T) {
+		waits = nil
+		missing := httptest.NewServer(http.NotFoundHandler())
+		defer missing.Close()
+		resp, _ := p.Get(missing.URL)
+		resp.Body.Close()
+		if len(waits) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("Backing off exponentially", func(t *testing.T) {
+		for attempt, limit := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+			if d := p.backoff(attempt); d < limit/2 || d > limit {
+				t.Errorf("expected attempt %d to wait up to %v, got %v", attempt, limit, d)
+			}
+		}
+		if d := p.backoff(20); d > p.MaxWait {
+			t.Errorf("expected the wait to be capped, got %v", d)
+		}
+	})
+
+	t.Run("Parsing Retry-After", func(t *testing.T) {
+		if d, ok := retryAfter("120"); !ok || d != 2*time.Minute {
+			t.Fail()
+		}
+		if _, ok := retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); !ok {
+			t.Fail()
+		}
+		if _, ok := retryAfter("soon"); ok {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"time"
+)
+
+// RiskModel is the configuration used to calculate a single risk score for
+// an Entry, combining its contact level, exposure duration, venue category
+// and recency into a number between 0 and 1.
+type RiskModel struct {
+	// ContactWeight is the weight of the contact level in the score.
+	ContactWeight float64 `json:"contact_weight"`
+	// DurationWeight is the weight of the exposure duration in the score.
+	DurationWeight float64 `json:"duration_weight"`
+	// CategoryWeight is the weight of the venue category in the score.
+	CategoryWeight float64 `json:"category_weight"`
+	// RecencyWeight is the weight of the exposure recency in the score.
+	RecencyWeight float64 `json:"recency_weight"`
+	// Contacts are the scores for each lowercase contact level. Unknown
+	// contact levels score the value of the "" key.
+	Contacts map[string]float64 `json:"contacts"`
+	// Categories are the scores for each venue category. Unknown venues
+	// are in the "other" category.
+	Categories map[string]float64 `json:"categories"`
+	// FullDuration is the exposure duration which scores the maximum.
+	FullDuration time.Duration `json:"-"`
+	// RecencyWindow is the age at which an exposure scores the minimum.
+	RecencyWindow time.Duration `json:"-"`
+	// Now returns the current time, used to calculate recency.
+	Now func() time.Time `json:"-"`
+}
+
+// DefaultRiskModel is the RiskModel used for the risk sort key and filter,
+// which can be replaced to change how all risk scores are calculated.
+var DefaultRiskModel = &RiskModel{
+	ContactWeight:  0.4,
+	DurationWeight: 0.2,
+	CategoryWeight: 0.15,
+	RecencyWeight:  0.25,
+	Contacts: map[string]float64{
+		"close":   1,
+		"casual":  0.6,
+		"monitor": 0.2,
+		"":        0.3,
+	},
+	Categories: map[string]float64{
+		"nightlife":   1,
+		"gym":         0.9,
+		"education":   0.8,
+		"dining":      0.8,
+		"transport":   0.6,
+		"healthcare":  0.6,
+		"supermarket": 0.4,
+		"pharmacy":    0.4,
+		"other":       0.5,
+	},
+	FullDuration:  2 * time.Hour,
+	RecencyWindow: 14 * 24 * time.Hour,
+}
+
+// venueCategories are the keywords used to classify a venue by its name,
+// checked in order so the more specific categories win.
+var venueCategories = []struct {
+	Category string
+	Keywords []string
+}{
+	{"pharmacy", []string{"pharmacy", "chemist"}},
+	{"healthcare", []string{"hospital", "medical", "clinic", "dental", "physio"}},
+	{"supermarket", []string{"woolworths", "coles", "aldi", "iga", "supermarket", "grocer"}},
+	{"transport", []string{"bus ", "route", "light rail", "flight", "qantas", "virgin", "airport"}},
+	{"education", []string{"school", "college", "childcare", "early learning", "university"}},
+	{"gym", []string{"gym", "fitness", "crossfit", "yoga", "pilates"}},
+	{"nightlife", []string{"bar", "pub", "club", "tavern", "hotel", "brewery", " inn", "nightclub"}},
+	{"dining", []string{"restaurant", "cafe", "café", "coffee", "kitchen", "pizza", "sushi", "takeaway", "bakery", "eatery"}},
+}
+
+// VenueCategory will classify the Entry's venue by its Category or enriched
+// Venue, or else by keywords in its name, returning "other" when no category
+// matches.
+func (e *Entry) VenueCategory() string {
+	if e.Category != "" {
+		return e.Category
+	}
+	if e.Venue != nil && e.Venue.Category != "" {
+		return e.Venue.Category
+	}
+	name := " " + strings.ToLower(e.ExposureLocation) + " "
+	for _, c := range venueCategories {
+		for _, keyword := range c.Keywords {
+			if strings.Contains(name, keyword) {
+				return c.Category
+			}
+		}
+	}
+	return "other"
+}
+
+// Risk will return the risk score of the Entry using the DefaultRiskModel.
+func (e *Entry) Risk() float64 {
+	return DefaultRiskModel.Score(e)
+}
+
+// Score will return the risk score of the Entry between 0 and 1.
+func (m *RiskModel) Score(e *Entry) float64 {
+	total := m.ContactWeight + m.DurationWeight + m.CategoryWeight + m.RecencyWeight
+	if total == 0 {
+		return 0
+	}
+
+	contact, ok := m.Contacts[strings.ToLower(string(e.Contact))]
+	if !ok {
+		contact = m.Contacts[""]
+	}
+	category, ok := m.Categories[e.VenueCategory()]
+	if !ok {
+		category = m.Categories["other"]
+	}
+
+	score := m.ContactWeight*contact +
+		m.DurationWeight*m.durationScore(e) +
+		m.CategoryWeight*category +
+		m.RecencyWeight*m.recencyScore(e)
+
+	return math.Round(score/total*100) / 100
+}
+
+// durationScore will score the length of the exposure window, where unknown
+// windows score 0.5 and windows past midnight wrap around to the next day.
+func (m *RiskModel) durationScore(e *Entry) float64 {
+	if e.ArrivalTime == nil || e.DepartureTime == nil || (e.ArrivalTime.IsZero() && e.DepartureTime.IsZero()) || m.FullDuration <= 0 {
+		return 0.5
+	}
+	d := e.DepartureTime.Sub(*e.ArrivalTime)
+	if d < 0 {
+		d += 24 * time.Hour
+	}
+	return math.Min(float64(d)/float64(m.FullDuration), 1)
+}
+
+// recencyScore will score how recent the exposure was, decreasing from 1 on
+// the current day to 0 at the end of the RecencyWindow.
+func (m *RiskModel) recencyScore(e *Entry) float64 {
+	if e.Date == nil || e.Date.IsZero() || m.RecencyWindow <= 0 {
+		return 0
+	}
+	now := time.Now
+	if m.Now != nil {
+		now = m.Now
+	}
+	age := now().Sub(*e.Date)
+	if age < 0 {
+		return 1
+	}
+	return math.Max(1-float64(age)/float64(m.RecencyWindow), 0)
+}
+
+// riskModelFile is the JSON representation of a RiskModel, where durations
+// are strings such as "90m" or "336h".
+type riskModelFile struct {
+	*RiskModel
+	FullDuration  string `json:"full_duration"`
+	RecencyWindow string `json:"recency_window"`
+}
+
+// LoadRiskModel will read a JSON risk model from the path. Any settings
+// which are not in the file are taken from the DefaultRiskModel.
+func LoadRiskModel(path string) (*RiskModel, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	model := *DefaultRiskModel
+	model.Contacts = copyScores(DefaultRiskModel.Contacts)
+	model.Categories = copyScores(DefaultRiskModel.Categories)
+
+	file := riskModelFile{RiskModel: &model}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse risk model: %s", err.Error())
+	}
+	if file.FullDuration != "" {
+		if model.FullDuration, err = time.ParseDuration(file.FullDuration); err != nil {
+			return nil, fmt.Errorf("could not parse full_duration: %s", err.Error())
+		}
+	}
+	if file.RecencyWindow != "" {
+		if model.RecencyWindow, err = time.ParseDuration(file.RecencyWindow); err != nil {
+			return nil, fmt.Errorf("could not parse recency_window: %s", err.Error())
+		}
+	}
+
+	return &model, nil
+}
+
+// copyScores will return a copy of the scores map.
+func copyScores(in map[string]float64) map[string]float64 {
+	out := map[string]float64{}
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
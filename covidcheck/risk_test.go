@@ -0,0 +1,94 @@
+package covidcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRiskModel will score static entries and check the components of the
+// score are combined and configurable as expected.
+func TestRiskModel(t *testing.T) {
+	now := time.Date(2021, 10, 10, 12, 0, 0, 0, time.UTC)
+	today := time.Date(2021, 10, 10, 0, 0, 0, 0, time.UTC)
+	lastMonth := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	start, _ := time.Parse(time.Kitchen, "9:00PM")
+	end, _ := time.Parse(time.Kitchen, "11:00PM")
+	short, _ := time.Parse(time.Kitchen, "9:15PM")
+
+	model := *DefaultRiskModel
+	model.Now = func() time.Time { return now }
+
+	high := Entry{ExposureLocation: "The Old Canberra Inn", Contact: "Close", Date: &today, ArrivalTime: &start, DepartureTime: &end}
+	low := Entry{ExposureLocation: "Coles Kaleen", Contact: "Monitor", Date: &lastMonth, ArrivalTime: &start, DepartureTime: &short}
+
+	t.Run("Classifying venues", func(t *testing.T) {
+		examples := map[string]string{
+			"The Old Canberra Inn":  "nightlife",
+			"Kaleen Plaza Pharmacy": "pharmacy",
+			"Coles Kaleen":          "supermarket",
+			"Bus Route 2":           "transport",
+			"Westfield Woden":       "other",
+		}
+		for location, category := range examples {
+			e := Entry{ExposureLocation: location}
+			if e.VenueCategory() != category {
+				t.Errorf("expected %s to be %s, got %s", location, category, e.VenueCategory())
+			}
+		}
+	})
+
+	t.Run("Scoring entries", func(t *testing.T) {
+		if score := model.Score(&high); score != 0.99 {
+			t.Errorf("unexpected high score %v", score)
+		}
+		if score := model.Score(&low); score != 0.17 {
+			t.Errorf("unexpected low score %v", score)
+		}
+	})
+
+	t.Run("Scoring overnight windows", func(t *testing.T) {
+		late, _ := time.Parse(time.Kitchen, "11:00PM")
+		early, _ := time.Parse(time.Kitchen, "1:00AM")
+		overnight := Entry{ArrivalTime: &late, DepartureTime: &early}
+		if model.durationScore(&overnight) != 1 {
+			t.Fail()
+		}
+	})
+
+	t.Run("Loading a risk model", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "covid-check-risk")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "risk.json")
+		ioutil.WriteFile(path, []byte(`{"contact_weight": 1, "duration_weight": 0, "category_weight": 0, "recency_weight": 0, "contacts": {"monitor": 0.5}, "full_duration": "30m"}`), 0644)
+
+		loaded, err := LoadRiskModel(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Score(&low) != 0.5 || loaded.Score(&high) != 1 || loaded.FullDuration != 30*time.Minute {
+			t.Fail()
+		}
+		if DefaultRiskModel.Contacts["monitor"] != 0.2 {
+			t.Error("loading a risk model changed the default")
+		}
+	})
+
+	t.Run("Filtering by minimum risk", func(t *testing.T) {
+		original := DefaultRiskModel
+		DefaultRiskModel = &model
+		defer func() { DefaultRiskModel = original }()
+
+		covid := &Client{RawResults: Entries{Items: []Entry{high, low}}}
+		covid.Query(&Filter{MinRisk: 0.7}, QueryParams{})
+		if len(covid.FilteredResults.Items) != 1 || covid.FilteredResults.Items[0].ExposureLocation != high.ExposureLocation {
+			t.Fail()
+		}
+	})
+}
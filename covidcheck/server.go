@@ -0,0 +1,156 @@
+package covidcheck
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is an http.Handler serving the exposure sites matching its Filter
+// from the latest data it was updated with. It serves them on /exposures as
+// JSON, or as canonical CSV with ?format=csv, reports whether the latest
+// update succeeded on /healthz, and serves Prometheus metrics on /metrics.
+type Server struct {
+	// Filter is applied to the entries the Server is updated with.
+	Filter Filter
+
+	mu      sync.RWMutex
+	results *Client
+	updated time.Time
+	err     error
+	errors  int
+}
+
+// Update will replace the entries served with those of entries matching the
+// Filter, and clear any error from a failed update.
+func (s *Server) Update(entries Entries) {
+	covid := &Client{RawResults: entries, FilteredResults: entries}
+	covid.Query(&s.Filter, QueryParams{})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = covid
+	s.updated = time.Now()
+	s.err = nil
+}
+
+// Fail will record that fetching new entries failed, which is reported by
+// /healthz while the previous entries are still served.
+func (s *Server) Fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+	s.errors++
+}
+
+// ServeHTTP will serve the exposure sites and health of the Server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch r.URL.Path {
+	case "/healthz":
+		switch {
+		case s.results == nil && s.err == nil:
+			http.Error(w, "waiting for the first update", http.StatusServiceUnavailable)
+		case s.err != nil:
+			http.Error(w, s.err.Error(), http.StatusServiceUnavailable)
+		default:
+			fmt.Fprintf(w, "ok, %d exposure sites updated %s\n", s.results.FilteredResults.Len(), s.updated.UTC().Format(time.RFC3339))
+		}
+	case "/metrics":
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.writeMetrics(w)
+	case "/", "/exposures":
+		if s.results == nil {
+			http.Error(w, "waiting for the first update", http.StatusServiceUnavailable)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		switch format {
+		case "":
+			format = "json"
+			fallthrough
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+		case "csv":
+			w.Header().Set("Content-Type", "text/csv")
+		default:
+			http.Error(w, fmt.Sprintf("unknown format '%s', expected one of [csv|json]", format), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Last-Modified", s.updated.UTC().Format(http.TimeFormat))
+		s.results.Export(w, format, true)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// writeMetrics will write the metrics of the Server in the Prometheus text
+// format. The counts are of the exposure sites matching the Filter, and are
+// left out until the first update.
+func (s *Server) writeMetrics(w io.Writer) {
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	if s.results != nil {
+		items := s.results.FilteredResults.Items
+		metric("covid_check_exposure_sites", "gauge", "Number of exposure sites matching the filter.")
+		fmt.Fprintf(w, "covid_check_exposure_sites %d\n", len(items))
+
+		groups := []struct {
+			name, help string
+			labels     func(e *Entry) string
+		}{
+			{"covid_check_exposure_sites_by_suburb", "Number of exposure sites matching the filter by suburb and contact level.", func(e *Entry) string {
+				return fmt.Sprintf("suburb=%s,contact=%s", metricLabel(e.Suburb, "unknown"), metricLabel(string(e.Contact), "unknown"))
+			}},
+			{"covid_check_exposure_sites_by_contact", "Number of exposure sites matching the filter by contact level.", func(e *Entry) string {
+				return "contact=" + metricLabel(string(e.Contact), "unknown")
+			}},
+			{"covid_check_exposure_sites_by_status", "Number of exposure sites matching the filter by status.", func(e *Entry) string {
+				return "status=" + metricLabel(string(e.Status), "none")
+			}},
+		}
+		for _, g := range groups {
+			counts := map[string]int{}
+			for i := range items {
+				counts[g.labels(&items[i])]++
+			}
+			labels := make([]string, 0, len(counts))
+			for l := range counts {
+				labels = append(labels, l)
+			}
+			sort.Strings(labels)
+			metric(g.name, "gauge", g.help)
+			for _, l := range labels {
+				fmt.Fprintf(w, "%s{%s} %d\n", g.name, l, counts[l])
+			}
+		}
+
+		metric("covid_check_last_success_timestamp_seconds", "gauge", "Unix time of the last successful fetch.")
+		fmt.Fprintf(w, "covid_check_last_success_timestamp_seconds %d\n", s.updated.Unix())
+	}
+
+	up := 0
+	if s.results != nil && s.err == nil {
+		up = 1
+	}
+	metric("covid_check_last_fetch_success", "gauge", "Whether the last fetch succeeded.")
+	fmt.Fprintf(w, "covid_check_last_fetch_success %d\n", up)
+	metric("covid_check_fetch_errors_total", "counter", "Number of failed fetches.")
+	fmt.Fprintf(w, "covid_check_fetch_errors_total %d\n", s.errors)
+}
+
+// metricLabel will quote a label value of a metric, using the fallback when
+// the value is empty.
+func metricLabel(value, fallback string) string {
+	if value = strings.TrimSpace(value); value == "" {
+		value = fallback
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
@@ -0,0 +1,108 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestServer will update a Server with static entries and check the
+// exposure sites, health and metrics it serves.
+func TestServer(t *testing.T) {
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV) {
+		entries.Add(fieldTranslate(record))
+	}
+	server := &Server{Filter: Filter{Contact: "casual"}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	get := func(path string) (int, string) {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+
+	t.Run("Waiting for the first update", func(t *testing.T) {
+		if code, _ := get("/healthz"); code != http.StatusServiceUnavailable {
+			t.Errorf("expected the server to be unhealthy, got %d", code)
+		}
+		if code, _ := get("/exposures"); code != http.StatusServiceUnavailable {
+			t.Errorf("expected no exposure sites, got %d", code)
+		}
+		if _, body := get("/metrics"); strings.Contains(body, "covid_check_exposure_sites") || !strings.Contains(body, "covid_check_last_fetch_success 0\n") {
+			t.Errorf("expected only the fetch metrics, got %s", body)
+		}
+	})
+
+	server.Update(entries)
+
+	t.Run("Serving exposure sites", func(t *testing.T) {
+		code, body := get("/exposures")
+		records := []exportRecord{}
+		if err := json.Unmarshal([]byte(body), &records); err != nil || code != http.StatusOK {
+			t.Fatalf("unexpected response %d %s", code, body)
+		}
+		if len(records) != 1 || records[0].Contact != "Casual" {
+			t.Errorf("expected the filtered exposure sites, got %v", records)
+		}
+		if _, body := get("/exposures?format=csv"); !strings.HasPrefix(body, canonicalCSVHeader) {
+			t.Errorf("unexpected csv %s", body)
+		}
+		if code, _ := get("/exposures?format=xml"); code != http.StatusBadRequest {
+			t.Errorf("expected an unknown format to be rejected, got %d", code)
+		}
+		if code, _ := get("/missing"); code != http.StatusNotFound {
+			t.Errorf("expected not found, got %d", code)
+		}
+	})
+
+	t.Run("Serving metrics", func(t *testing.T) {
+		code, body := get("/metrics")
+		if code != http.StatusOK {
+			t.Fatalf("unexpected response %d %s", code, body)
+		}
+		for _, line := range []string{
+			"# TYPE covid_check_exposure_sites gauge\ncovid_check_exposure_sites 1\n",
+			`covid_check_exposure_sites_by_suburb{suburb="Belconnen",contact="Casual"} 1` + "\n",
+			`covid_check_exposure_sites_by_contact{contact="Casual"} 1` + "\n",
+			`covid_check_exposure_sites_by_status{status="New"} 1` + "\n",
+			"covid_check_last_fetch_success 1\n",
+			"# TYPE covid_check_fetch_errors_total counter\ncovid_check_fetch_errors_total 0\n",
+		} {
+			if !strings.Contains(body, line) {
+				t.Errorf("expected the metrics to contain %q, got %s", line, body)
+			}
+		}
+		if !strings.Contains(body, "covid_check_last_success_timestamp_seconds ") || strings.Contains(body, "Kaleen") {
+			t.Errorf("expected only the filtered exposure sites, got %s", body)
+		}
+		if label := metricLabel("a \"b\"\\", "unknown"); label != `"a \"b\"\\"` || metricLabel(" ", "none") != `"none"` {
+			t.Errorf("unexpected labels %s", label)
+		}
+	})
+
+	t.Run("Reporting health", func(t *testing.T) {
+		if code, body := get("/healthz"); code != http.StatusOK || !strings.HasPrefix(body, "ok, 1 exposure sites") {
+			t.Errorf("unexpected health %d %s", code, body)
+		}
+		server.Fail(errors.New("offline"))
+		if code, body := get("/healthz"); code != http.StatusServiceUnavailable || !strings.Contains(body, "offline") {
+			t.Errorf("expected the failure to be reported, got %d %s", code, body)
+		}
+		if code, _ := get("/exposures"); code != http.StatusOK {
+			t.Error("expected the previous exposure sites to still be served")
+		}
+		if _, body := get("/metrics"); !strings.Contains(body, "covid_check_fetch_errors_total 1\n") || !strings.Contains(body, "covid_check_last_fetch_success 0\n") {
+			t.Errorf("expected the failure to be counted, got %s", body)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Service is a long-running watch of covid-check, which is installed as a
+// systemd user unit on Linux, a launchd agent on macOS or a scheduled task
+// on Windows.
+type Service struct {
+	// Name is the name of the systemd unit and scheduled task.
+	Name string
+	// Label is the reverse domain name of the launchd agent.
+	Label string
+	// Executable is the absolute path of covid-check.
+	Executable string
+	// Args are the arguments covid-check is run with.
+	Args []string
+}
+
+// quoteArg will quote an argument containing spaces or quotes, escaping
+// the quotes and backslashes in it.
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(arg) + "\""
+}
+
+// CommandLine will return the executable and arguments as one command line,
+// with each quoted as needed.
+func (s *Service) CommandLine() string {
+	parts := []string{quoteArg(s.Executable)}
+	for _, arg := range s.Args {
+		parts = append(parts, quoteArg(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// SystemdUnit will return a systemd unit running the Service, which is
+// restarted when it exits.
+func (s *Service) SystemdUnit() string {
+	var b strings.Builder
+	fmt.Fprintln(&b, "[Unit]")
+	fmt.Fprintln(&b, "Description=COVID-19 exposure site watch")
+	fmt.Fprintln(&b, "After=network-online.target")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Service]")
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.ReplaceAll(s.CommandLine(), "%", "%%"))
+	fmt.Fprintln(&b, "Restart=on-failure")
+	fmt.Fprintln(&b, "RestartSec=30")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Install]")
+	fmt.Fprintln(&b, "WantedBy=default.target")
+	return b.String()
+}
+
+// LaunchdPlist will return a launchd property list running the Service,
+// which is started on login and kept alive.
+func (s *Service) LaunchdPlist() string {
+	escape := func(value string) string {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(value))
+		return buf.String()
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(&b, `<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">`)
+	fmt.Fprintln(&b, `<plist version="1.0">`)
+	fmt.Fprintln(&b, "<dict>")
+	fmt.Fprintf(&b, "  <key>Label</key>\n  <string>%s</string>\n", escape(s.Label))
+	fmt.Fprintln(&b, "  <key>ProgramArguments</key>")
+	fmt.Fprintln(&b, "  <array>")
+	for _, arg := range append([]string{s.Executable}, s.Args...) {
+		fmt.Fprintf(&b, "    <string>%s</string>\n", escape(arg))
+	}
+	fmt.Fprintln(&b, "  </array>")
+	fmt.Fprintln(&b, "  <key>RunAtLoad</key>\n  <true/>")
+	fmt.Fprintln(&b, "  <key>KeepAlive</key>\n  <true/>")
+	fmt.Fprintln(&b, "</dict>")
+	fmt.Fprintln(&b, "</plist>")
+	return b.String()
+}
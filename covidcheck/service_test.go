@@ -0,0 +1,59 @@
+package covidcheck
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// TestService will generate the service files of a static Service and check
+// the command line is kept intact.
+func TestService(t *testing.T) {
+	s := &Service{
+		Name:       "covid-check",
+		Label:      "com.github.fubarhouse.covid-check",
+		Executable: "/usr/local/bin/covid-check",
+		Args:       []string{"watch", "-location", "ALDI Belconnen", "-filter", `contact == "Close"`, "-q", "100%"},
+	}
+
+	t.Run("Quoting the command line", func(t *testing.T) {
+		want := `/usr/local/bin/covid-check watch -location "ALDI Belconnen" -filter "contact == \"Close\"" -q 100%`
+		if got := s.CommandLine(); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	})
+
+	t.Run("Generating a systemd unit", func(t *testing.T) {
+		unit := s.SystemdUnit()
+		if !strings.Contains(unit, `ExecStart=/usr/local/bin/covid-check watch -location "ALDI Belconnen"`) || !strings.Contains(unit, "-q 100%%\n") || !strings.Contains(unit, "WantedBy=default.target") {
+			t.Errorf("unexpected unit %s", unit)
+		}
+	})
+
+	t.Run("Generating a launchd plist", func(t *testing.T) {
+		plist := s.LaunchdPlist()
+		decoder := xml.NewDecoder(strings.NewReader(plist))
+		decoder.Strict = false
+		strs := []string{}
+		inString := false
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			switch tok := token.(type) {
+			case xml.StartElement:
+				inString = tok.Name.Local == "string"
+			case xml.CharData:
+				if inString {
+					strs = append(strs, string(tok))
+				}
+			case xml.EndElement:
+				inString = false
+			}
+		}
+		if len(strs) != 9 || strs[0] != s.Label || strs[1] != s.Executable || strs[6] != `contact == "Close"` {
+			t.Errorf("unexpected plist strings %q", strs)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package covidcheck
+
+import (
+	"regexp"
+	"strings"
+)
+
+// severityRule maps the contact labels of a source which match Pattern onto
+// a Contact.
+type severityRule struct {
+	// Pattern matches the lowercase label.
+	Pattern *regexp.Regexp
+	// Contact is the Contact of the labels which match.
+	Contact Contact
+}
+
+// severityMappings are the rules of each source mapping the contact labels
+// it publishes onto the Contact scale, tried in order, so entries of every
+// jurisdiction can be filtered and scored alike when sources are combined.
+var severityMappings = map[string][]severityRule{
+	"act": {
+		{regexp.MustCompile(`^close`), ContactClose},
+		{regexp.MustCompile(`^casual`), ContactCasual},
+		{regexp.MustCompile(`^monitor`), ContactMonitor},
+	},
+	"nsw": {
+		{regexp.MustCompile(`close contact|isolate for (7|14) days`), ContactClose},
+		{regexp.MustCompile(`isolate until|casual contact`), ContactCasual},
+		{regexp.MustCompile(`monitor`), ContactMonitor},
+	},
+	"vic": {
+		{regexp.MustCompile(`^tier 1`), ContactClose},
+		{regexp.MustCompile(`^tier 2`), ContactCasual},
+		{regexp.MustCompile(`^tier 3`), ContactMonitor},
+	},
+	"qld": {
+		{regexp.MustCompile(`close`), ContactClose},
+		{regexp.MustCompile(`casual`), ContactCasual},
+		{regexp.MustCompile(`low risk`), ContactMonitor},
+	},
+}
+
+// MapContact will map a contact label published by the named source, such
+// as "Tier 1" in Victoria, onto the Contact scale shared by every source.
+// Labels the source has no rule for are read as the name of a Contact, and
+// are ContactUnknown otherwise.
+func MapContact(source, label string) Contact {
+	label = strings.ToLower(strings.TrimSpace(label))
+	for _, rule := range severityMappings[strings.ToLower(source)] {
+		if rule.Pattern.MatchString(label) {
+			return rule.Contact
+		}
+	}
+	contact, _ := ParseContact(label)
+	return contact
+}
@@ -0,0 +1,46 @@
+package covidcheck
+
+import "testing"
+
+// TestMapContact will map the contact labels of every source onto the
+// Contact scale, and check the labels are kept by the parsed entries.
+func TestMapContact(t *testing.T) {
+	examples := []struct {
+		source, label string
+		contact       Contact
+	}{
+		{"act", "Close", ContactClose},
+		{"act", "casual contact", ContactCasual},
+		{"act", "Monitor", ContactMonitor},
+		{"nsw", "Close contact", ContactClose},
+		{"nsw", "Get tested and isolate for 7 days", ContactClose},
+		{"nsw", "Get tested and isolate until you get a negative result", ContactCasual},
+		{"nsw", "Monitor for symptoms", ContactMonitor},
+		{"vic", "Tier 1 - Get tested immediately and quarantine for 14 days", ContactClose},
+		{"vic", "Tier 2 - Get tested urgently and isolate until you have a negative result", ContactCasual},
+		{"vic", "TIER 3 - Monitor for symptoms", ContactMonitor},
+		{"qld", "Low risk contact", ContactMonitor},
+		{"QLD", "Casual contact", ContactCasual},
+		{"vic", "Casual", ContactCasual},
+		{"nowhere", "close", ContactClose},
+		{"vic", "Tier 4", ContactUnknown},
+		{"act", "", ContactUnknown},
+	}
+	for _, example := range examples {
+		if contact := MapContact(example.source, example.label); contact != example.contact {
+			t.Errorf("expected %s %q to be %q, got %q", example.source, example.label, example.contact, contact)
+		}
+	}
+
+	t.Run("Keeping the label of the source", func(t *testing.T) {
+		entries, err := parseVIC([]byte(vicTestCSV))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries.Items {
+			if e.ContactLabel == "" || MapContact("vic", e.ContactLabel) != e.Contact {
+				t.Errorf("expected the label of %s to map onto %s, got %q", e.ExposureLocation, e.Contact, e.ContactLabel)
+			}
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// SimulatedAlert is an alert watch mode would have sent, had it polled the
+// source when a snapshot was taken.
+type SimulatedAlert struct {
+	// Time is when the snapshot was taken.
+	Time time.Time
+	// Changes are the new and updated entries of the snapshot which match
+	// the filter.
+	Changes Changes
+}
+
+// Simulate will replay the snapshots of the source in the archive, oldest
+// first, through a Watcher with the filter, and return the alerts watch
+// mode would have sent for them. As in watch mode, the first snapshot only
+// records the current entries, and each later snapshot alerts when it has
+// new or updated entries matching the filter.
+func Simulate(archive *SnapshotArchive, source string, filter Filter) ([]SimulatedAlert, int, error) {
+	times, err := archive.Snapshots(source)
+	if err != nil {
+		return nil, 0, err
+	}
+	replay := &replaySource{Archive: archive, Source: source, Times: times}
+	w := &Watcher{Source: replay, Filter: filter}
+	alerts := []SimulatedAlert{}
+	for _, t := range times {
+		changes, err := w.Poll()
+		if err != nil {
+			return alerts, len(times), err
+		}
+		if changes.Initial || changes.Added.Len()+changes.Updated.Len() == 0 {
+			continue
+		}
+		changes.Removed = Entries{}
+		alerts = append(alerts, SimulatedAlert{Time: t, Changes: changes})
+	}
+	return alerts, len(times), nil
+}
+
+// replaySource is a DataSource which reads the next of the snapshots of
+// the source taken at Times on each Fetch.
+type replaySource struct {
+	Archive *SnapshotArchive
+	Source  string
+	Times   []time.Time
+}
+
+// Fetch will load the next snapshot.
+func (s *replaySource) Fetch() (Entries, error) {
+	t := s.Times[0]
+	s.Times = s.Times[1:]
+	entries, _, err := s.Archive.Find(s.Source, t.Format(snapshotTimeFormat), t)
+	return entries, err
+}
+
+// alertRecord is the representation of a SimulatedAlert in JSON exports.
+type alertRecord struct {
+	Time    string         `json:"time"`
+	Added   []exportRecord `json:"added"`
+	Updated []exportRecord `json:"updated"`
+}
+
+// ExportAlerts will write the alerts to w as JSON, with the added and
+// updated entries of each in the same format as Export.
+func ExportAlerts(w io.Writer, alerts []SimulatedAlert) error {
+	records := []alertRecord{}
+	for _, alert := range alerts {
+		records = append(records, alertRecord{
+			Time:    alert.Time.UTC().Format(time.RFC3339),
+			Added:   exportRecords(alert.Changes.Added.Items),
+			Updated: exportRecords(alert.Changes.Updated.Items),
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
@@ -0,0 +1,66 @@
+package covidcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestSimulate will replay snapshots through the filter and check alerts
+// are reported only for the snapshots which watch mode would have alerted.
+func TestSimulate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-simulate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive := &SnapshotArchive{Dir: dir}
+
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV) {
+		entries.Add(fieldTranslate(record))
+	}
+	older := Entries{Items: entries.Items[1:]}
+	times := []time.Time{
+		time.Date(2021, 10, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2021, 10, 5, 9, 0, 0, 0, time.UTC),
+		time.Date(2021, 10, 6, 9, 0, 0, 0, time.UTC),
+	}
+	for i, snapshot := range []Entries{older, entries, entries} {
+		if _, err := archive.Save("act", snapshot, times[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Alerting on new entries", func(t *testing.T) {
+		alerts, replayed, err := Simulate(archive, "act", Filter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if replayed != 3 || len(alerts) != 1 || !alerts[0].Time.Equal(times[1]) || alerts[0].Changes.Added.Len() != 1 {
+			t.Fatalf("expected an alert for the second snapshot, got %+v from %d", alerts, replayed)
+		}
+		if e := alerts[0].Changes.Added.Items[0]; e.ExposureLocation != "ALDI Belconnen" {
+			t.Errorf("expected the new entry, got %s", e.ExposureLocation)
+		}
+
+		var buf bytes.Buffer
+		if err := ExportAlerts(&buf, alerts); err != nil {
+			t.Fatal(err)
+		}
+		records := []alertRecord{}
+		if err := json.Unmarshal(buf.Bytes(), &records); err != nil || len(records) != 1 || records[0].Time != "2021-10-05T09:00:00Z" {
+			t.Errorf("unexpected export %s", buf.String())
+		}
+	})
+
+	t.Run("Filtering alerts", func(t *testing.T) {
+		alerts, _, err := Simulate(archive, "act", Filter{Suburb: "Kaleen"})
+		if err != nil || len(alerts) != 0 {
+			t.Errorf("expected no alerts, got %+v and %v", alerts, err)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotTimeFormat is the format of the timestamp in snapshot file names.
+const snapshotTimeFormat = "20060102T150405Z"
+
+// SnapshotArchive is a local directory of timestamped snapshots of fetched
+// datasets, which can be queried instead of the live data.
+type SnapshotArchive struct {
+	// Dir is the directory the snapshots are stored in.
+	Dir string
+}
+
+// NewSnapshotArchive will return a SnapshotArchive stored under the user
+// data directory, which is $XDG_DATA_HOME/covid-check/snapshots on Linux.
+func NewSnapshotArchive() (*SnapshotArchive, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return &SnapshotArchive{Dir: filepath.Join(dir, "covid-check", "snapshots")}, nil
+}
+
+// Save will write the entries of the source as a snapshot taken at t, and
+// return the path of the snapshot.
+func (a *SnapshotArchive) Save(source string, entries Entries, t time.Time) (string, error) {
+	if err := os.MkdirAll(a.Dir, 0755); err != nil {
+		return "", err
+	}
+
+	items := make([]Entry, len(entries.Items))
+	copy(items, entries.Items)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Hash() < items[j].Hash()
+	})
+	data, err := json.MarshalIndent(exportRecords(items), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(a.Dir, fmt.Sprintf("%s-%s.json", source, t.UTC().Format(snapshotTimeFormat)))
+	return path, ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Snapshots will return the times of the snapshots of the source, oldest
+// first.
+func (a *SnapshotArchive) Snapshots(source string) ([]time.Time, error) {
+	paths, err := filepath.Glob(filepath.Join(a.Dir, source+"-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	times := []time.Time{}
+	for _, path := range paths {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), source+"-"), ".json")
+		if t, err := time.Parse(snapshotTimeFormat, stamp); err == nil {
+			times = append(times, t)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times, nil
+}
+
+// Load will read the latest snapshot of the source taken before the end of
+// the asOf day, and return its entries and when it was taken.
+func (a *SnapshotArchive) Load(source string, asOf time.Time) (Entries, time.Time, error) {
+	times, err := a.Snapshots(source)
+	if err != nil {
+		return Entries{}, time.Time{}, err
+	}
+	end := day(asOf).AddDate(0, 0, 1)
+	for i := len(times) - 1; i >= 0; i-- {
+		if !times[i].Before(end) {
+			continue
+		}
+		f, err := os.Open(filepath.Join(a.Dir, fmt.Sprintf("%s-%s.json", source, times[i].Format(snapshotTimeFormat))))
+		if err != nil {
+			return Entries{}, time.Time{}, err
+		}
+		defer f.Close()
+		entries, err := ReadJSON(f)
+		return entries, times[i], err
+	}
+	return Entries{}, time.Time{}, fmt.Errorf("no %s snapshot found as of %s in %s", source, asOf.Format(jsonDateFormat), a.Dir)
+}
+
+// Find will read the snapshot of the source given by ref, which is either
+// "latest", the timestamp of a snapshot such as "20211005T090000Z", or a date
+// as accepted by ParseDate to load the latest snapshot taken by that day.
+func (a *SnapshotArchive) Find(source, ref string, now time.Time) (Entries, time.Time, error) {
+	if ref == "latest" {
+		return a.Load(source, now)
+	}
+	if t, err := time.Parse(snapshotTimeFormat, ref); err == nil {
+		f, err := os.Open(filepath.Join(a.Dir, fmt.Sprintf("%s-%s.json", source, ref)))
+		if err != nil {
+			return Entries{}, time.Time{}, fmt.Errorf("no %s snapshot found at %s in %s", source, ref, a.Dir)
+		}
+		defer f.Close()
+		entries, err := ReadJSON(f)
+		return entries, t, err
+	}
+	date, err := ParseDate(ref, now)
+	if err != nil {
+		return Entries{}, time.Time{}, fmt.Errorf("expected a file, snapshot timestamp or date: %s", err.Error())
+	}
+	return a.Load(source, date)
+}
+
+// ReadJSON will read entries from the JSON export format, which must have a
+// valid status, state and contact level.
+func ReadJSON(r io.Reader) (Entries, error) {
+	records := []exportRecord{}
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return Entries{}, fmt.Errorf("could not parse json entries: %s", err.Error())
+	}
+
+	parse := func(value, layout string) *time.Time {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return &time.Time{}
+		}
+		return &t
+	}
+	entries := Entries{}
+	for _, r := range records {
+		status, err := ParseStatus(r.Status)
+		if err != nil {
+			return Entries{}, fmt.Errorf("could not parse json entries: %s", err.Error())
+		}
+		state, err := ParseState(r.State)
+		if err != nil {
+			return Entries{}, fmt.Errorf("could not parse json entries: %s", err.Error())
+		}
+		contact, err := ParseContact(r.Contact)
+		if err != nil {
+			return Entries{}, fmt.Errorf("could not parse json entries: %s", err.Error())
+		}
+		entries.Add(Entry{
+			Status:           status,
+			ExposureLocation: r.Location,
+			Street:           r.Street,
+			Suburb:           r.Suburb,
+			State:            state,
+			Date:             parse(r.Date, jsonDateFormat),
+			ArrivalTime:      parse(r.StartTime, jsonTimeFormat),
+			DepartureTime:    parse(r.EndTime, jsonTimeFormat),
+			Contact:          contact,
+			ContactLabel:     r.ContactLabel,
+			Trust:            r.Trust,
+		})
+	}
+	return entries, nil
+}
+
+// snapshotSource is a DataSource which reads a snapshot from the archive.
+type snapshotSource struct {
+	Archive *SnapshotArchive
+	Source  string
+	AsOf    time.Time
+}
+
+// NewSnapshotSource will return a DataSource for the latest snapshot of the
+// source in the archive taken as of the date.
+func NewSnapshotSource(archive *SnapshotArchive, source string, asOf time.Time) DataSource {
+	return &snapshotSource{Archive: archive, Source: source, AsOf: asOf}
+}
+
+// Fetch will load the snapshot.
+func (s *snapshotSource) Fetch() (Entries, error) {
+	entries, _, err := s.Archive.Load(s.Source, s.AsOf)
+	return entries, err
+}
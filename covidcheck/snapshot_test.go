@@ -0,0 +1,92 @@
+package covidcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestSnapshotArchive will save snapshots of static entries and check the
+// right snapshot is loaded for historical queries.
+func TestSnapshotArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive := &SnapshotArchive{Dir: dir}
+
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV) {
+		entries.Add(fieldTranslate(record))
+	}
+	entries.SetTrust(TrustOfficial)
+	older := Entries{Items: entries.Items[1:]}
+
+	first := time.Date(2021, 10, 1, 9, 0, 0, 0, time.UTC)
+	second := time.Date(2021, 10, 5, 9, 0, 0, 0, time.UTC)
+	if _, err := archive.Save("act", older, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := archive.Save("act", entries, second); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Listing snapshots", func(t *testing.T) {
+		times, err := archive.Snapshots("act")
+		if err != nil || len(times) != 2 || !times[0].Equal(first) {
+			t.Errorf("unexpected snapshots %v %v", times, err)
+		}
+	})
+
+	t.Run("Loading as of a date", func(t *testing.T) {
+		loaded, taken, err := archive.Load("act", time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !taken.Equal(first) || loaded.Len() != 1 {
+			t.Errorf("expected the first snapshot, got %v with %d entries", taken, loaded.Len())
+		}
+
+		loaded, _, _ = archive.Load("act", second)
+		if loaded.Len() != 2 {
+			t.Errorf("expected the snapshot taken on the day, got %d entries", loaded.Len())
+		}
+	})
+
+	t.Run("Round tripping entries", func(t *testing.T) {
+		loaded, _ := NewSnapshotSource(archive, "act", second).Fetch()
+		found := loaded.FindSlug(entries.Items[0].Slug())
+		if found.Len() != 1 {
+			t.Fatal("expected the entry to keep its slug")
+		}
+		e := found.Items[0]
+		if e.ExposureLocation != "ALDI Belconnen" || e.Contact != "Casual" || e.Trust != TrustOfficial || e.ArrivalTime.Format(time.Kitchen) != "7:00PM" {
+			t.Errorf("unexpected entry %+v", e)
+		}
+	})
+
+	t.Run("Finding snapshots by reference", func(t *testing.T) {
+		now := time.Date(2021, 10, 6, 12, 0, 0, 0, time.UTC)
+		for ref, want := range map[string]time.Time{"latest": second, "20211001T090000Z": first, "2021-10-04": first, "yesterday": second} {
+			if _, taken, err := archive.Find("act", ref, now); err != nil || !taken.Equal(want) {
+				t.Errorf("expected %s to find the snapshot at %v, got %v %v", ref, want, taken, err)
+			}
+		}
+		for _, ref := range []string{"20211002T090000Z", "someday"} {
+			if _, _, err := archive.Find("act", ref, now); err == nil {
+				t.Errorf("expected an error finding %s", ref)
+			}
+		}
+	})
+
+	t.Run("Reporting missing snapshots", func(t *testing.T) {
+		if _, _, err := archive.Load("act", first.AddDate(0, 0, -1)); err == nil {
+			t.Fail()
+		}
+		if _, _, err := archive.Load("nsw", second); err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package covidcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortKey is a single field to sort Entries by, and the direction to do it in.
+type SortKey struct {
+	// Name is the name of the field, matching the name of the filter flag.
+	Name string
+	// Descending will reverse the comparison for this key.
+	Descending bool
+}
+
+// sortComparators is a map of sort key names to functions which compare
+// two entries, returning a negative number when a sorts before b, a
+// positive number when b sorts before a and zero when they are equal.
+var sortComparators = map[string]func(a, b *Entry) int{
+	"status":     func(a, b *Entry) int { return compareStrings(string(a.Status), string(b.Status)) },
+	"location":   func(a, b *Entry) int { return compareStrings(a.ExposureLocation, b.ExposureLocation) },
+	"street":     func(a, b *Entry) int { return compareStrings(a.Street, b.Street) },
+	"suburb":     func(a, b *Entry) int { return compareStrings(a.Suburb, b.Suburb) },
+	"state":      func(a, b *Entry) int { return compareStrings(string(a.State), string(b.State)) },
+	"date":       func(a, b *Entry) int { return compareTimes(a.Date, b.Date) },
+	"start-time": func(a, b *Entry) int { return compareTimes(a.ArrivalTime, b.ArrivalTime) },
+	"end-time":   func(a, b *Entry) int { return compareTimes(a.DepartureTime, b.DepartureTime) },
+	"contact":    func(a, b *Entry) int { return compareStrings(string(a.Contact), string(b.Contact)) },
+	"risk":       func(a, b *Entry) int { return compareFloats(a.Risk(), b.Risk()) },
+}
+
+// ParseSortKeys will convert a comma separated list of field names into
+// a slice of SortKey. A field name prefixed with "-" is sorted in
+// descending order. An error is returned for unknown field names.
+func ParseSortKeys(in string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, name := range strings.Split(in, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		key := SortKey{}
+		if strings.HasPrefix(name, "-") {
+			key.Descending = true
+			name = strings.TrimPrefix(name, "-")
+		}
+		if _, ok := sortComparators[name]; !ok {
+			return nil, fmt.Errorf("unknown sort key '%s'", name)
+		}
+		key.Name = name
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// SortBy will sort the Entries by the given keys in order of priority.
+// The sort is stable, so entries which compare equally on every key keep
+// their original order and unchanged data will always sort identically.
+func (entries *Entries) SortBy(keys []SortKey) {
+	if len(keys) == 0 {
+		return
+	}
+	sort.SliceStable(entries.Items, func(i, j int) bool {
+		for _, key := range keys {
+			c := sortComparators[key.Name](&entries.Items[i], &entries.Items[j])
+			if key.Descending {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// compareStrings will compare two strings case-insensitively.
+func compareStrings(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// compareFloats will compare two numbers.
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareTimes will compare two times, treating nil as the zero time.
+func compareTimes(a, b *time.Time) int {
+	var ta, tb time.Time
+	if a != nil {
+		ta = *a
+	}
+	if b != nil {
+		tb = *b
+	}
+	switch {
+	case ta.Before(tb):
+		return -1
+	case ta.After(tb):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package covidcheck
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSortBy will sort a static set of entries by multiple keys and check
+// the order is as expected, including the stability of equal entries.
+func TestSortBy(t *testing.T) {
+	dayOne, _ := time.Parse("02/01/2006", "01/10/2021")
+	dayTwo, _ := time.Parse("02/01/2006", "02/10/2021")
+	morning, _ := time.Parse(time.Kitchen, "9:00AM")
+	evening, _ := time.Parse(time.Kitchen, "6:00PM")
+
+	entries := Entries{Items: []Entry{
+		{ExposureLocation: "A", Suburb: "Woden", Date: &dayTwo, ArrivalTime: &morning},
+		{ExposureLocation: "B", Suburb: "Belconnen", Date: &dayTwo, ArrivalTime: &evening},
+		{ExposureLocation: "C", Suburb: "Belconnen", Date: &dayOne, ArrivalTime: &evening},
+		{ExposureLocation: "D", Suburb: "Belconnen", Date: &dayTwo, ArrivalTime: &morning},
+		{ExposureLocation: "E", Suburb: "Belconnen", Date: &dayTwo, ArrivalTime: &morning},
+	}}
+
+	t.Run("Parsing sort keys", func(t *testing.T) {
+		keys, err := ParseSortKeys("date, Suburb,-start-time")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 3 || keys[1].Name != "suburb" || !keys[2].Descending {
+			t.Fail()
+		}
+		if _, err := ParseSortKeys("date,nonsense"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("Sorting by date, suburb and start time", func(t *testing.T) {
+		keys, _ := ParseSortKeys("date,suburb,start-time")
+		entries.SortBy(keys)
+		expected := "CDEBA"
+		for i, item := range entries.Items {
+			if item.ExposureLocation != string(expected[i]) {
+				t.Errorf("expected %s at position %d, got %s", string(expected[i]), i, item.ExposureLocation)
+			}
+		}
+	})
+
+	t.Run("Sorting in reverse", func(t *testing.T) {
+		keys, _ := ParseSortKeys("-date,suburb,-start-time")
+		entries.SortBy(keys)
+		expected := "BDEAC"
+		for i, item := range entries.Items {
+			if item.ExposureLocation != string(expected[i]) {
+				t.Errorf("expected %s at position %d, got %s", string(expected[i]), i, item.ExposureLocation)
+			}
+		}
+	})
+}
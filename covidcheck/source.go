@@ -0,0 +1,1008 @@
+package covidcheck
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ACTEndpointURL is the ACT Government web page listing the exposure sites,
+// which links to the CSV file containing the data.
+var ACTEndpointURL = "https://www.covid19.act.gov.au/act-status-and-response/act-covid-19-exposure-locations"
+
+// NSWEndpointURL is the Data.NSW JSON API resource containing the NSW Health
+// COVID-19 case locations.
+var NSWEndpointURL = "https://data.nsw.gov.au/data/dataset/0a52e6c1-bc0b-48af-8b45-d791a6d8e289/resource/f3a28eed-8c2a-437b-8ac1-2dab3cf760f9/download/venue-data.json"
+
+// VICEndpointURL is the DataVic API resource containing the Victorian
+// Department of Health exposure sites.
+var VICEndpointURL = "https://discover.data.vic.gov.au/api/3/action/datastore_search?resource_id=afb52611-6061-4a2b-9110-74c920bede77&limit=10000"
+
+// QLDEndpointURL is the Queensland Health web page listing the contact
+// tracing locations.
+var QLDEndpointURL = "https://www.qld.gov.au/health/conditions/health-alerts/coronavirus-covid-19/current-status/contact-tracing"
+
+// DataSource is a provider of exposure site data for a jurisdiction, which
+// is responsible for fetching and translating its data into Entries.
+type DataSource interface {
+	// Fetch will retrieve the data and return it as Entries.
+	Fetch() (Entries, error)
+}
+
+// SourceOptions are the settings used to construct a DataSource.
+type SourceOptions struct {
+	// Endpoint overrides the default endpoint of the DataSource.
+	Endpoint string
+	// File is an optional path to a local copy of the data, which is used
+	// instead of fetching from the endpoint when set, or "-" to read the
+	// data from Stdin.
+	File string
+	// Cache is an optional Cache which downloaded data is stored in and
+	// reused from while it is fresh.
+	Cache *Cache
+	// Parallelism is the number of sources fetched at once when several
+	// are given, where every source is fetched at once when it is zero.
+	Parallelism int
+	// Mapping is an optional ColumnMapping of the columns of the ACT CSV
+	// file.
+	Mapping *ColumnMapping
+	// CSVURL is an optional URL of the ACT CSV file, which is downloaded
+	// instead of scraping the web page of the Endpoint for it.
+	CSVURL string
+	// Format is the format of the ACT data, being csv by default, json to
+	// read JSON data with the Mapping, which is downloaded from the Endpoint
+	// when there is no CSVURL, or xlsx to read the first sheet of an Excel
+	// workbook as a CSV file. Workbooks are also read as csv.
+	Format string
+}
+
+// sources is the registry of DataSource constructors keyed by the name
+// used with the -source flag.
+var sources = map[string]func(options SourceOptions) DataSource{}
+
+// RegisterSource will add a DataSource constructor to the registry.
+func RegisterSource(name string, constructor func(options SourceOptions) DataSource) {
+	sources[name] = constructor
+}
+
+// SourceNames will return the sorted names of all registered sources.
+func SourceNames() []string {
+	var names []string
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSource will construct the registered DataSource with the given name,
+// or a DataSource merging several sources given as a comma separated list
+// of names such as "act,nsw".
+func NewSource(name string, options SourceOptions) (DataSource, error) {
+	if strings.Contains(name, ",") {
+		return newMultiSource(name, options)
+	}
+	constructor, ok := sources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown source '%s', expected one of [%s]", name, strings.Join(SourceNames(), "|"))
+	}
+	return constructor(options), nil
+}
+
+// FetchError is the failure of some or all of the sources fetched at once by
+// a DataSource merging several sources. When only some of them failed, it is
+// returned along with the entries of the others.
+type FetchError struct {
+	// Errors are the failures of each source, in the order the sources
+	// were given.
+	Errors []SourceError
+	// Sources is the number of sources which were fetched.
+	Sources int
+}
+
+// SourceError is the failure of one source.
+type SourceError struct {
+	// Source is the name of the source.
+	Source string
+	// Err is the reason it failed.
+	Err error
+}
+
+// Error will return the reason the source failed.
+func (e SourceError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Source, e.Err.Error())
+}
+
+// Error will list the reason each source failed.
+func (e *FetchError) Error() string {
+	reasons := []string{}
+	for _, err := range e.Errors {
+		reasons = append(reasons, err.Error())
+	}
+	return fmt.Sprintf("%d of %d sources failed: %s", len(e.Errors), e.Sources, strings.Join(reasons, "; "))
+}
+
+// Partial will check whether any of the sources succeeded, so their entries
+// can still be used. Sources which were cut short by their Limits count as
+// having succeeded.
+func (e *FetchError) Partial() bool {
+	failed := 0
+	for _, err := range e.Errors {
+		if !isLimit(err.Err) {
+			failed++
+		}
+	}
+	return failed < e.Sources
+}
+
+// multiSource is a DataSource which fetches several sources concurrently and
+// merges their Entries, in the order the sources were given.
+type multiSource struct {
+	// Names are the names of the Sources, used in errors and as the State
+	// of their entries without one.
+	Names []string
+	// Sources are the sources which are merged.
+	Sources []DataSource
+	// Parallelism is the number of sources fetched at once, where every
+	// source is fetched at once when it is zero.
+	Parallelism int
+}
+
+// newMultiSource will construct each of the comma separated sources. Files
+// and endpoints belong to a single source, so they can't be given.
+func newMultiSource(names string, options SourceOptions) (DataSource, error) {
+	if options.File != "" || options.Endpoint != "" {
+		return nil, fmt.Errorf("a file or endpoint can only be used with a single source, not '%s'", names)
+	}
+	s := &multiSource{Parallelism: options.Parallelism}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		src, err := NewSource(name, options)
+		if err != nil {
+			return nil, err
+		}
+		s.Names = append(s.Names, name)
+		s.Sources = append(s.Sources, src)
+	}
+	return s, nil
+}
+
+// Fetch will fetch the sources Parallelism at a time. When any of them fail,
+// a *FetchError is returned with the entries of those which succeeded.
+func (s *multiSource) Fetch() (Entries, error) {
+	results := make([]Entries, len(s.Sources))
+	errs := make([]error, len(s.Sources))
+	parallelism := s.Parallelism
+	if parallelism < 1 || parallelism > len(s.Sources) {
+		parallelism = len(s.Sources)
+	}
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := range s.Sources {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			start := time.Now()
+			results[i], errs[i] = s.Sources[i].Fetch()
+			DefaultLogger.Debug("fetched source", "source", s.Names[i], "entries", results[i].Len(), "duration", time.Since(start))
+		}(i)
+	}
+	wg.Wait()
+
+	merged := Entries{}
+	failed := &FetchError{Sources: len(s.Sources)}
+	for i, entries := range results {
+		if errs[i] != nil {
+			failed.Errors = append(failed.Errors, SourceError{Source: s.Names[i], Err: errs[i]})
+			if !isLimit(errs[i]) {
+				continue
+			}
+		}
+		for _, e := range entries.Items {
+			if e.State == "" {
+				e.State = State(strings.ToUpper(s.Names[i]))
+			}
+			merged.Add(e)
+		}
+		merged.Warnings = append(merged.Warnings, entries.Warnings...)
+	}
+	if len(failed.Errors) > 0 {
+		return merged, failed
+	}
+	return merged, nil
+}
+
+func init() {
+	RegisterSource("act", func(options SourceOptions) DataSource {
+		if options.Endpoint == "" {
+			options.Endpoint = ACTEndpointURL
+		}
+		return &actSource{Endpoint: options.Endpoint, CSVURL: options.CSVURL, Format: options.Format, File: options.File, Cache: options.Cache, Mapping: options.Mapping}
+	})
+	RegisterSource("nsw", func(options SourceOptions) DataSource {
+		if options.Endpoint == "" {
+			options.Endpoint = NSWEndpointURL
+		}
+		return &nswSource{Endpoint: options.Endpoint, File: options.File, Cache: options.Cache}
+	})
+	RegisterSource("vic", func(options SourceOptions) DataSource {
+		if options.Endpoint == "" {
+			options.Endpoint = VICEndpointURL
+		}
+		return &vicSource{Endpoint: options.Endpoint, File: options.File, Cache: options.Cache}
+	})
+	RegisterSource("qld", func(options SourceOptions) DataSource {
+		if options.Endpoint == "" {
+			options.Endpoint = QLDEndpointURL
+		}
+		return &qldSource{Endpoint: options.Endpoint, File: options.File, Cache: options.Cache}
+	})
+}
+
+// actSource is a DataSource for the ACT Government exposure locations,
+// which scrapes the web page for the CSV file and processes it.
+type actSource struct {
+	// Endpoint is the URL of the web page linking to the CSV file.
+	Endpoint string
+	// CSVURL is an optional URL of the CSV file, which is downloaded
+	// without scraping the Endpoint when set.
+	CSVURL string
+	// Format is the format of the data, being csv by default, json or
+	// xlsx.
+	Format string
+	// File is an optional path to a local copy of the CSV file, which is
+	// used instead of the Endpoint when set.
+	File string
+	// Cache is an optional Cache for the downloaded CSV file.
+	Cache *Cache
+	// Mapping is an optional ColumnMapping of the CSV file, which is used
+	// instead of finding the columns relative to the date when set.
+	Mapping *ColumnMapping
+}
+
+// Fetch will retrieve the ACT CSV file and translate it into Entries as it
+// is read.
+func (s *actSource) Fetch() (Entries, error) {
+	c := &Client{}
+	var limit error
+	if s.File == "" {
+		direct := s.dataURL()
+		key := "act " + s.Endpoint
+		if direct != "" {
+			key = "act " + direct
+		}
+		if data, ok := s.Cache.Get(key); ok {
+			if err := s.read(c, bytes.NewReader(data)); err != nil {
+				return Entries{}, err
+			}
+		} else {
+			// A stale copy is revalidated with the CSV file it came from,
+			// skipping the web page, which is only scraped again when there
+			// is no stale copy.
+			resp, stale := refresh(s.Cache, "act", key)
+			if stale != nil {
+				if err := s.read(c, bytes.NewReader(stale)); err != nil {
+					return Entries{}, err
+				}
+				return s.entries(c, nil)
+			}
+			if resp == nil {
+				if direct != "" {
+					c.DataEndpoint = direct
+				} else if err := c.GetHTML(s.Endpoint); err != nil {
+					return Entries{}, err
+				} else if err := c.GetCSVReference(); err != nil {
+					return Entries{}, err
+				}
+				var err error
+				if resp, err = c.openCSVData(); err != nil {
+					return Entries{}, err
+				}
+			}
+			defer resp.Body.Close()
+			// The file is only kept in memory for the cache, and the rows
+			// read before it was cut off are still used, but not cached.
+			var raw io.Reader = resp.Body
+			var data bytes.Buffer
+			if s.Cache != nil {
+				raw = io.TeeReader(resp.Body, &data)
+			}
+			if err := s.read(c, raw); isLimit(err) {
+				limit = err
+			} else if err != nil {
+				return Entries{}, err
+			} else if err := s.Cache.PutValidated(key, data.Bytes(), validators(resp)); err != nil {
+				return Entries{}, err
+			}
+		}
+	} else {
+		f, err := openFile(s.File)
+		if err != nil {
+			return Entries{}, err
+		}
+		defer f.Close()
+		if err := s.read(c, f); err != nil {
+			return Entries{}, err
+		}
+	}
+
+	return s.entries(c, limit)
+}
+
+// entries will return the RawResults read into the client, with the limit
+// the download reached if any.
+func (s *actSource) entries(c *Client, limit error) (Entries, error) {
+	trust := TrustOfficial
+	if s.File != "" {
+		trust = TrustImported
+	}
+	c.RawResults.SetTrust(trust)
+	c.RawResults.Dedupe()
+	if err := DefaultLimits.rows(&c.RawResults); limit == nil {
+		limit = err
+	}
+	return c.RawResults, limit
+}
+
+// dataURL will return the URL the data is downloaded from without scraping
+// the web page of the Endpoint, which is the CSVURL, or the Endpoint for
+// JSON data. It is empty when the web page is scraped for the CSV file.
+func (s *actSource) dataURL() string {
+	if s.CSVURL == "" && s.Format == "json" {
+		return s.Endpoint
+	}
+	return s.CSVURL
+}
+
+// read will translate the rows of the CSV file or Excel workbook, or the
+// records of the JSON data, read from r into the RawResults of the client,
+// with the Mapping when there is one.
+func (s *actSource) read(c *Client, r io.Reader) error {
+	switch s.Format {
+	case "json":
+		if s.Mapping == nil {
+			return fmt.Errorf("json data needs a mapping of the path of each field")
+		}
+		entries, err := s.Mapping.ParseJSON(r)
+		c.RawResults = entries
+		return err
+	case "xlsx":
+		return s.readXLSX(c, r)
+	case "", "csv":
+	default:
+		return fmt.Errorf("unknown format '%s', expected one of [csv|json|xlsx]", s.Format)
+	}
+
+	// A workbook is told apart from a CSV file by the zip file it is.
+	buffered := bufio.NewReader(r)
+	if magic, _ := buffered.Peek(len(xlsxMagic)); string(magic) == xlsxMagic {
+		return s.readXLSX(c, buffered)
+	}
+	return s.readCSV(c, buffered)
+}
+
+// readCSV will translate the rows of the CSV file read from r into the
+// RawResults of the client, with the Mapping when there is one.
+func (s *actSource) readCSV(c *Client, r io.Reader) error {
+	if s.Mapping == nil {
+		return c.ReadCSVData(r)
+	}
+	entries, err := s.Mapping.ParseReader(r)
+	c.RawResults = entries
+	return err
+}
+
+// readXLSX will translate the rows of the first sheet of the Excel workbook
+// read from r like those of a CSV file. A workbook can't be read in part, so
+// it fails when reading does.
+func (s *actSource) readXLSX(c *Client, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("could not read xlsx workbook: %s", err.Error())
+	}
+	csvData, err := xlsxCSV(data)
+	if err != nil {
+		return err
+	}
+	return s.readCSV(c, bytes.NewReader(csvData))
+}
+
+// nswSource is a DataSource for the NSW Health exposure locations dataset.
+type nswSource struct {
+	// Endpoint is the URL of the JSON dataset.
+	Endpoint string
+	// File is an optional path to a local copy of the JSON dataset, which
+	// is used instead of the Endpoint when set.
+	File string
+	// Cache is an optional Cache for the downloaded JSON dataset.
+	Cache *Cache
+}
+
+// nswDataset is the structure of the NSW Health JSON dataset.
+type nswDataset struct {
+	Date  string `json:"date"`
+	Title string `json:"title"`
+	Data  struct {
+		Monitor []nswVenue `json:"monitor"`
+	} `json:"data"`
+}
+
+// nswVenue is a single exposure location in the NSW Health JSON dataset.
+type nswVenue struct {
+	Venue            string `json:"Venue"`
+	Address          string `json:"Address"`
+	Suburb           string `json:"Suburb"`
+	Date             string `json:"Date"`
+	Time             string `json:"Time"`
+	Alert            string `json:"Alert"`
+	HealthAdviceHTML string `json:"HealthAdviceHTML"`
+}
+
+// Fetch will retrieve the NSW dataset and translate it into Entries.
+func (s *nswSource) Fetch() (Entries, error) {
+	if s.File != "" {
+		f, err := openFile(s.File)
+		if err != nil {
+			return Entries{}, err
+		}
+		defer f.Close()
+		entries, err := parseNSW(f)
+		entries.SetTrust(TrustImported)
+		return entries, err
+	}
+
+	data, err := download("nsw", s.Endpoint, s.Cache)
+	if isLimit(err) {
+		return Entries{}, fmt.Errorf("could not parse NSW dataset: %s", err.Error())
+	}
+	if err != nil {
+		return Entries{}, err
+	}
+	entries, err := parseNSW(bytes.NewReader(data))
+	entries.SetTrust(TrustOfficial)
+	return entries, err
+}
+
+// Stdin is the reader of the data of a source whose File is "-", which is
+// standard input by default. It is only read once, and its data is read
+// again by later fetches, such as the polls of watch mode.
+var Stdin io.Reader = os.Stdin
+
+var (
+	// stdinOnce guards the reading of Stdin into stdinData.
+	stdinOnce sync.Once
+	stdinData []byte
+	stdinErr  error
+)
+
+// CheckFile will return an error explaining why the local copy of the data
+// of a source can't be read, being that it doesn't exist, is a directory or
+// isn't readable, so a mistyped path is reported before anything is fetched.
+// There is nothing to check of an empty path or "-", read from Stdin.
+func CheckFile(path string) error {
+	if path == "" || path == "-" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		return fmt.Errorf("could not read file '%s': it is a directory", path)
+	}
+	if err == nil {
+		var f *os.File
+		if f, err = os.Open(path); err == nil {
+			f.Close()
+			return nil
+		}
+	}
+	switch {
+	case os.IsNotExist(err):
+		if wd, _ := os.Getwd(); wd != "" && !filepath.IsAbs(path) {
+			return fmt.Errorf("could not read file '%s': it does not exist in %s", path, wd)
+		}
+		return fmt.Errorf("could not read file '%s': it does not exist", path)
+	case os.IsPermission(err):
+		return fmt.Errorf("could not read file '%s': permission denied", path)
+	}
+	return fmt.Errorf("could not read file '%s': %s", path, err.Error())
+}
+
+// readFile will read the local copy of the data of a source, or Stdin when
+// the path is "-".
+func readFile(path string) ([]byte, error) {
+	if path != "-" {
+		if err := CheckFile(path); err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read file '%s': %s", path, err.Error())
+		}
+		return data, nil
+	}
+	stdinOnce.Do(func() {
+		if stdinData, stdinErr = ioutil.ReadAll(Stdin); stdinErr != nil {
+			stdinErr = fmt.Errorf("could not read stdin: %s", stdinErr.Error())
+		}
+	})
+	return stdinData, stdinErr
+}
+
+// openFile will open the local copy of the data of a source like readFile.
+func openFile(path string) (io.ReadCloser, error) {
+	if path != "-" {
+		if err := CheckFile(path); err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read file '%s': %s", path, err.Error())
+		}
+		return f, nil
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// download will fetch the dataset of the named source from the endpoint,
+// reusing it from the cache while it is fresh, or while it is stale and
+// can't be revalidated. When the dataset is longer
+// than the MaxFetchBytes of the DefaultLimits, what was read is returned
+// uncached with the LimitError.
+func download(name, endpoint string, cache *Cache) ([]byte, error) {
+	key := name + " " + endpoint
+	if data, ok := cache.Get(key); ok {
+		return data, nil
+	}
+
+	resp, stale := refresh(cache, name, key)
+	if stale != nil {
+		return stale, nil
+	}
+	if resp == nil {
+		var err error
+		if resp, err = DefaultRetryPolicy.Get(endpoint); err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to fetch data: %d %s", resp.StatusCode, resp.Status)
+		}
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(newUTF8Reader(resp.Body))
+	if isLimit(err) {
+		return data, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, cache.PutValidated(key, data, validators(resp))
+}
+
+// refresh will revalidate the stale copy cached under the key for the named
+// source like revalidate, except the stale copy is still returned when it
+// can't be revalidated, such as when the endpoint is unreachable.
+func refresh(cache *Cache, name, key string) (*http.Response, []byte) {
+	resp, stale, err := revalidate(cache, key)
+	if err != nil {
+		DefaultLogger.Debug("revalidating the cached copy failed", "source", name, "error", err)
+		if data, _, ok := cache.Stale(key); ok {
+			return nil, data
+		}
+	}
+	return resp, stale
+}
+
+// revalidate will request the stale copy cached under the key again from
+// where it was downloaded, only if it has changed since. When it hasn't,
+// the copy is made fresh again and returned. Otherwise the response of the
+// changed data is returned, or neither when there is no stale copy with
+// CacheValidators to revalidate.
+func revalidate(cache *Cache, key string) (*http.Response, []byte, error) {
+	data, v, ok := cache.Stale(key)
+	if !ok || v.URL == "" || (v.ETag == "" && v.LastModified == "") {
+		return nil, nil, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, v.URL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+	resp, err := DefaultRetryPolicy.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		resp.Body.Close()
+		DefaultLogger.Debug("cached copy is unchanged", "url", v.URL)
+		// It is only revalidated again next time when it can't be touched.
+		if err := cache.Touch(key); err != nil {
+			DefaultLogger.Warn("could not refresh the cached copy", "error", err)
+		}
+		return nil, data, nil
+	case http.StatusOK:
+		return resp, nil, nil
+	}
+	resp.Body.Close()
+	return nil, nil, fmt.Errorf("failed to fetch data: %d %s", resp.StatusCode, resp.Status)
+}
+
+// validators will return the CacheValidators of the response.
+func validators(resp *http.Response) CacheValidators {
+	return CacheValidators{
+		URL:          resp.Request.URL.String(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+}
+
+// parseNSW will decode the NSW Health JSON dataset into Entries. Venues with
+// dates or times which cannot be parsed are still included, with those
+// fields left at their zero value.
+func parseNSW(r io.Reader) (Entries, error) {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Entries{}, err
+	}
+
+	dataset := nswDataset{}
+	if err := json.Unmarshal(body, &dataset); err != nil {
+		return Entries{}, fmt.Errorf("could not parse NSW dataset: %s", err.Error())
+	}
+
+	entries := Entries{}
+	for _, venue := range dataset.Data.Monitor {
+		date := time.Time{}
+		if t, err := time.Parse("Monday 2 January 2006", strings.TrimSpace(venue.Date)); err == nil {
+			date = t
+		} else {
+			DefaultLogger.Debug("unrecognized field", "field", "date", "location", venue.Venue, "error", err)
+			entries.warn("nsw", []string{venue.Venue, venue.Address, venue.Suburb, venue.Date, venue.Time}, "an unparseable date", false)
+		}
+		start, end := nswTimes(venue.Time)
+		entries.Add(Entry{
+			ExposureLocation: strings.TrimSpace(venue.Venue),
+			Street:           strings.TrimSpace(venue.Address),
+			Suburb:           strings.TrimSpace(venue.Suburb),
+			State:            StateNSW,
+			Date:             &date,
+			ArrivalTime:      start,
+			DepartureTime:    end,
+			Contact:          MapContact("nsw", venue.Alert+" "+venue.HealthAdviceHTML),
+			ContactLabel:     strings.TrimSpace(venue.Alert),
+		})
+	}
+
+	entries.Dedupe()
+	return entries, DefaultLimits.rows(&entries)
+}
+
+// nswTimes will split a NSW time window such as "8:40am to 9:10am" or
+// "9am to 10am" into an arrival and departure time.
+func nswTimes(in string) (*time.Time, *time.Time) {
+	start, end := &time.Time{}, &time.Time{}
+	parts := strings.Split(strings.ToUpper(in), " TO ")
+	if len(parts) != 2 {
+		return start, end
+	}
+	if t, ok := nswTime(parts[0]); ok {
+		start = &t
+	}
+	if t, ok := nswTime(parts[1]); ok {
+		end = &t
+	}
+	return start, end
+}
+
+// nswTime will parse a single time with or without minutes.
+func nswTime(in string) (time.Time, bool) {
+	in = strings.ReplaceAll(strings.TrimSpace(in), " ", "")
+	for _, layout := range []string{time.Kitchen, "3PM"} {
+		if t, err := time.Parse(layout, in); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// vicSource is a DataSource for the Victorian Department of Health exposure
+// sites dataset, which is read from the DataVic API as JSON or downloaded as
+// CSV.
+type vicSource struct {
+	// Endpoint is the URL of the JSON API resource or CSV file.
+	Endpoint string
+	// File is an optional path to a local copy of the dataset as JSON or
+	// CSV, which is used instead of the Endpoint when set.
+	File string
+	// Cache is an optional Cache for the downloaded dataset.
+	Cache *Cache
+}
+
+// vicDataset is the structure of a DataVic API response.
+type vicDataset struct {
+	Result struct {
+		Records []map[string]interface{} `json:"records"`
+	} `json:"result"`
+}
+
+// Fetch will retrieve the Victorian dataset and translate it into Entries.
+func (s *vicSource) Fetch() (Entries, error) {
+	trust := TrustOfficial
+	var data []byte
+	var err error
+	if s.File != "" {
+		trust = TrustImported
+		data, err = readFile(s.File)
+	} else {
+		data, err = download("vic", s.Endpoint, s.Cache)
+	}
+	if isLimit(err) {
+		return Entries{}, fmt.Errorf("could not parse VIC dataset: %s", err.Error())
+	}
+	if err != nil {
+		return Entries{}, err
+	}
+	entries, err := parseVIC(data)
+	entries.SetTrust(trust)
+	return entries, err
+}
+
+// parseVIC will decode the Victorian dataset, either a DataVic API response
+// or a CSV file with a header row, into Entries. Sites with dates or times
+// which cannot be parsed are still included, with those fields left at their
+// zero value.
+func parseVIC(data []byte) (Entries, error) {
+	records := []map[string]string{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		dataset := vicDataset{}
+		if err := json.Unmarshal(trimmed, &dataset); err != nil {
+			return Entries{}, fmt.Errorf("could not parse VIC dataset: %s", err.Error())
+		}
+		for _, r := range dataset.Result.Records {
+			record := map[string]string{}
+			for k, v := range r {
+				if v != nil {
+					record[k] = fmt.Sprint(v)
+				}
+			}
+			records = append(records, record)
+		}
+	} else {
+		rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return Entries{}, fmt.Errorf("could not parse VIC dataset: %s", err.Error())
+		}
+		for n, row := range rows {
+			if n == 0 {
+				continue
+			}
+			record := map[string]string{}
+			for i, field := range row {
+				if i < len(rows[0]) {
+					record[strings.TrimPrefix(rows[0][i], "\ufeff")] = field
+				}
+			}
+			records = append(records, record)
+		}
+	}
+
+	entries := Entries{}
+	for _, r := range records {
+		date := time.Time{}
+		if t, err := time.Parse("02/01/2006", strings.TrimSpace(r["Exposure_date"])); err == nil {
+			date = t
+		} else {
+			DefaultLogger.Debug("unrecognized field", "field", "date", "location", r["Site_title"], "error", err)
+			entries.warn("vic", []string{r["Site_title"], r["Site_streetaddress"], r["Suburb"], r["Exposure_date"], r["Exposure_time"]}, "an unparseable date", false)
+		}
+		start, end := vicTime(r["Exposure_time_start_24"]), vicTime(r["Exposure_time_end_24"])
+		if start.IsZero() && end.IsZero() {
+			start, end = nswTimes(strings.Replace(r["Exposure_time"], " - ", " to ", 1))
+		}
+		state, err := ParseState(r["Site_state"])
+		if err != nil || state == StateUnknown {
+			state = StateVIC
+		}
+		entries.Add(Entry{
+			ExposureLocation: strings.TrimSpace(r["Site_title"]),
+			Street:           strings.TrimSpace(r["Site_streetaddress"]),
+			Suburb:           strings.TrimSpace(r["Suburb"]),
+			State:            state,
+			Date:             &date,
+			ArrivalTime:      start,
+			DepartureTime:    end,
+			Contact:          MapContact("vic", r["Advice_title"]),
+			ContactLabel:     strings.TrimSpace(r["Advice_title"]),
+		})
+	}
+	entries.Dedupe()
+	return entries, DefaultLimits.rows(&entries)
+}
+
+// vicTime will parse a 24 hour time such as "19:00:00" or "19:00".
+func vicTime(in string) *time.Time {
+	in = strings.TrimSpace(in)
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, in); err == nil {
+			return &t
+		}
+	}
+	return &time.Time{}
+}
+
+// qldSource is a DataSource for the Queensland Health contact tracing
+// locations, which scrapes the tables of the web page.
+type qldSource struct {
+	// Endpoint is the URL of the web page listing the locations.
+	Endpoint string
+	// File is an optional path to a local copy of the web page, which is
+	// used instead of the Endpoint when set.
+	File string
+	// Cache is an optional Cache for the downloaded web page.
+	Cache *Cache
+}
+
+// Fetch will retrieve the Queensland web page and translate its tables into
+// Entries.
+func (s *qldSource) Fetch() (Entries, error) {
+	trust := TrustOfficial
+	var data []byte
+	var err error
+	if s.File != "" {
+		trust = TrustImported
+		data, err = readFile(s.File)
+	} else {
+		data, err = download("qld", s.Endpoint, s.Cache)
+	}
+	// The tables of a page which was cut off are still read, as far as
+	// they go.
+	limit := err
+	if err != nil && !isLimit(err) {
+		return Entries{}, err
+	}
+	entries, err := parseQLD(bytes.NewReader(data))
+	entries.SetTrust(trust)
+	if limit != nil && err == nil {
+		err = limit
+	}
+	return entries, err
+}
+
+// qldColumns are the fields of the Queensland tables, each found by the
+// first column with a header containing one of its names.
+var qldColumns = map[string][]string{
+	"date":     {"date"},
+	"location": {"place", "venue", "location"},
+	"street":   {"address"},
+	"suburb":   {"suburb"},
+	"time":     {"time"},
+	"contact":  {"advice", "category", "risk", "contact"},
+}
+
+// parseQLD will read the tables of the Queensland web page into Entries,
+// using the columns named by their headers. The contact level is read from
+// an advice column, or otherwise from the heading or caption of the table,
+// such as "Close contacts" or "Low risk contacts". Locations with dates or
+// times which cannot be parsed are still included, with those fields left
+// at their zero value.
+func parseQLD(r io.Reader) (Entries, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Entries{}, fmt.Errorf("could not parse QLD page: %s", err.Error())
+	}
+
+	entries := Entries{}
+	heading := ""
+	skipped := 0
+	doc.Find("h2, h3, h4, table").Each(func(_ int, node *goquery.Selection) {
+		if !node.Is("table") {
+			heading = node.Text()
+			return
+		}
+		if caption := node.Find("caption"); caption.Length() > 0 {
+			heading = caption.Text()
+		}
+
+		columns := map[string]int{}
+		node.Find("tr").First().Find("th, td").Each(func(i int, cell *goquery.Selection) {
+			header := strings.ToLower(cell.Text())
+			for field, names := range qldColumns {
+				for _, name := range names {
+					if _, ok := columns[field]; !ok && strings.Contains(header, name) {
+						columns[field] = i
+					}
+				}
+			}
+		})
+		if _, ok := columns["location"]; !ok {
+			return
+		}
+
+		node.Find("tr").Slice(1, goquery.ToEnd).Each(func(_ int, row *goquery.Selection) {
+			cells := []string{}
+			row.Find("td").Each(func(_ int, cell *goquery.Selection) {
+				cells = append(cells, strings.Join(strings.Fields(cell.Text()), " "))
+			})
+			value := func(field string) string {
+				if i, ok := columns[field]; ok && i < len(cells) {
+					return cells[i]
+				}
+				return ""
+			}
+			if len(cells) == 0 || value("location") == "" {
+				if len(cells) > 0 {
+					skipped++
+					entries.warn("qld", cells, "a missing location", true)
+				}
+				return
+			}
+
+			label := value("contact")
+			contact := MapContact("qld", label)
+			if contact == ContactUnknown {
+				label = strings.Join(strings.Fields(heading), " ")
+				contact = MapContact("qld", label)
+			}
+			date := time.Time{}
+			for _, layout := range []string{"Monday 2 January 2006", "2 January 2006", "02/01/2006", "2/1/2006"} {
+				if t, err := time.Parse(layout, value("date")); err == nil {
+					date = t
+					break
+				}
+			}
+			if date.IsZero() {
+				DefaultLogger.Debug("unrecognized field", "field", "date", "location", value("location"), "value", value("date"))
+				entries.warn("qld", cells, "an unparseable date", false)
+			}
+			window := strings.NewReplacer(".", ":", " - ", " to ", "-", " to ").Replace(value("time"))
+			start, end := nswTimes(strings.Join(strings.Fields(window), " "))
+			entries.Add(Entry{
+				ExposureLocation: value("location"),
+				Street:           value("street"),
+				Suburb:           value("suburb"),
+				State:            StateQLD,
+				Date:             &date,
+				ArrivalTime:      start,
+				DepartureTime:    end,
+				Contact:          contact,
+				ContactLabel:     label,
+			})
+		})
+	})
+	if skipped > 0 {
+		DefaultLogger.Info("skipped rows without a location", "source", "qld", "rows", skipped)
+	}
+	entries.Dedupe()
+	return entries, DefaultLimits.rows(&entries)
+}
@@ -0,0 +1,483 @@
+package covidcheck
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// nswTestData is a small extract in the format of the NSW Health dataset.
+var nswTestData = `{
+  "date": "2021-11-04",
+  "title": "NSW COVID-19 case locations",
+  "data": {
+    "monitor": [
+      {
+        "Venue": "Woolworths Queanbeyan",
+        "Address": "Monaro Street",
+        "Suburb": "Queanbeyan",
+        "Date": "Wednesday 3 November 2021",
+        "Time": "8:40am to 9:10am",
+        "Alert": "Get tested immediately and self-isolate until you receive a negative result.",
+        "HealthAdviceHTML": "Anyone who attended this venue is a casual contact."
+      },
+      {
+        "Venue": "Jerrabomberra Fitness",
+        "Address": "1 Limestone Drive",
+        "Suburb": "Jerrabomberra",
+        "Date": "Tuesday 2 November 2021",
+        "Time": "6pm to 7pm",
+        "Alert": "Get tested immediately and self-isolate for 7 days.",
+        "HealthAdviceHTML": "Anyone who attended this venue is a close contact."
+      }
+    ]
+  }
+}`
+
+// TestNSWSource will serve a static NSW dataset and check it is fetched and
+// translated into the expected Entries.
+func TestNSWSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, nswTestData)
+	}))
+	defer server.Close()
+
+	var src DataSource = &nswSource{Endpoint: server.URL}
+	entries, err := src.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Counting entries", func(t *testing.T) {
+		if entries.Len() != 2 {
+			t.Fail()
+		}
+	})
+
+	t.Run("Translating fields", func(t *testing.T) {
+		e := entries.Items[0]
+		if e.ExposureLocation != "Woolworths Queanbeyan" || e.Street != "Monaro Street" || e.Suburb != "Queanbeyan" || e.State != "NSW" {
+			t.Fail()
+		}
+		if e.Date.Format("02/01/2006") != "03/11/2021" {
+			t.Errorf("unexpected date %v", e.Date)
+		}
+		if e.ArrivalTime.Format(time.Kitchen) != "8:40AM" || e.DepartureTime.Format(time.Kitchen) != "9:10AM" {
+			t.Errorf("unexpected times %v - %v", e.ArrivalTime, e.DepartureTime)
+		}
+		if e.Contact != "Casual" {
+			t.Errorf("unexpected contact %s", e.Contact)
+		}
+	})
+
+	t.Run("Translating times without minutes", func(t *testing.T) {
+		e := entries.Items[1]
+		if e.ArrivalTime.Format(time.Kitchen) != "6:00PM" || e.DepartureTime.Format(time.Kitchen) != "7:00PM" {
+			t.Errorf("unexpected times %v - %v", e.ArrivalTime, e.DepartureTime)
+		}
+		if e.Contact != "Close" {
+			t.Errorf("unexpected contact %s", e.Contact)
+		}
+	})
+
+	t.Run("Reporting failed requests", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer failing.Close()
+		if _, err := (&nswSource{Endpoint: failing.URL}).Fetch(); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+// actTestCSV is a small extract in the format of the ACT Government dataset.
+var actTestCSV = `1,"New","ALDI Belconnen","Westfield Belconnen, Benjamin Way","Belconnen","ACT","04/10/2021 - Monday",7:00pm,7:30pm,"Casual"
+2,"Archived","Kaleen Plaza Pharmacy","Shop 5, Kaleen Shopping Centre, Georgina Crescent","Kaleen","ACT","01/09/2021 - Wednesday",6:15pm,7:10pm,"Close"
+`
+
+// TestACTSource will serve a static ACT web page and CSV file and check they
+// are discovered, fetched and translated through the source registry.
+func TestACTSource(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body><script>\nPapa.parse(\"%s/data.csv\", {download: true});\n</script></body></html>", server.URL)
+	})
+	mux.HandleFunc("/data.csv", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, actTestCSV)
+	})
+
+	t.Run("Looking up an unknown source", func(t *testing.T) {
+		if _, err := NewSource("nowhere", SourceOptions{}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("Fetching from the registry", func(t *testing.T) {
+		src, err := NewSource("act", SourceOptions{Endpoint: server.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := src.Fetch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries.Len() != 2 {
+			t.Errorf("expected 2 entries, got %d", entries.Len())
+		}
+		if entries.Items[0].Trust != TrustOfficial {
+			t.Errorf("unexpected trust %s", entries.Items[0].Trust)
+		}
+	})
+
+	t.Run("Fetching the csv file directly", func(t *testing.T) {
+		src, _ := NewSource("act", SourceOptions{Endpoint: "http://covid-check.invalid/", CSVURL: server.URL + "/data.csv"})
+		entries, err := src.Fetch()
+		if err != nil || entries.Len() != 2 {
+			t.Errorf("expected 2 entries without the page, got %d and %v", entries.Len(), err)
+		}
+
+		src, _ = NewSource("act", SourceOptions{Endpoint: server.URL + "/data.csv"})
+		if _, err := src.Fetch(); err == nil || !strings.Contains(err.Error(), "-csv-url") {
+			t.Errorf("expected an error suggesting -csv-url, got %v", err)
+		}
+	})
+
+	t.Run("Labelling files as imported", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "covid-check-source")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "data.csv")
+		ioutil.WriteFile(path, []byte(actTestCSV), 0644)
+
+		src, _ := NewSource("act", SourceOptions{File: path})
+		entries, err := src.Fetch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries.Len() != 2 || entries.Items[0].Trust != TrustImported {
+			t.Fail()
+		}
+	})
+
+	t.Run("Reading stdin", func(t *testing.T) {
+		defer func(r io.Reader) { Stdin, stdinOnce = r, sync.Once{} }(Stdin)
+		Stdin, stdinOnce = strings.NewReader(actTestCSV), sync.Once{}
+
+		src, _ := NewSource("act", SourceOptions{File: "-"})
+		for i := 0; i < 2; i++ {
+			entries, err := src.Fetch()
+			if err != nil || entries.Len() != 2 {
+				t.Errorf("expected 2 entries on fetch %d, got %d and %v", i+1, entries.Len(), err)
+			}
+		}
+	})
+
+	t.Run("Filtering by trust", func(t *testing.T) {
+		covid := &Client{RawResults: Entries{Items: []Entry{
+			{ExposureLocation: "ALDI Belconnen", Trust: TrustOfficial},
+			{ExposureLocation: "Westfield Woden", Trust: TrustCommunity},
+		}}}
+		if !covid.RawResults.MixedTrust() {
+			t.Error("expected the entries to have mixed trust")
+		}
+		covid.Query(&Filter{Trust: TrustOfficial}, QueryParams{})
+		if len(covid.FilteredResults.Items) != 1 || covid.FilteredResults.Items[0].ExposureLocation != "ALDI Belconnen" {
+			t.Fail()
+		}
+	})
+
+	t.Run("Reporting unreadable files", func(t *testing.T) {
+		src, _ := NewSource("act", SourceOptions{File: "does-not-exist.csv"})
+		if _, err := src.Fetch(); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+// TestFileSources will write a local copy of the data of each source and
+// check it is read in place of the endpoint, and that paths which can't be
+// read are explained.
+func TestFileSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"act": "act.csv",
+		"nsw": "nsw.json",
+		"vic": "vic.csv",
+		"qld": "qld.html",
+	}
+	data := map[string]string{
+		"act": actTestCSV,
+		"nsw": nswTestData,
+		"vic": vicTestCSV,
+		"qld": qldTestHTML,
+	}
+	counts := map[string]int{"act": 2, "nsw": 2, "vic": 1, "qld": 3}
+	for name, file := range files {
+		ioutil.WriteFile(filepath.Join(dir, file), []byte(data[name]), 0644)
+	}
+
+	for _, name := range []string{"act", "nsw", "vic", "qld"} {
+		t.Run("Reading "+name+" files", func(t *testing.T) {
+			src, _ := NewSource(name, SourceOptions{Endpoint: "http://covid-check.invalid/", File: filepath.Join(dir, files[name])})
+			entries, err := src.Fetch()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries.Len() != counts[name] {
+				t.Fatalf("expected %d entries, got %d", counts[name], entries.Len())
+			}
+			for _, e := range entries.Items {
+				if e.Trust != TrustImported {
+					t.Errorf("expected imported entries, got %s", e.Trust)
+				}
+			}
+		})
+	}
+
+	t.Run("Explaining unreadable paths", func(t *testing.T) {
+		for path, reason := range map[string]string{
+			filepath.Join(dir, "missing.csv"): "does not exist",
+			"missing.csv":                     "does not exist in ",
+			dir:                               "is a directory",
+		} {
+			if err := CheckFile(path); err == nil || !strings.Contains(err.Error(), reason) {
+				t.Errorf("expected %s to be reported as %q, got %v", path, reason, err)
+			}
+			for _, name := range []string{"act", "nsw", "vic", "qld"} {
+				src, _ := NewSource(name, SourceOptions{File: path})
+				if _, err := src.Fetch(); err == nil || !strings.Contains(err.Error(), reason) {
+					t.Errorf("expected %s of %s to be reported as %q, got %v", path, name, reason, err)
+				}
+			}
+		}
+		for _, path := range []string{"", "-", filepath.Join(dir, "act.csv")} {
+			if err := CheckFile(path); err != nil {
+				t.Errorf("expected %q to be readable, got %v", path, err)
+			}
+		}
+	})
+}
+
+// vicTestJSON is a small extract in the format of the DataVic API.
+var vicTestJSON = `{
+  "success": true,
+  "result": {
+    "records": [
+      {
+        "_id": 1,
+        "Suburb": "Brunswick",
+        "Site_title": "Coles Brunswick",
+        "Site_streetaddress": "400 Sydney Road",
+        "Site_state": "VIC",
+        "Exposure_date": "04/10/2021",
+        "Exposure_time": "7:00pm - 7:30pm",
+        "Advice_title": "Tier 2 - Get tested immediately and isolate until you have a negative result",
+        "Exposure_time_start_24": "19:00:00",
+        "Exposure_time_end_24": "19:30:00"
+      }
+    ]
+  }
+}`
+
+// vicTestCSV is a small extract in the format of the DataVic CSV download.
+var vicTestCSV = "\ufeffSuburb,Site_title,Site_streetaddress,Site_state,Exposure_date,Exposure_time,Advice_title\n" +
+	"Carlton,Lygon Street Cafe,1 Lygon Street,,05/10/2021,8:15am - 9am,Tier 1 - Get tested immediately and quarantine for 14 days\n"
+
+// TestVICSource will serve static Victorian datasets and check they are
+// fetched and translated into the expected Entries.
+func TestVICSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, vicTestJSON)
+	}))
+	defer server.Close()
+
+	t.Run("Translating the api", func(t *testing.T) {
+		src, _ := NewSource("vic", SourceOptions{Endpoint: server.URL})
+		entries, err := src.Fetch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries.Len() != 1 {
+			t.Fatalf("expected 1 entry, got %d", entries.Len())
+		}
+		e := entries.Items[0]
+		if e.ExposureLocation != "Coles Brunswick" || e.Street != "400 Sydney Road" || e.Suburb != "Brunswick" || e.State != "VIC" || e.Trust != TrustOfficial {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		if e.Date.Format("02/01/2006") != "04/10/2021" || e.ArrivalTime.Format(time.Kitchen) != "7:00PM" || e.DepartureTime.Format(time.Kitchen) != "7:30PM" {
+			t.Errorf("unexpected date and times %v %v - %v", e.Date, e.ArrivalTime, e.DepartureTime)
+		}
+		if e.Contact != "Casual" {
+			t.Errorf("unexpected contact %s", e.Contact)
+		}
+	})
+
+	t.Run("Translating csv files", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "covid-check-source")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "vic.csv")
+		ioutil.WriteFile(path, []byte(vicTestCSV), 0644)
+
+		src, _ := NewSource("vic", SourceOptions{File: path})
+		entries, err := src.Fetch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := entries.Items[0]
+		if entries.Len() != 1 || e.Suburb != "Carlton" || e.State != "VIC" || e.Contact != "Close" || e.Trust != TrustImported {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		if e.ArrivalTime.Format(time.Kitchen) != "8:15AM" || e.DepartureTime.Format(time.Kitchen) != "9:00AM" {
+			t.Errorf("unexpected times %v - %v", e.ArrivalTime, e.DepartureTime)
+		}
+	})
+
+	t.Run("Reporting invalid datasets", func(t *testing.T) {
+		if _, err := parseVIC([]byte("{not json")); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+// qldTestHTML is a small extract in the format of the Queensland Health
+// contact tracing page.
+var qldTestHTML = `<html><body>
+<h2>Close contacts</h2>
+<div><table>
+  <thead><tr><th>Date</th><th>Place</th><th>Address</th><th>Suburb</th><th>Arrival time - Departure time</th></tr></thead>
+  <tbody>
+    <tr><td>Saturday 31 July 2021</td><td>Indooroopilly Shopping Centre</td><td>322 Moggill Road</td><td>Indooroopilly</td><td>10.30am - 11.15am</td></tr>
+  </tbody>
+</table></div>
+<h2>Other locations</h2>
+<table>
+  <tr><th>Date</th><th>Venue</th><th>Address</th><th>Suburb</th><th>Time</th><th>Advice</th></tr>
+  <tr><td>1 August 2021</td><td>Coles Toowong</td><td>9 Sherwood Road</td><td>Toowong</td><td>5pm - 6pm</td><td>Low risk contact</td></tr>
+  <tr><td>01/08/2021</td><td>Toowong Library</td><td></td><td>Toowong</td><td>9am-10am</td><td>Casual contact</td></tr>
+</table>
+<table><tr><th>Phone</th></tr><tr><td>13 43 25 84</td></tr></table>
+</body></html>`
+
+// TestQLDSource will serve a static Queensland page and check its tables are
+// translated into the expected Entries.
+func TestQLDSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, qldTestHTML)
+	}))
+	defer server.Close()
+
+	src, _ := NewSource("qld", SourceOptions{Endpoint: server.URL})
+	entries, err := src.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Counting entries", func(t *testing.T) {
+		if entries.Len() != 3 {
+			t.Fatalf("expected 3 entries, got %d", entries.Len())
+		}
+	})
+
+	t.Run("Translating fields", func(t *testing.T) {
+		e := entries.Items[0]
+		if e.ExposureLocation != "Indooroopilly Shopping Centre" || e.Street != "322 Moggill Road" || e.Suburb != "Indooroopilly" || e.State != "QLD" || e.Trust != TrustOfficial {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		if e.Date.Format("02/01/2006") != "31/07/2021" || e.ArrivalTime.Format(time.Kitchen) != "10:30AM" || e.DepartureTime.Format(time.Kitchen) != "11:15AM" {
+			t.Errorf("unexpected date and times %v %v - %v", e.Date, e.ArrivalTime, e.DepartureTime)
+		}
+		if entries.Items[2].Date.Format("02/01/2006") != "01/08/2021" || entries.Items[2].DepartureTime.Format(time.Kitchen) != "10:00AM" {
+			t.Errorf("unexpected date and times of %+v", entries.Items[2])
+		}
+	})
+
+	t.Run("Mapping advice to contact levels", func(t *testing.T) {
+		for i, contact := range []Contact{ContactClose, ContactMonitor, ContactCasual} {
+			if entries.Items[i].Contact != contact {
+				t.Errorf("expected %s to be a %s contact, got %s", entries.Items[i].ExposureLocation, contact, entries.Items[i].Contact)
+			}
+		}
+	})
+}
+
+// TestMultiSource will merge static sources and check the entries of each
+// are included with their State.
+func TestMultiSource(t *testing.T) {
+	t.Run("Constructing sources", func(t *testing.T) {
+		src, err := NewSource("act, nsw,act", SourceOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if names := src.(*multiSource).Names; len(names) != 2 || names[0] != "act" || names[1] != "nsw" {
+			t.Errorf("unexpected sources %v", names)
+		}
+		if _, err := NewSource("act,nowhere", SourceOptions{}); err == nil {
+			t.Error("expected an unknown source to be reported")
+		}
+		if _, err := NewSource("act,nsw", SourceOptions{File: "data.csv"}); err == nil {
+			t.Error("expected a file to be rejected with several sources")
+		}
+	})
+
+	t.Run("Merging entries", func(t *testing.T) {
+		src := &multiSource{
+			Names: []string{"act", "nsw"},
+			Sources: []DataSource{
+				&staticSource{polls: []Entries{{Items: []Entry{{ExposureLocation: "ALDI Belconnen", State: "ACT"}}}}},
+				&staticSource{polls: []Entries{{Items: []Entry{{ExposureLocation: "Woolworths Queanbeyan"}}}}},
+			},
+		}
+		entries, err := src.Fetch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries.Len() != 2 || entries.Items[0].State != "ACT" || entries.Items[1].ExposureLocation != "Woolworths Queanbeyan" || entries.Items[1].State != "NSW" {
+			t.Errorf("unexpected entries %+v", entries.Items)
+		}
+	})
+
+	t.Run("Reporting failed sources", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer failing.Close()
+		act := &staticSource{polls: []Entries{{Items: []Entry{{ExposureLocation: "ALDI Belconnen"}}}}}
+		src := &multiSource{Names: []string{"act", "nsw", "qld"}, Sources: []DataSource{act, &nswSource{Endpoint: failing.URL}, &qldSource{Endpoint: failing.URL}}, Parallelism: 1}
+		entries, err := src.Fetch()
+		failed, ok := err.(*FetchError)
+		if !ok || !failed.Partial() || len(failed.Errors) != 2 || failed.Errors[0].Source != "nsw" {
+			t.Fatalf("expected the failed sources to be reported, got %v", err)
+		}
+		if !strings.HasPrefix(err.Error(), "2 of 3 sources failed: nsw: ") || !strings.Contains(err.Error(), "; qld: ") {
+			t.Errorf("unexpected error %s", err.Error())
+		}
+		if entries.Len() != 1 || entries.Items[0].State != "ACT" {
+			t.Errorf("expected the entries of the other sources, got %+v", entries.Items)
+		}
+
+		src = &multiSource{Names: []string{"nsw"}, Sources: []DataSource{&nswSource{Endpoint: failing.URL}}}
+		if _, err := src.Fetch(); err == nil || err.(*FetchError).Partial() {
+			t.Error("expected every source to have failed")
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// sparkLevels are the characters of a Sparkline, from the fewest new
+// exposure sites to the most.
+const sparkLevels = " .:-=+*#"
+
+// DayCount is the number of exposure sites first seen on a day.
+type DayCount struct {
+	// Date is the day, formatted as 2006-01-02.
+	Date string `json:"date"`
+	// New is the number of exposure sites first seen on the day.
+	New int `json:"new"`
+}
+
+// SuburbTrend is the number of exposure sites first seen in a suburb over
+// the window, and over the window before it.
+type SuburbTrend struct {
+	// Suburb is the name of the suburb.
+	Suburb string `json:"suburb"`
+	// New is the number of exposure sites first seen over the window.
+	New int `json:"new"`
+	// Previous is the number first seen over the window before it.
+	Previous int `json:"previous"`
+	// Trend describes the change in New from Previous, such as "+50%", or
+	// "new" when there were none before.
+	Trend string `json:"trend"`
+}
+
+// Stats are the counts of the exposure sites first seen in the snapshots of
+// a source over a window of days, compared with the window before it.
+type Stats struct {
+	// From is the first day of the window.
+	From time.Time `json:"from"`
+	// To is the last day of the window.
+	To time.Time `json:"to"`
+	// Snapshots is the number of snapshots replayed.
+	Snapshots int `json:"snapshots"`
+	// New is the number of exposure sites first seen over the window.
+	New int `json:"new"`
+	// Previous is the number first seen over the window before it.
+	Previous int `json:"previous"`
+	// Trend describes the change in New from Previous, such as "-20%".
+	Trend string `json:"trend"`
+	// Days are the number first seen on each day of the window, in order.
+	Days []DayCount `json:"days"`
+	// Suburbs are the suburbs with exposure sites first seen over either
+	// window, those with the most new sites first.
+	Suburbs []SuburbTrend `json:"suburbs"`
+}
+
+// trend will describe the change from previous to current as a percentage.
+func trend(current, previous int) string {
+	switch {
+	case current == previous:
+		return "0%"
+	case previous == 0:
+		return "new"
+	}
+	return fmt.Sprintf("%+d%%", int(math.Round(float64(current-previous)*100/float64(previous))))
+}
+
+// ComputeStats will replay the snapshots of the source in the archive
+// through a Watcher with the filter, and count the exposure sites first seen
+// by each snapshot over the days of the window ending on the day of now.
+// The first snapshot is what the rest are compared to, so its exposure sites
+// aren't counted as new.
+func ComputeStats(archive *SnapshotArchive, source string, filter Filter, days int, now time.Time) (Stats, error) {
+	times, err := archive.Snapshots(source)
+	if err != nil {
+		return Stats{}, err
+	}
+	if len(times) == 0 {
+		return Stats{}, fmt.Errorf("no %s snapshots found in %s", source, archive.Dir)
+	}
+
+	to := day(now)
+	from := to.AddDate(0, 0, 1-days)
+	before := from.AddDate(0, 0, -days)
+	stats := Stats{From: from, To: to, Snapshots: len(times), Days: []DayCount{}, Suburbs: []SuburbTrend{}}
+	counts := map[string]int{}
+	suburbs := map[string]*SuburbTrend{}
+
+	w := &Watcher{Source: &replaySource{Archive: archive, Source: source, Times: times}, Filter: filter}
+	for _, t := range times {
+		changes, err := w.Poll()
+		if err != nil {
+			return stats, err
+		}
+		seen := day(t)
+		if changes.Initial || seen.Before(before) || seen.After(to) {
+			continue
+		}
+		for _, e := range changes.Added.Items {
+			key := normalizeField(e.Suburb)
+			if suburbs[key] == nil {
+				suburbs[key] = &SuburbTrend{Suburb: e.Suburb}
+			}
+			if seen.Before(from) {
+				stats.Previous++
+				suburbs[key].Previous++
+				continue
+			}
+			stats.New++
+			suburbs[key].New++
+			counts[seen.Format(jsonDateFormat)]++
+		}
+	}
+
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		date := d.Format(jsonDateFormat)
+		stats.Days = append(stats.Days, DayCount{Date: date, New: counts[date]})
+	}
+	for _, s := range suburbs {
+		s.Trend = trend(s.New, s.Previous)
+		stats.Suburbs = append(stats.Suburbs, *s)
+	}
+	sort.Slice(stats.Suburbs, func(i, j int) bool {
+		a, b := stats.Suburbs[i], stats.Suburbs[j]
+		if a.New != b.New {
+			return a.New > b.New
+		}
+		if a.Previous != b.Previous {
+			return a.Previous > b.Previous
+		}
+		return a.Suburb < b.Suburb
+	})
+	stats.Trend = trend(stats.New, stats.Previous)
+	return stats, nil
+}
+
+// Sparkline will draw the new exposure sites of each day in the window as
+// a line of ASCII characters, taller for busier days.
+func (s *Stats) Sparkline() string {
+	most := 0
+	for _, d := range s.Days {
+		if d.New > most {
+			most = d.New
+		}
+	}
+	var line strings.Builder
+	for _, d := range s.Days {
+		level := 0
+		if d.New > 0 {
+			level = 1 + d.New*(len(sparkLevels)-2)/most
+		}
+		line.WriteByte(sparkLevels[level])
+	}
+	return line.String()
+}
+
+// RenderStats will render tables of the new exposure sites of each day, and
+// of the busiest suburbs up to top of them, or every suburb when top is 0.
+func RenderStats(w io.Writer, stats Stats, top int) {
+	fmt.Fprintf(w, "new exposure sites from %s to %s, from %d snapshots\n", stats.From.Format(jsonDateFormat), stats.To.Format(jsonDateFormat), stats.Snapshots)
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Date", "New"})
+	for _, d := range stats.Days {
+		table.Append([]string{d.Date, strconv.Itoa(d.New)})
+	}
+	table.Render()
+
+	table = tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Suburb", "New", "Previous", "Trend"})
+	for i, s := range stats.Suburbs {
+		if top > 0 && i >= top {
+			break
+		}
+		table.Append([]string{s.Suburb, strconv.Itoa(s.New), strconv.Itoa(s.Previous), s.Trend})
+	}
+	if len(stats.Suburbs) > 0 {
+		table.Render()
+	}
+	fmt.Fprintf(w, "%d new exposure sites, against %d in the previous %d days (%s)\n", stats.New, stats.Previous, len(stats.Days), stats.Trend)
+}
+
+// ExportStats will write the stats to w as JSON.
+func ExportStats(w io.Writer, stats Stats) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stats)
+}
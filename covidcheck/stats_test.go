@@ -0,0 +1,95 @@
+package covidcheck
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestComputeStats will replay snapshots adding exposure sites on several
+// days, and check the new sites are counted by day, suburb and window.
+func TestComputeStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive := &SnapshotArchive{Dir: dir}
+
+	entries := Entries{}
+	for _, record := range readCSV(actTestCSV + `3,"New","Belconnen Library","Chandler Street","Belconnen","ACT","06/10/2021 - Wednesday",1:00pm,2:00pm,"Casual"
+4,"New","Dickson Shops","Woolley Street","Dickson","ACT","06/10/2021 - Wednesday",1:00pm,2:00pm,"Close"
+`) {
+		entries.Add(fieldTranslate(record))
+	}
+	snapshots := []struct {
+		day   int
+		items []Entry
+	}{
+		{1, entries.Items[1:2]},
+		{3, entries.Items[:2]},
+		{7, entries.Items},
+		{8, entries.Items},
+	}
+	for _, s := range snapshots {
+		if _, err := archive.Save("act", Entries{Items: s.items}, time.Date(2021, 10, s.day, 9, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := ComputeStats(archive, "act", Filter{}, 4, time.Date(2021, 10, 9, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Counting the window", func(t *testing.T) {
+		if stats.Snapshots != 4 || stats.New != 2 || stats.Previous != 1 || stats.Trend != "+100%" {
+			t.Errorf("unexpected totals %d, %d, %d and %s", stats.Snapshots, stats.New, stats.Previous, stats.Trend)
+		}
+		if len(stats.Days) != 4 || stats.Days[0].Date != "2021-10-06" || stats.Days[1].New != 2 {
+			t.Errorf("unexpected days %+v", stats.Days)
+		}
+		if spark := stats.Sparkline(); spark != " #  " {
+			t.Errorf("unexpected sparkline %q", spark)
+		}
+	})
+
+	t.Run("Ranking suburbs", func(t *testing.T) {
+		want := []SuburbTrend{{"Belconnen", 1, 1, "0%"}, {"Dickson", 1, 0, "new"}}
+		if len(stats.Suburbs) != len(want) {
+			t.Fatalf("expected %+v, got %+v", want, stats.Suburbs)
+		}
+		for i := range want {
+			if stats.Suburbs[i] != want[i] {
+				t.Errorf("expected %+v, got %+v", want[i], stats.Suburbs[i])
+			}
+		}
+	})
+
+	t.Run("Filtering", func(t *testing.T) {
+		stats, err := ComputeStats(archive, "act", Filter{Contact: "close"}, 4, time.Date(2021, 10, 9, 12, 0, 0, 0, time.UTC))
+		if err != nil || stats.New != 1 || stats.Previous != 0 || stats.Trend != "new" {
+			t.Errorf("expected one new close contact site, got %+v and %v", stats, err)
+		}
+	})
+
+	t.Run("Rendering", func(t *testing.T) {
+		var buf bytes.Buffer
+		RenderStats(&buf, stats, 1)
+		if out := buf.String(); !strings.Contains(out, "Belconnen") || strings.Contains(out, "Dickson") {
+			t.Errorf("expected only the busiest suburb, got %s", out)
+		}
+		if !strings.Contains(buf.String(), "2 new exposure sites, against 1 in the previous 4 days (+100%)") {
+			t.Errorf("unexpected summary %s", buf.String())
+		}
+	})
+
+	t.Run("Without snapshots", func(t *testing.T) {
+		if _, err := ComputeStats(archive, "nsw", Filter{}, 4, time.Now()); err == nil {
+			t.Error("expected an error without snapshots")
+		}
+	})
+}
@@ -0,0 +1,251 @@
+package covidcheck
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Storage is a remote destination which snapshots and exports can be
+// uploaded to, so archives survive the machine running the tool.
+type Storage interface {
+	// Upload will store data under the given object name.
+	Upload(name string, data []byte) error
+}
+
+// NewStorage will construct a Storage from a destination URL, which is
+// either s3://bucket/prefix or gs://bucket/prefix. Credentials are read
+// from the standard environment variables for each provider.
+func NewStorage(destination, endpoint string) (Storage, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("no bucket found in upload destination '%s'", destination)
+	}
+	prefix := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case "s3":
+		region := os.Getenv("AWS_REGION")
+		if region == "" {
+			region = os.Getenv("AWS_DEFAULT_REGION")
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+		return &s3Storage{
+			Endpoint:     endpoint,
+			Region:       region,
+			Bucket:       u.Host,
+			Prefix:       prefix,
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	case "gs":
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+		return &gcsStorage{
+			Endpoint: endpoint,
+			Bucket:   u.Host,
+			Prefix:   prefix,
+			Token:    os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown upload destination '%s', expected s3:// or gs://", destination)
+}
+
+// objectName will join the prefix and name into an object name.
+func objectName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// s3Storage is a Storage for Amazon S3 and S3-compatible services, which
+// signs requests with AWS Signature Version 4 and uses path-style URLs.
+type s3Storage struct {
+	// Endpoint is the base URL of the S3 service.
+	Endpoint string
+	// Region is the region used in the request signature.
+	Region string
+	// Bucket is the name of the bucket to upload to.
+	Bucket string
+	// Prefix is prepended to the object names.
+	Prefix string
+	// AccessKey is the access key ID.
+	AccessKey string
+	// SecretKey is the secret access key.
+	SecretKey string
+	// SessionToken is an optional token for temporary credentials.
+	SessionToken string
+	// Now returns the time used to sign the request.
+	Now func() time.Time
+}
+
+// Upload will PUT the data as an object in the bucket.
+func (s *s3Storage) Upload(name string, data []byte) error {
+	if s.AccessKey == "" || s.SecretKey == "" {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to upload to s3")
+	}
+
+	path := "/" + awsURIEncode(s.Bucket, false) + "/" + awsURIEncode(objectName(s.Prefix, name), true)
+	req, err := http.NewRequest(http.MethodPut, strings.TrimSuffix(s.Endpoint, "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	s.sign(req, path, data, now().UTC())
+
+	return doUpload(req)
+}
+
+// sign will add the AWS Signature Version 4 headers to the request.
+func (s *s3Storage) sign(req *http.Request, path string, payload []byte, t time.Time) {
+	amzDate := t.Format("20060102T150405Z")
+	day := t.Format("20060102")
+	payloadHash := fmt.Sprintf("%x", sha256.Sum256(payload))
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if s.SessionToken != "" {
+		headers = append(headers, "x-amz-security-token")
+		values["x-amz-security-token"] = s.SessionToken
+	}
+
+	var canonicalHeaders string
+	for _, h := range headers {
+		canonicalHeaders += h + ":" + strings.TrimSpace(values[h]) + "\n"
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{day, s.Region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		fmt.Sprintf("%x", sha256.Sum256([]byte(canonicalRequest))),
+	}, "\n")
+
+	signature := fmt.Sprintf("%x", hmacSHA256(awsSigningKey(s.SecretKey, day, s.Region, "s3"), stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.AccessKey, scope, signedHeaders, signature))
+}
+
+// awsSigningKey will derive the AWS Signature Version 4 signing key.
+func awsSigningKey(secret, day, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// hmacSHA256 will return the HMAC-SHA256 of data with the key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsURIEncode will percent-encode everything except the unreserved
+// characters, as required by AWS. Slashes are kept when keepSlash is set.
+func awsURIEncode(in string, keepSlash bool) string {
+	var out strings.Builder
+	for _, b := range []byte(in) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9', b == '-', b == '.', b == '_', b == '~':
+			out.WriteByte(b)
+		case b == '/' && keepSlash:
+			out.WriteByte(b)
+		default:
+			fmt.Fprintf(&out, "%%%02X", b)
+		}
+	}
+	return out.String()
+}
+
+// gcsStorage is a Storage for Google Cloud Storage, which uses the JSON API
+// media upload with an OAuth 2.0 access token.
+type gcsStorage struct {
+	// Endpoint is the base URL of the Cloud Storage API.
+	Endpoint string
+	// Bucket is the name of the bucket to upload to.
+	Bucket string
+	// Prefix is prepended to the object names.
+	Prefix string
+	// Token is the OAuth 2.0 access token.
+	Token string
+}
+
+// Upload will POST the data as an object in the bucket.
+func (s *gcsStorage) Upload(name string, data []byte) error {
+	if s.Token == "" {
+		return fmt.Errorf("GOOGLE_OAUTH_ACCESS_TOKEN must be set to upload to gs")
+	}
+
+	query := url.Values{}
+	query.Set("uploadType", "media")
+	query.Set("name", objectName(s.Prefix, name))
+	endpoint := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", strings.TrimSuffix(s.Endpoint, "/"), url.PathEscape(s.Bucket), query.Encode())
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	return doUpload(req)
+}
+
+// doUpload will send the upload request and check the response.
+func doUpload(req *http.Request) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("failed to upload %s: %d %s %s", req.URL.Path, resp.StatusCode, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package covidcheck
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStorage will upload to fake S3 and GCS services and check the requests
+// are formed as the services expect.
+func TestStorage(t *testing.T) {
+	t.Run("Deriving the AWS signing key", func(t *testing.T) {
+		// Example values from the AWS Signature Version 4 documentation.
+		key := awsSigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+		if fmt.Sprintf("%x", key) != "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9" {
+			t.Errorf("unexpected signing key %x", key)
+		}
+	})
+
+	t.Run("Parsing destinations", func(t *testing.T) {
+		s, err := NewStorage("s3://archive/act/daily", "http://localhost:9000")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s3, ok := s.(*s3Storage); !ok || s3.Bucket != "archive" || s3.Prefix != "act/daily" || s3.Endpoint != "http://localhost:9000" {
+			t.Fail()
+		}
+		if _, err := NewStorage("ftp://archive", ""); err == nil {
+			t.Fail()
+		}
+		if _, err := NewStorage("gs:///no-bucket", ""); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("Uploading to S3", func(t *testing.T) {
+		var method, path, auth, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, path, auth = r.Method, r.URL.Path, r.Header.Get("Authorization")
+			b, _ := ioutil.ReadAll(r.Body)
+			body = string(b)
+		}))
+		defer server.Close()
+
+		s := &s3Storage{
+			Endpoint:  server.URL,
+			Region:    "ap-southeast-2",
+			Bucket:    "archive",
+			Prefix:    "act",
+			AccessKey: "AKIDEXAMPLE",
+			SecretKey: "secret",
+			Now:       func() time.Time { return time.Date(2021, 10, 19, 8, 0, 0, 0, time.UTC) },
+		}
+		if err := s.Upload("snapshot.csv", []byte("data")); err != nil {
+			t.Fatal(err)
+		}
+		if method != http.MethodPut || path != "/archive/act/snapshot.csv" || body != "data" {
+			t.Errorf("unexpected request %s %s %s", method, path, body)
+		}
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20211019/ap-southeast-2/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+			t.Errorf("unexpected authorization %s", auth)
+		}
+	})
+
+	t.Run("Uploading to GCS", func(t *testing.T) {
+		var path, name, auth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path, name, auth = r.URL.Path, r.URL.Query().Get("name"), r.Header.Get("Authorization")
+		}))
+		defer server.Close()
+
+		s := &gcsStorage{Endpoint: server.URL, Bucket: "archive", Prefix: "act", Token: "token"}
+		if err := s.Upload("snapshot.json", []byte("[]")); err != nil {
+			t.Fatal(err)
+		}
+		if path != "/upload/storage/v1/b/archive/o" || name != "act/snapshot.json" || auth != "Bearer token" {
+			t.Errorf("unexpected request %s %s %s", path, name, auth)
+		}
+	})
+
+	t.Run("Reporting failed uploads", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		s := &gcsStorage{Endpoint: server.URL, Bucket: "archive", Token: "token"}
+		if err := s.Upload("snapshot.json", []byte("[]")); err == nil {
+			t.Fail()
+		}
+		if err := (&gcsStorage{Endpoint: server.URL, Bucket: "archive"}).Upload("snapshot.json", nil); err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package covidcheck
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// summaryFields are the fields Summarize can group entries by.
+var summaryFields = []string{"suburb", "contact", "date"}
+
+// summaryContacts are the contact levels counted in each SummaryGroup,
+// most severe first.
+var summaryContacts = []Contact{ContactClose, ContactCasual, ContactMonitor, ContactUnknown}
+
+// SummaryGroup is the number of entries sharing the value of a field.
+type SummaryGroup struct {
+	// Name is the value of the field, such as the suburb.
+	Name string `json:"name"`
+	// Total is the number of entries in the group.
+	Total int `json:"total"`
+	// Contacts are the number of entries of each contact level in the
+	// group, keyed by its lowercase name or "none".
+	Contacts map[string]int `json:"contacts"`
+}
+
+// contactKey will return the key of the contact level in the Contacts of a
+// SummaryGroup.
+func contactKey(c Contact) string {
+	if c == ContactUnknown {
+		return "none"
+	}
+	return strings.ToLower(string(c))
+}
+
+// capitalize will upper case the first letter of the name of a field.
+func capitalize(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Breakdown will describe the contact levels of the group, most severe
+// first, such as "14 close, 32 casual".
+func (g *SummaryGroup) Breakdown() string {
+	parts := []string{}
+	for _, c := range summaryContacts {
+		if n := g.Contacts[contactKey(c)]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, contactKey(c)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseSummary will return the field to group by given to -summary, written
+// as "by=suburb" or just "suburb".
+func ParseSummary(value string) (string, error) {
+	field := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "by=")))
+	for _, f := range summaryFields {
+		if field == f {
+			return field, nil
+		}
+	}
+	return "", fmt.Errorf("unknown summary field '%s', expected one of [%s]", value, strings.Join(summaryFields, "|"))
+}
+
+// Summarize will count the entries in groups sharing the value of the field,
+// which is suburb, contact or date. Dates are listed in order, contact
+// levels most severe first, and suburbs with the most entries first. Suburbs
+// are grouped ignoring case, and named as they were first found.
+func Summarize(entries Entries, by string) []SummaryGroup {
+	groups := []SummaryGroup{}
+	index := map[string]int{}
+	for _, e := range entries.Items {
+		var name string
+		switch by {
+		case "contact":
+			name = string(e.Contact)
+			if e.Contact == ContactUnknown {
+				name = "None"
+			}
+		case "date":
+			name = formatTime(e.Date, jsonDateFormat)
+		default:
+			name = e.Suburb
+		}
+		key := normalizeField(name)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, SummaryGroup{Name: name, Contacts: map[string]int{}})
+		}
+		groups[i].Total++
+		groups[i].Contacts[contactKey(e.Contact)]++
+	}
+
+	severity := map[string]int{}
+	for i, c := range summaryContacts {
+		severity[contactKey(c)] = i
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		switch by {
+		case "contact":
+			return severity[normalizeField(groups[i].Name)] < severity[normalizeField(groups[j].Name)]
+		case "date":
+			return groups[i].Name < groups[j].Name
+		}
+		if groups[i].Total != groups[j].Total {
+			return groups[i].Total > groups[j].Total
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// RenderSummary will render a table of the groups to the user, with the
+// contact levels of each unless they are grouped by contact.
+func RenderSummary(w io.Writer, groups []SummaryGroup, by string, markdown bool) {
+	if len(groups) == 0 {
+		fmt.Fprintln(w, "no results found")
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	header := []string{capitalize(by), "Total"}
+	if by != "contact" {
+		header = append(header, "Contacts")
+	}
+	table.SetHeader(header)
+	table.SetCaption(false, "COVID-19 Exposure Site Summary")
+	if markdown {
+		table.SetAutoFormatHeaders(false)
+		table.SetAutoWrapText(false)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+	}
+	for _, g := range groups {
+		row := []string{g.Name, strconv.Itoa(g.Total)}
+		if by != "contact" {
+			row = append(row, g.Breakdown())
+		}
+		if markdown {
+			for n := range row {
+				row[n] = markdownEscaper.Replace(row[n])
+			}
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
+
+// ExportSummary will write the groups to w as a JSON array, or as CSV with
+// the total and a column of each contact level.
+func ExportSummary(w io.Writer, groups []SummaryGroup, by string, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(groups)
+	case "csv":
+		writer := csv.NewWriter(w)
+		header := []string{capitalize(by), "Total"}
+		for _, c := range summaryContacts {
+			header = append(header, capitalize(contactKey(c)))
+		}
+		writer.Write(header)
+		for _, g := range groups {
+			row := []string{g.Name, strconv.Itoa(g.Total)}
+			for _, c := range summaryContacts {
+				row = append(row, strconv.Itoa(g.Contacts[contactKey(c)]))
+			}
+			writer.Write(row)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return fmt.Errorf("summaries can't be written as %s, expected one of [table|markdown|csv|json]", format)
+}
@@ -0,0 +1,74 @@
+package covidcheck
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSummarize will count static entries by each field, and check the
+// groups, their order and how they are written.
+func TestSummarize(t *testing.T) {
+	day := func(d int) *time.Time {
+		t := time.Date(2021, 10, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	entries := Entries{Items: []Entry{
+		{Suburb: "Belconnen", Contact: ContactCasual, Date: day(4)},
+		{Suburb: "Kaleen", Contact: ContactClose, Date: day(2)},
+		{Suburb: "BELCONNEN", Contact: ContactClose, Date: day(4)},
+		{Suburb: "Belconnen", Contact: ContactCasual, Date: day(3)},
+		{Suburb: "Dickson", Date: day(3)},
+	}}
+
+	t.Run("Parsing the field", func(t *testing.T) {
+		for _, value := range []string{"by=suburb", "Suburb", " by=suburb "} {
+			if by, err := ParseSummary(value); err != nil || by != "suburb" {
+				t.Errorf("expected suburb from %q, got %q and %v", value, by, err)
+			}
+		}
+		if _, err := ParseSummary("by=street"); err == nil {
+			t.Error("expected an error for an unknown field")
+		}
+	})
+
+	t.Run("Grouping", func(t *testing.T) {
+		for _, example := range []struct {
+			by   string
+			want string
+		}{
+			{"suburb", "Belconnen=3 (1 close, 2 casual); Dickson=1 (1 none); Kaleen=1 (1 close)"},
+			{"contact", "Close=2 (2 close); Casual=2 (2 casual); None=1 (1 none)"},
+			{"date", "2021-10-02=1 (1 close); 2021-10-03=2 (1 casual, 1 none); 2021-10-04=2 (1 close, 1 casual)"},
+		} {
+			got := []string{}
+			for _, g := range Summarize(entries, example.by) {
+				got = append(got, fmt.Sprintf("%s=%d (%s)", g.Name, g.Total, g.Breakdown()))
+			}
+			if strings.Join(got, "; ") != example.want {
+				t.Errorf("by %s: expected %s, got %s", example.by, example.want, strings.Join(got, "; "))
+			}
+		}
+	})
+
+	t.Run("Writing", func(t *testing.T) {
+		groups := Summarize(entries, "suburb")
+		var buf bytes.Buffer
+		if err := ExportSummary(&buf, groups, "suburb", "csv"); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), "Suburb,Total,Close,Casual,Monitor,None\nBelconnen,3,1,2,0,0\n") {
+			t.Errorf("unexpected csv %s", buf.String())
+		}
+		buf.Reset()
+		RenderSummary(&buf, groups, "suburb", false)
+		if !strings.Contains(buf.String(), "1 close, 2 casual") {
+			t.Errorf("expected the breakdown in the table, got %s", buf.String())
+		}
+		if err := ExportSummary(&buf, groups, "suburb", "html"); err == nil {
+			t.Error("expected an error for html")
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package covidcheck
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// userAgent is the User-Agent of requests which don't set their own, as
+// the one of Go is blocked by the CDN in front of some health sites.
+const userAgent = "covid-check (https://github.com/fubarhouse/covid-check)"
+
+// TransportOptions configure how every request covid-check sends is
+// connected, for networks behind a proxy or a TLS-inspecting middlebox,
+// and the headers it is sent with.
+type TransportOptions struct {
+	// Proxy is the URL of the proxy requests are sent through. Without it,
+	// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are
+	// honoured.
+	Proxy string
+	// InsecureSkipVerify disables the verification of the certificates of
+	// TLS connections, which should only be used to get past a middlebox
+	// whose certificate can't be trusted with CACert.
+	InsecureSkipVerify bool
+	// CACert is the path to a PEM file of certificate authorities trusted
+	// along with the system's.
+	CACert string
+	// UserAgent replaces the User-Agent of every request.
+	UserAgent string
+	// Header are the headers set on every request, replacing those of the
+	// request with the same names.
+	Header http.Header
+}
+
+// DefaultTransport is the http.RoundTripper of every request covid-check
+// sends, being downloads, notifications, uploads and geocoding, which can
+// be replaced with one made by NewTransport.
+var DefaultTransport http.RoundTripper = http.DefaultTransport
+
+// NewTransport will return a http.RoundTripper connecting with the options.
+func NewTransport(o TransportOptions) (http.RoundTripper, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if o.Proxy != "" {
+		proxy := o.Proxy
+		if !strings.Contains(proxy, "://") {
+			proxy = "http://" + proxy
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("could not parse proxy '%s'", o.Proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	if o.InsecureSkipVerify || o.CACert != "" {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	}
+	if o.CACert != "" {
+		data, err := ioutil.ReadFile(o.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("could not read ca certificates: %s", err.Error())
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in '%s'", o.CACert)
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+
+	if o.UserAgent == "" && len(o.Header) == 0 {
+		return t, nil
+	}
+	header := o.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if o.UserAgent != "" {
+		header.Set("User-Agent", o.UserAgent)
+	}
+	return &headerTransport{Transport: t, Header: header}, nil
+}
+
+// headerTransport is a http.RoundTripper which sets headers on every
+// request.
+type headerTransport struct {
+	// Transport sends the requests.
+	Transport http.RoundTripper
+	// Header are the headers set on every request.
+	Header http.Header
+}
+
+// RoundTrip will send the request with the headers.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.Header {
+		req.Header[key] = values
+	}
+	return t.Transport.RoundTrip(req)
+}
+
+// defaultTransport is a http.RoundTripper which sends requests with the
+// DefaultTransport of the time they are sent, so replacing it applies to
+// the clients made before.
+type defaultTransport struct{}
+
+// RoundTrip will send the request with the DefaultTransport, identifying
+// covid-check when the request has no User-Agent of its own.
+func (defaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", userAgent)
+	}
+	return DefaultTransport.RoundTrip(req)
+}
+
+// httpClient is the http.Client of the requests outside the fetch path,
+// such as notifications, uploads and geocoding.
+var httpClient = &http.Client{Transport: defaultTransport{}}
@@ -0,0 +1,124 @@
+package covidcheck
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// TestTransport will send requests through a proxy and to a TLS server
+// with a certificate which isn't trusted by the system.
+func TestTransport(t *testing.T) {
+	t.Run("Sending requests through a proxy", func(t *testing.T) {
+		var requested string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = r.URL.String()
+			w.Write([]byte(actTestCSV))
+		}))
+		defer proxy.Close()
+
+		transport, err := NewTransport(TransportOptions{Proxy: proxy.Listener.Addr().String()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func(d http.RoundTripper) { DefaultTransport = d }(DefaultTransport)
+		DefaultTransport = transport
+
+		resp, err := DefaultRetryPolicy.Get("http://covid-check.invalid/data.csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if requested != "http://covid-check.invalid/data.csv" {
+			t.Errorf("expected the request to be proxied, got %q", requested)
+		}
+		if _, err := NewTransport(TransportOptions{Proxy: "http://"}); err == nil {
+			t.Error("expected an error for a proxy without a host")
+		}
+	})
+
+	t.Run("Setting headers", func(t *testing.T) {
+		var header http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+		}))
+		defer server.Close()
+
+		resp, err := DefaultRetryPolicy.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if header.Get("User-Agent") != userAgent {
+			t.Errorf("expected the default user agent, got %q", header.Get("User-Agent"))
+		}
+
+		transport, err := NewTransport(TransportOptions{UserAgent: "Mozilla/5.0", Header: http.Header{"X-Api-Key": {"secret"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func(d http.RoundTripper) { DefaultTransport = d }(DefaultTransport)
+		DefaultTransport = transport
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("User-Agent", "overridden")
+		resp, err = httpClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if header.Get("User-Agent") != "Mozilla/5.0" || header.Get("X-Api-Key") != "secret" {
+			t.Errorf("expected the configured headers, got %v", header)
+		}
+		if req.Header.Get("User-Agent") != "overridden" {
+			t.Error("expected the request to be left unchanged")
+		}
+	})
+
+	t.Run("Trusting certificate authorities", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}))
+		server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+		server.StartTLS()
+		defer server.Close()
+
+		get := func(o TransportOptions) error {
+			transport, err := NewTransport(o)
+			if err != nil {
+				return err
+			}
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			return err
+		}
+		if err := get(TransportOptions{}); err == nil {
+			t.Error("expected an untrusted certificate to fail")
+		}
+		if err := get(TransportOptions{InsecureSkipVerify: true}); err != nil {
+			t.Errorf("expected verification to be skipped, got %v", err)
+		}
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "ca.pem")
+		ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+		if err := get(TransportOptions{CACert: path}); err != nil {
+			t.Errorf("expected the certificate to be trusted, got %v", err)
+		}
+
+		empty := filepath.Join(dir, "empty.pem")
+		ioutil.WriteFile(empty, []byte("not a certificate"), 0600)
+		if _, err := NewTransport(TransportOptions{CACert: empty}); err == nil {
+			t.Error("expected an error without certificates")
+		}
+		if _, err := NewTransport(TransportOptions{CACert: filepath.Join(dir, "missing.pem")}); err == nil {
+			t.Error("expected an error for a missing file")
+		}
+	})
+}
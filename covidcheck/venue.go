@@ -0,0 +1,147 @@
+package covidcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Venue is what OpenStreetMap knows about the venue of an exposure site.
+type Venue struct {
+	// Name is the name of the venue in OpenStreetMap.
+	Name string
+	// Tag is the main OpenStreetMap tag of the venue, such as
+	// "amenity=pharmacy".
+	Tag string
+	// Category is the venue category of the Tag, or empty when the Tag
+	// isn't a known category.
+	Category string
+	// Point is the location of the venue.
+	Point *Point
+	// Website is the website of the venue.
+	Website string
+	// Phone is the phone number of the venue.
+	Phone string
+	// OpeningHours are the opening hours of the venue, in the format of
+	// the OpenStreetMap opening_hours tag.
+	OpeningHours string
+}
+
+// osmCategories are the venue categories of OpenStreetMap tags, keyed by
+// "key=value" or by the key alone.
+var osmCategories = map[string]string{
+	"amenity=pharmacy":       "pharmacy",
+	"shop=chemist":           "pharmacy",
+	"healthcare=pharmacy":    "pharmacy",
+	"shop=supermarket":       "supermarket",
+	"shop=convenience":       "supermarket",
+	"shop=greengrocer":       "supermarket",
+	"amenity=hospital":       "healthcare",
+	"amenity=clinic":         "healthcare",
+	"amenity=doctors":        "healthcare",
+	"amenity=dentist":        "healthcare",
+	"healthcare":             "healthcare",
+	"amenity=school":         "education",
+	"amenity=college":        "education",
+	"amenity=university":     "education",
+	"amenity=kindergarten":   "education",
+	"amenity=childcare":      "education",
+	"leisure=fitness_centre": "gym",
+	"leisure=sports_centre":  "gym",
+	"amenity=bar":            "nightlife",
+	"amenity=pub":            "nightlife",
+	"amenity=nightclub":      "nightlife",
+	"amenity=restaurant":     "dining",
+	"amenity=cafe":           "dining",
+	"amenity=fast_food":      "dining",
+	"amenity=food_court":     "dining",
+	"shop=bakery":            "dining",
+	"amenity=bus_station":    "transport",
+	"public_transport":       "transport",
+	"railway":                "transport",
+	"aeroway":                "transport",
+}
+
+// osmCategory will return the venue category of an OpenStreetMap tag.
+func osmCategory(key, value string) string {
+	if category, ok := osmCategories[key+"="+value]; ok {
+		return category
+	}
+	return osmCategories[key]
+}
+
+// VenueLookup will find the venue of an exposure site.
+type VenueLookup interface {
+	// LookupVenue will return the Venue of the Entry, or nil when it
+	// can't be found.
+	LookupVenue(e *Entry) (*Venue, error)
+}
+
+// nominatimVenue is a single result of a Nominatim search with extra tags.
+type nominatimVenue struct {
+	Lat       string            `json:"lat"`
+	Lon       string            `json:"lon"`
+	Name      string            `json:"name"`
+	Class     string            `json:"class"`
+	Type      string            `json:"type"`
+	ExtraTags map[string]string `json:"extratags"`
+}
+
+// LookupVenue will search Nominatim for the venue by its name and address.
+// Results are kept in the Cache, including when the venue isn't found.
+func (g *NominatimGeocoder) LookupVenue(e *Entry) (*Venue, error) {
+	parts := []string{}
+	for _, field := range []string{e.ExposureLocation, e.Street, strings.TrimSpace(e.Suburb + " " + string(e.State))} {
+		if field = strings.TrimSpace(field); field != "" {
+			parts = append(parts, field)
+		}
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	address := strings.Join(append(parts, "Australia"), ", ")
+
+	key := "venue " + g.Endpoint + " " + normalizeField(address)
+	data, ok := g.Cache.Get(key)
+	if !ok {
+		query := url.Values{}
+		query.Set("q", address)
+		query.Set("extratags", "1")
+		var err error
+		if data, err = g.search(query); err != nil {
+			return nil, err
+		}
+		if err := g.Cache.Put(key, data); err != nil {
+			return nil, err
+		}
+	}
+
+	places := []nominatimVenue{}
+	if err := json.Unmarshal(data, &places); err != nil {
+		return nil, fmt.Errorf("could not parse venue result: %s", err.Error())
+	}
+	if len(places) == 0 {
+		return nil, nil
+	}
+
+	place := places[0]
+	venue := &Venue{
+		Name:         place.Name,
+		Tag:          place.Class + "=" + place.Type,
+		Category:     osmCategory(place.Class, place.Type),
+		Website:      place.ExtraTags["website"],
+		Phone:        place.ExtraTags["phone"],
+		OpeningHours: place.ExtraTags["opening_hours"],
+	}
+	if venue.Website == "" {
+		venue.Website = place.ExtraTags["contact:website"]
+	}
+	if venue.Phone == "" {
+		venue.Phone = place.ExtraTags["contact:phone"]
+	}
+	if p, ok := ParsePoint(place.Lat + "," + place.Lon); ok {
+		venue.Point = &p
+	}
+	return venue, nil
+}
@@ -0,0 +1,80 @@
+package covidcheck
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestVenue will enrich static entries from a fake Nominatim server and
+// check the venues are parsed, cached and used.
+func TestVenue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-venue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	requests := 0
+	var extratags string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		extratags = r.URL.Query().Get("extratags")
+		if strings.Contains(r.URL.Query().Get("q"), "Nowhere") {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"lat": "-35.2385", "lon": "149.0660", "name": "Benjamin Way Pharmacy", "class": "amenity", "type": "pharmacy",
+			"extratags": {"contact:website": "https://example.org", "phone": "+61 2 6100 0000", "opening_hours": "Mo-Su 08:00-20:00"}}]`))
+	}))
+	defer server.Close()
+
+	g := &NominatimGeocoder{Endpoint: server.URL, Cache: &Cache{Dir: dir, TTL: time.Hour}}
+	start, _ := time.Parse(time.Kitchen, "9:00PM")
+	end, _ := time.Parse(time.Kitchen, "9:30PM")
+	entries := Entries{Items: []Entry{
+		{ExposureLocation: "Benjamin Way Chemist Warehouse", Street: "Benjamin Way", Suburb: "Belconnen", State: "ACT", ArrivalTime: &start, DepartureTime: &end},
+		{ExposureLocation: "Nowhere", Suburb: "Belconnen", State: "ACT"},
+	}}
+
+	t.Run("Enriching entries", func(t *testing.T) {
+		if err := Enrich(g, &entries); err != nil {
+			t.Fatal(err)
+		}
+		v := entries.Items[0].Venue
+		if v == nil || v.Tag != "amenity=pharmacy" || v.Category != "pharmacy" || v.Website != "https://example.org" || v.Phone != "+61 2 6100 0000" || v.Point == nil {
+			t.Fatalf("unexpected venue %+v", v)
+		}
+		if entries.Items[1].Venue != nil || extratags != "1" {
+			t.Error("expected no venue for an unknown place")
+		}
+		Enrich(g, &entries)
+		if requests != 2 {
+			t.Errorf("expected the venues to be cached, got %d requests", requests)
+		}
+	})
+
+	t.Run("Using venues", func(t *testing.T) {
+		e := &entries.Items[0]
+		if e.VenueCategory() != "pharmacy" {
+			t.Errorf("expected the venue category, got %s", e.VenueCategory())
+		}
+		if e.PlausibleHours() {
+			t.Error("expected the venue opening hours to be used")
+		}
+		if p, err := GeocodeEntry(staticGeocoder{}, e); err != nil || p != *e.Venue.Point {
+			t.Errorf("expected the venue location, got %v %v", p, err)
+		}
+
+		var b bytes.Buffer
+		RenderEntry(&b, e)
+		if !strings.Contains(b.String(), "Website:  https://example.org") || !strings.Contains(b.String(), "Map:      -35.238500,149.066000") {
+			t.Errorf("expected the venue in the detail view %q", b.String())
+		}
+	})
+}
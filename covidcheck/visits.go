@@ -0,0 +1,258 @@
+package covidcheck
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Visit is a place someone has been, which is checked against the exposure
+// sites to see whether they were exposed.
+type Visit struct {
+	// Place is the name of the place visited.
+	Place string
+	// Suburb is the optional suburb of the place visited.
+	Suburb string
+	// Date is the day of the visit.
+	Date time.Time
+	// Start is the time of day the visit started, or nil when unknown.
+	Start *time.Time
+	// End is the time of day the visit ended, or nil when unknown.
+	End *time.Time
+}
+
+// visitRecord is the representation of a Visit in JSON visit files.
+type visitRecord struct {
+	Place  string `json:"place"`
+	Suburb string `json:"suburb"`
+	Date   string `json:"date"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+}
+
+// visitDateFormats are the accepted formats of visit dates.
+var visitDateFormats = []string{"02/01/2006", "2/1/2006", "2006-01-02"}
+
+// visitTimeFormats are the accepted formats of visit times.
+var visitTimeFormats = []string{time.Kitchen, "3PM", "15:04"}
+
+// LoadVisits will read a visit history from a JSON file, with an array of
+// objects with place, suburb, date, start and end keys, or from a CSV file
+// with a header row naming the same columns.
+func LoadVisits(path string) ([]Visit, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := []visitRecord{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.NewDecoder(f).Decode(&records); err != nil {
+			return nil, fmt.Errorf("could not parse visits: %s", err.Error())
+		}
+	} else if records, err = readVisitCSV(f); err != nil {
+		return nil, err
+	}
+
+	visits := []Visit{}
+	for i, r := range records {
+		v, err := r.visit()
+		if err != nil {
+			return nil, fmt.Errorf("visit %d: %s", i+1, err.Error())
+		}
+		visits = append(visits, v)
+	}
+	return visits, nil
+}
+
+// readVisitCSV will read the visit records from CSV, using the header row to
+// find the columns.
+func readVisitCSV(r io.Reader) ([]visitRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse visits: %s", err.Error())
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["place"]; !ok {
+		return nil, fmt.Errorf("could not parse visits: no place column in header")
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, fmt.Errorf("could not parse visits: no date column in header")
+	}
+	field := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	records := []visitRecord{}
+	for _, row := range rows[1:] {
+		records = append(records, visitRecord{
+			Place:  field(row, "place"),
+			Suburb: field(row, "suburb"),
+			Date:   field(row, "date"),
+			Start:  field(row, "start"),
+			End:    field(row, "end"),
+		})
+	}
+	return records, nil
+}
+
+// visit will parse the record into a Visit.
+func (r visitRecord) visit() (Visit, error) {
+	v := Visit{Place: strings.TrimSpace(r.Place), Suburb: strings.TrimSpace(r.Suburb)}
+	if v.Place == "" {
+		return v, fmt.Errorf("no place")
+	}
+
+	var err error
+	if v.Date, err = parseFirst(r.Date, visitDateFormats); err != nil {
+		return v, fmt.Errorf("could not parse date '%s'", r.Date)
+	}
+	for _, t := range []struct {
+		In  string
+		Out **time.Time
+	}{{r.Start, &v.Start}, {r.End, &v.End}} {
+		if strings.TrimSpace(t.In) == "" {
+			continue
+		}
+		parsed, err := parseFirst(strings.ToUpper(strings.ReplaceAll(t.In, " ", "")), visitTimeFormats)
+		if err != nil {
+			return v, fmt.Errorf("could not parse time '%s'", t.In)
+		}
+		*t.Out = &parsed
+	}
+	return v, nil
+}
+
+// parseFirst will parse the input with the first matching layout.
+func parseFirst(in string, layouts []string) (time.Time, error) {
+	var err error
+	for _, layout := range layouts {
+		var t time.Time
+		if t, err = time.Parse(layout, strings.TrimSpace(in)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// Exposure is a Visit which overlaps with an exposure site.
+type Exposure struct {
+	// Visit is the visit which overlaps.
+	Visit Visit
+	// Entry is the exposure site it overlaps with.
+	Entry Entry
+}
+
+// CheckVisits will return every pair of visit and exposure site which
+// overlap in both place and time window.
+func CheckVisits(visits []Visit, entries Entries) []Exposure {
+	exposures := []Exposure{}
+	for _, v := range visits {
+		for _, e := range entries.Items {
+			if samePlace(&v, &e) && overlaps(&v, &e) {
+				exposures = append(exposures, Exposure{Visit: v, Entry: e})
+			}
+		}
+	}
+	return exposures
+}
+
+// samePlace will check whether the visit was to the exposure site. The
+// place must be part of the location or street, or the other way around,
+// and the suburbs must match when the visit has one.
+func samePlace(v *Visit, e *Entry) bool {
+	place := normalizeField(v.Place)
+	matches := func(field string) bool {
+		field = normalizeField(field)
+		return field != "" && (strings.Contains(field, place) || strings.Contains(place, field))
+	}
+	if !matches(e.ExposureLocation) && !matches(e.Street) {
+		return false
+	}
+	return v.Suburb == "" || normalizeField(v.Suburb) == normalizeField(e.Suburb)
+}
+
+// overlaps will check whether the visit and exposure site were on the same
+// day, with overlapping time windows. Unknown times overlap the whole day,
+// and windows which end before they start run past midnight.
+func overlaps(v *Visit, e *Entry) bool {
+	if e.Date == nil || day(v.Date) != day(*e.Date) {
+		return false
+	}
+	vStart, vEnd := window(v.Start, v.End)
+	eStart, eEnd := window(e.ArrivalTime, e.DepartureTime)
+	return vStart < eEnd && eStart < vEnd
+}
+
+// window will return the minutes of the day a time window starts and ends.
+func window(start, end *time.Time) (int, int) {
+	minutes := func(t *time.Time) int { return t.Hour()*60 + t.Minute() }
+	unknown := func(t *time.Time) bool { return t == nil || t.IsZero() }
+	switch {
+	case unknown(start) && unknown(end):
+		return 0, 24 * 60
+	case unknown(start):
+		return 0, minutes(end)
+	case unknown(end):
+		return minutes(start), 24 * 60
+	}
+	s, f := minutes(start), minutes(end)
+	if f <= s {
+		f += 24 * 60
+	}
+	return s, f
+}
+
+// RenderExposures will render a table of the exposures to the user.
+func RenderExposures(w io.Writer, exposures []Exposure, width int) {
+	if len(exposures) == 0 {
+		fmt.Fprintln(w, "no exposures found")
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Visit", "Visit Date/Time", "Location", "Suburb", "Exposure Date/Time", "Contact"})
+	table.SetCaption(false, "Possible COVID-19 Exposures")
+	table.SetColWidth(width)
+	for _, x := range exposures {
+		visit := strings.TrimSpace(x.Visit.Place)
+		if x.Visit.Suburb != "" {
+			visit += ", " + x.Visit.Suburb
+		}
+		when := x.Visit.Date.Format(canonicalDateFormat)
+		if x.Visit.Start != nil || x.Visit.End != nil {
+			when += fmt.Sprintf(" %s - %s", formatTime(x.Visit.Start, time.Kitchen), formatTime(x.Visit.End, time.Kitchen))
+		}
+		table.Append([]string{
+			visit,
+			when,
+			x.Entry.ExposureLocation,
+			x.Entry.Suburb,
+			fmt.Sprintf("%s %s - %s", formatTime(x.Entry.Date, canonicalDateFormat), formatTime(x.Entry.ArrivalTime, time.Kitchen), formatTime(x.Entry.DepartureTime, time.Kitchen)),
+			string(x.Entry.Contact),
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,94 @@
+package covidcheck
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCheckVisits will load visit histories and check they are matched
+// against the exposure sites by both place and time window.
+func TestCheckVisits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-visits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	csvPath := filepath.Join(dir, "visits.csv")
+	ioutil.WriteFile(csvPath, []byte("Place,Suburb,Date,Start,End\nALDI,Belconnen,04/10/2021,7:15pm,8:00pm\nKaleen Plaza Pharmacy,Kaleen,01/09/2021,5:00PM,6:00PM\nWestfield Belconnen,,04/10/2021,,\n"), 0644)
+	jsonPath := filepath.Join(dir, "visits.json")
+	ioutil.WriteFile(jsonPath, []byte(`[{"place": "aldi belconnen", "date": "2021-10-04", "start": "19:20", "end": "19:25"}]`), 0644)
+
+	records := readCSV(actTestCSV)
+	entries := Entries{}
+	for _, record := range records {
+		entries.Add(fieldTranslate(record))
+	}
+
+	t.Run("Loading visits from csv", func(t *testing.T) {
+		visits, err := LoadVisits(csvPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(visits) != 3 || visits[0].Start.Format(time.Kitchen) != "7:15PM" || visits[2].Start != nil {
+			t.Errorf("unexpected visits %v", visits)
+		}
+
+		exposures := CheckVisits(visits, entries)
+		if len(exposures) != 2 {
+			t.Fatalf("expected 2 exposures, got %d", len(exposures))
+		}
+		if exposures[0].Entry.ExposureLocation != "ALDI Belconnen" || exposures[1].Visit.Place != "Westfield Belconnen" {
+			t.Errorf("unexpected exposures %v", exposures)
+		}
+	})
+
+	t.Run("Loading visits from json", func(t *testing.T) {
+		visits, err := LoadVisits(jsonPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exposures := CheckVisits(visits, entries); len(exposures) != 1 || exposures[0].Entry.Contact != "Casual" {
+			t.Errorf("unexpected exposures %v", exposures)
+		}
+	})
+
+	t.Run("Rejecting invalid visits", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid.csv")
+		ioutil.WriteFile(path, []byte("place,date\nALDI,yesterday\n"), 0644)
+		if _, err := LoadVisits(path); err == nil {
+			t.Fail()
+		}
+		ioutil.WriteFile(path, []byte("venue,date\nALDI,04/10/2021\n"), 0644)
+		if _, err := LoadVisits(path); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("Overlapping time windows", func(t *testing.T) {
+		late, _ := time.Parse(time.Kitchen, "11:00PM")
+		early, _ := time.Parse(time.Kitchen, "1:00AM")
+		midnight, _ := time.Parse(time.Kitchen, "11:30PM")
+		date := time.Date(2021, 10, 4, 0, 0, 0, 0, time.UTC)
+		e := Entry{Date: &date, ArrivalTime: &late, DepartureTime: &early}
+		if !overlaps(&Visit{Date: date, Start: &midnight}, &e) {
+			t.Fail()
+		}
+		if overlaps(&Visit{Date: date.AddDate(0, 0, 1), Start: &midnight}, &e) {
+			t.Fail()
+		}
+	})
+
+	t.Run("Rendering exposures", func(t *testing.T) {
+		var buf bytes.Buffer
+		RenderExposures(&buf, nil, 50)
+		if !strings.Contains(buf.String(), "no exposures found") {
+			t.Fail()
+		}
+	})
+}
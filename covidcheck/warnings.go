@@ -0,0 +1,71 @@
+package covidcheck
+
+import (
+	"encoding/csv"
+	"strings"
+	"unicode"
+)
+
+// ParseWarning is a row of a source which couldn't be fully parsed, so
+// it was dropped from the results or kept with missing fields.
+type ParseWarning struct {
+	// Source is the name of the source of the row, such as act.
+	Source string
+	// Row is the row as it was read, as a line of CSV, or the JSON of a
+	// record of JSON data.
+	Row string
+	// Reason is why the row couldn't be fully parsed.
+	Reason string
+	// Dropped is whether the row was left out of the results.
+	Dropped bool
+}
+
+// String will describe the warning and the row.
+func (w ParseWarning) String() string {
+	action := "kept"
+	if w.Dropped {
+		action = "dropped"
+	}
+	return strings.TrimPrefix(w.Source+": ", ": ") + action + " row with " + w.Reason + ": " + w.Row
+}
+
+// Dropped will return how many rows were left out of the entries.
+func (e *Entries) Dropped() int {
+	dropped := 0
+	for _, w := range e.Warnings {
+		if w.Dropped {
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// warn will add a ParseWarning about the row of the source to the entries.
+func (e *Entries) warn(source string, row []string, reason string, dropped bool) {
+	var line strings.Builder
+	writer := csv.NewWriter(&line)
+	writer.Write(row)
+	writer.Flush()
+	e.Warnings = append(e.Warnings, ParseWarning{Source: source, Row: strings.TrimSuffix(line.String(), "\n"), Reason: reason, Dropped: dropped})
+}
+
+// recordProblem will return why the Entry translated from an ACT record by
+// fieldTranslate isn't complete, and whether it is dropped because of it,
+// or an empty reason when it is.
+func recordProblem(e Entry) (string, bool) {
+	switch {
+	case e.Date == nil:
+		return "an unparseable date", true
+	case e.Suburb == "":
+		return "a missing suburb", true
+	case e.ExposureLocation == "" || e.Street == "" || strings.EqualFold(e.ExposureLocation, e.Street):
+		return "an ambiguous street and location", false
+	}
+	return "", false
+}
+
+// headerRow will check whether the record is a header row, which has no
+// digits where every data row has a date.
+func headerRow(record []string) bool {
+	return strings.IndexFunc(strings.Join(record, ""), unicode.IsDigit) == -1
+}
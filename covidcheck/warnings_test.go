@@ -0,0 +1,63 @@
+package covidcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseWarnings will parse rows which are missing fields, and check
+// each is reported with why it was dropped or kept, other than the header.
+func TestParseWarnings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-warnings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "act.csv")
+	data := "Event Id,Status,Exposure Location,Street,Suburb,State,Date,Arrival Time,Departure Time,Contact\n" +
+		actTestCSV +
+		`3,"New","Nowhere","Somewhere","","ACT","04/10/2021 - Monday",7:00pm,7:30pm,"Casual"` + "\n" +
+		`4,"New","Dickson Shops","Dickson Shops","Dickson","ACT","31/02/2021 - Monday",7:00pm,7:30pm,"Casual"` + "\n" +
+		`5,"New","Bus Route 3","Bus Route 3","Dickson","ACT","04/10/2021 - Monday",7:00pm,7:30pm,"Casual"` + "\n" +
+		"too,short\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := (&actSource{File: path}).Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries.Len() != 3 || entries.Dropped() != 3 {
+		t.Errorf("expected 3 entries and 3 dropped rows, got %d and %d", entries.Len(), entries.Dropped())
+	}
+	want := []string{
+		"act: dropped row with too few fields: too,short",
+		`act: dropped row with a missing suburb: 3,New,Nowhere,Somewhere,,ACT,04/10/2021 - Monday,7:00pm,7:30pm,Casual`,
+		`act: dropped row with an unparseable date: 4,New,Dickson Shops,Dickson Shops,Dickson,ACT,31/02/2021 - Monday,7:00pm,7:30pm,Casual`,
+		`act: kept row with an ambiguous street and location: 5,New,Bus Route 3,Bus Route 3,Dickson,ACT,04/10/2021 - Monday,7:00pm,7:30pm,Casual`,
+	}
+	got := []string{}
+	for _, w := range entries.Warnings {
+		got = append(got, w.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected warnings\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	t.Run("Reporting QLD rows without a location", func(t *testing.T) {
+		page := `<table><tr><th>Date</th><th>Place</th><th>Suburb</th></tr>
+			<tr><td>Monday 4 October 2021</td><td>Queen Street Mall</td><td>Brisbane City</td></tr>
+			<tr><td>Monday 4 October 2021</td><td></td><td>Brisbane City</td></tr></table>`
+		entries, err := parseQLD(strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries.Len() != 1 || entries.Dropped() != 1 || entries.Warnings[0].Reason != "a missing location" {
+			t.Errorf("expected 1 entry and a dropped row, got %d and %+v", entries.Len(), entries.Warnings)
+		}
+	})
+}
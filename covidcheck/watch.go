@@ -0,0 +1,144 @@
+package covidcheck
+
+import (
+	"time"
+)
+
+// Changes is the difference between two sets of Entries.
+type Changes struct {
+	// Added are the entries which were not previously present.
+	Added Entries
+	// Updated are the entries which were present, but have changed their
+	// status or contact level. The new version of the entry is kept.
+	Updated Entries
+	// Removed are the entries which are no longer present.
+	Removed Entries
+	// Initial is set on the first poll of a Watcher, where every current
+	// entry is Added.
+	Initial bool
+	// Reminders are the test reminders which are due, which are sent
+	// along with the changes but aren't counted by Len.
+	Reminders []Reminder
+}
+
+// Len will return the total number of changes.
+func (c *Changes) Len() int {
+	return c.Added.Len() + c.Updated.Len() + c.Removed.Len()
+}
+
+// Diff will compare the previous and current Entries, identifying entries
+// by their Hash, and return what has changed between them.
+func Diff(previous, current Entries) Changes {
+	changes := Changes{}
+
+	before := map[string]*Entry{}
+	for i := range previous.Items {
+		before[previous.Items[i].Hash()] = &previous.Items[i]
+	}
+	after := map[string]bool{}
+
+	for _, e := range current.Items {
+		hash := e.Hash()
+		after[hash] = true
+		old, ok := before[hash]
+		switch {
+		case !ok:
+			changes.Added.Add(e)
+		case old.Status != e.Status || old.Contact != e.Contact:
+			changes.Updated.Add(e)
+		}
+	}
+
+	for _, e := range previous.Items {
+		if !after[e.Hash()] {
+			changes.Removed.Add(e)
+		}
+	}
+
+	return changes
+}
+
+// Apply will return the entries which match the Filter.
+func (f *Filter) Apply(entries Entries) Entries {
+	c := &Client{RawResults: entries, FilteredResults: entries}
+	c.Query(f, QueryParams{})
+	return c.FilteredResults
+}
+
+// Watcher will poll a DataSource and report the changes which match its
+// Filter between each poll.
+type Watcher struct {
+	// Source is the DataSource which is polled.
+	Source DataSource
+	// Filter is applied to the changes before they are reported.
+	Filter Filter
+	// Interval is the time between each poll.
+	Interval time.Duration
+	// OnChange is called with the matching changes after each poll which
+	// found any.
+	OnChange func(Changes)
+	// OnError is called when a poll fails. The Watcher keeps polling.
+	OnError func(error)
+	// OnPoll is called after every poll, for periodic work such as
+	// sending reminders.
+	OnPoll func()
+
+	previous *Entries
+}
+
+// Poll will fetch the Source and return the matching changes since the last
+// poll. The first poll only records the current data, so it returns the
+// matching entries as Added.
+func (w *Watcher) Poll() (Changes, error) {
+	current, err := w.Source.Fetch()
+	if err != nil {
+		return Changes{}, err
+	}
+
+	var changes Changes
+	if w.previous == nil {
+		changes = Changes{Added: current, Initial: true}
+	} else {
+		changes = Diff(*w.previous, current)
+	}
+	w.previous = &current
+
+	changes.Added = w.Filter.Apply(changes.Added)
+	changes.Updated = w.Filter.Apply(changes.Updated)
+	changes.Removed = w.Filter.Apply(changes.Removed)
+	return changes, nil
+}
+
+// Current will return the entries fetched by the last successful poll.
+func (w *Watcher) Current() Entries {
+	if w.previous == nil {
+		return Entries{}
+	}
+	return *w.previous
+}
+
+// Run will poll the Source on the Interval until stop is closed, calling
+// OnChange and OnError as appropriate.
+func (w *Watcher) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		changes, err := w.Poll()
+		if err != nil && w.OnError != nil {
+			w.OnError(err)
+		}
+		if err == nil && changes.Len() > 0 && w.OnChange != nil {
+			w.OnChange(changes)
+		}
+		if w.OnPoll != nil {
+			w.OnPoll()
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package covidcheck
+
+import (
+	"testing"
+)
+
+// staticSource is a DataSource returning each of its Entries in turn.
+type staticSource struct {
+	polls []Entries
+}
+
+func (s *staticSource) Fetch() (Entries, error) {
+	e := s.polls[0]
+	if len(s.polls) > 1 {
+		s.polls = s.polls[1:]
+	}
+	return e, nil
+}
+
+// TestWatcher will poll a static source and check only the new and updated
+// entries matching the filter are reported.
+func TestWatcher(t *testing.T) {
+	woden := Entry{Status: "New", ExposureLocation: "Westfield Woden", Suburb: "Phillip", Contact: "Monitor"}
+	updated := woden
+	updated.Status = "Updated"
+	updated.Contact = "Casual"
+	coles := Entry{Status: "New", ExposureLocation: "Coles Phillip", Suburb: "Phillip", Contact: "Casual"}
+	belconnen := Entry{Status: "New", ExposureLocation: "Westfield Belconnen", Suburb: "Belconnen", Contact: "Monitor"}
+
+	t.Run("Diffing entries", func(t *testing.T) {
+		changes := Diff(Entries{Items: []Entry{woden, belconnen}}, Entries{Items: []Entry{updated, coles}})
+		if changes.Added.Len() != 1 || changes.Added.Items[0].ExposureLocation != coles.ExposureLocation {
+			t.Errorf("unexpected added entries %v", changes.Added.Items)
+		}
+		if changes.Updated.Len() != 1 || changes.Updated.Items[0].Contact != "Casual" {
+			t.Errorf("unexpected updated entries %v", changes.Updated.Items)
+		}
+		if changes.Removed.Len() != 1 || changes.Removed.Items[0].ExposureLocation != belconnen.ExposureLocation {
+			t.Errorf("unexpected removed entries %v", changes.Removed.Items)
+		}
+	})
+
+	t.Run("Polling a source", func(t *testing.T) {
+		w := &Watcher{
+			Source: &staticSource{polls: []Entries{
+				{Items: []Entry{woden, belconnen}},
+				{Items: []Entry{woden, belconnen}},
+				{Items: []Entry{updated, belconnen, coles}},
+			}},
+			Filter: Filter{Suburb: "phillip"},
+		}
+
+		changes, err := w.Poll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changes.Added.Len() != 1 || changes.Updated.Len() != 0 {
+			t.Errorf("expected the first poll to report the current matches, got %d", changes.Len())
+		}
+
+		changes, _ = w.Poll()
+		if changes.Len() != 0 {
+			t.Errorf("expected no changes, got %d", changes.Len())
+		}
+
+		changes, _ = w.Poll()
+		if changes.Added.Len() != 1 || changes.Updated.Len() != 1 {
+			t.Errorf("expected 1 new and 1 updated entry, got %d and %d", changes.Added.Len(), changes.Updated.Len())
+		}
+	})
+}
@@ -0,0 +1,303 @@
+package covidcheck
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsxMagic are the first bytes of an Excel workbook, which is a zip file.
+const xlsxMagic = "PK\x03\x04"
+
+// xlsxWorkbook is the structure of xl/workbook.xml, listing the sheets.
+type xlsxWorkbook struct {
+	Properties struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships is the structure of xl/_rels/workbook.xml.rels, giving
+// the file of each sheet.
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a string of the shared strings or an inline string, which is
+// either plain text or runs of rich text.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String will return the text of the string.
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+// xlsxStyles is the structure of xl/styles.xml, giving the number format of
+// each cell style.
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+// xlsxSheet is the structure of a worksheet.
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Style  int      `xml:"s,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// xlsxDateKind is how a number formatted by a cell style is read, being as
+// a number, a date, a time of day, or both.
+type xlsxDateKind int
+
+const (
+	xlsxNumber xlsxDateKind = iota
+	xlsxDate
+	xlsxTime
+	xlsxDateTime
+)
+
+// xlsxFormatLiterals match the parts of a number format which aren't date
+// or time codes, being quoted text, escaped characters and the sections in
+// brackets such as colours.
+var xlsxFormatLiterals = regexp.MustCompile(`"[^"]*"|\\.|\[[^\]]*\]`)
+
+// xlsxKind will return how numbers of a number format are read, by the ids
+// of the built in date and time formats, or the codes of a custom format.
+func xlsxKind(id int, code string) xlsxDateKind {
+	switch {
+	case id >= 14 && id <= 17, id >= 27 && id <= 31, id >= 34 && id <= 36, id >= 50 && id <= 58:
+		return xlsxDate
+	case id >= 18 && id <= 21, id == 32, id == 33, id >= 45 && id <= 47:
+		return xlsxTime
+	case id == 22:
+		return xlsxDateTime
+	case code == "":
+		return xlsxNumber
+	}
+	code = strings.ToLower(xlsxFormatLiterals.ReplaceAllString(code, ""))
+	date := strings.ContainsAny(code, "dy")
+	clock := strings.ContainsAny(code, "hs")
+	switch {
+	case date && clock:
+		return xlsxDateTime
+	case date || (strings.Contains(code, "m") && !clock):
+		return xlsxDate
+	case clock:
+		return xlsxTime
+	}
+	return xlsxNumber
+}
+
+// xlsxSerial will format a serial date, being the days since the epoch of
+// the workbook, as the kind of value it is.
+func xlsxSerial(value string, kind xlsxDateKind, date1904 bool) string {
+	days, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	// The epoch is the last day of 1899 as Excel wrongly counts 1900 as a
+	// leap year, which only changes the dates before March 1900.
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	t := epoch.Add(time.Duration(days * float64(24*time.Hour))).Round(time.Second)
+	switch kind {
+	case xlsxDate:
+		return t.Format("02/01/2006")
+	case xlsxTime:
+		return strings.ToLower(t.Format("3:04PM"))
+	}
+	return t.Format("02/01/2006") + " " + strings.ToLower(t.Format("3:04PM"))
+}
+
+// xlsxColumn will return the position of the column of a cell reference
+// such as "AB12", counting from 0, or -1 when it has no column.
+func xlsxColumn(ref string) int {
+	column := 0
+	n := 0
+	for ; n < len(ref); n++ {
+		c := ref[n] | 0x20
+		if c < 'a' || c > 'z' {
+			break
+		}
+		column = column*26 + int(c-'a'+1)
+	}
+	return column - 1
+}
+
+// readXLSXFile will unmarshal the XML of the named file of the workbook,
+// returning false when the workbook doesn't have it.
+func readXLSXFile(z *zip.Reader, name string, v interface{}) (bool, error) {
+	for _, f := range z.File {
+		if f.Name != name {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return true, err
+		}
+		defer r.Close()
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return true, err
+		}
+		return true, xml.Unmarshal(data, v)
+	}
+	return false, nil
+}
+
+// readXLSX will read the rows of the first sheet of an Excel workbook as
+// records, where shared and inline strings are read as their text, and the
+// numbers of cells formatted as dates or times as day/month/year dates and
+// times such as "7:00pm". Empty rows are left out.
+func readXLSX(data []byte) ([][]string, error) {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("could not read xlsx workbook: %s", err.Error())
+	}
+	fail := func(name string, err error) ([][]string, error) {
+		return nil, fmt.Errorf("could not read %s of xlsx workbook: %s", name, err.Error())
+	}
+
+	var workbook xlsxWorkbook
+	if _, err := readXLSXFile(z, "xl/workbook.xml", &workbook); err != nil {
+		return fail("workbook", err)
+	}
+	sheet := "xl/worksheets/sheet1.xml"
+	if len(workbook.Sheets) > 0 {
+		var rels xlsxRelationships
+		if _, err := readXLSXFile(z, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+			return fail("relationships", err)
+		}
+		for _, r := range rels.Relationships {
+			if r.ID == workbook.Sheets[0].ID {
+				sheet = path.Join("xl", r.Target)
+				if strings.HasPrefix(r.Target, "/") {
+					sheet = strings.TrimPrefix(r.Target, "/")
+				}
+			}
+		}
+	}
+
+	var shared struct {
+		Strings []xlsxText `xml:"si"`
+	}
+	if _, err := readXLSXFile(z, "xl/sharedStrings.xml", &shared); err != nil {
+		return fail("shared strings", err)
+	}
+	var styles xlsxStyles
+	if _, err := readXLSXFile(z, "xl/styles.xml", &styles); err != nil {
+		return fail("styles", err)
+	}
+	codes := map[int]string{}
+	for _, f := range styles.NumFmts {
+		codes[f.ID] = f.Code
+	}
+
+	var s xlsxSheet
+	if ok, err := readXLSXFile(z, sheet, &s); err != nil {
+		return fail("sheet", err)
+	} else if !ok {
+		return nil, fmt.Errorf("could not find the first sheet of xlsx workbook")
+	}
+
+	records := [][]string{}
+	for _, row := range s.Rows {
+		record := []string{}
+		for _, cell := range row.Cells {
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(value)
+				if err != nil || i < 0 || i >= len(shared.Strings) {
+					return nil, fmt.Errorf("could not read cell %s of xlsx workbook: no shared string %s", cell.Ref, value)
+				}
+				value = shared.Strings[i].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = strings.ToUpper(strconv.FormatBool(value == "1"))
+			case "d":
+				if t, err := time.Parse("2006-01-02", strings.SplitN(value, "T", 2)[0]); err == nil {
+					value = t.Format("02/01/2006")
+				}
+			case "", "n":
+				if cell.Style >= 0 && cell.Style < len(styles.CellXfs) {
+					id := styles.CellXfs[cell.Style].NumFmtID
+					if kind := xlsxKind(id, codes[id]); kind != xlsxNumber {
+						value = xlsxSerial(value, kind, workbook.Properties.Date1904)
+					}
+				}
+			}
+			column := xlsxColumn(cell.Ref)
+			if column < 0 {
+				column = len(record)
+			}
+			for len(record) < column {
+				record = append(record, "")
+			}
+			if column < len(record) {
+				record[column] = value
+			} else {
+				record = append(record, value)
+			}
+		}
+		if strings.Join(record, "") != "" {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// xlsxCSV will convert the first sheet of an Excel workbook into CSV data.
+func xlsxCSV(data []byte) ([]byte, error) {
+	records, err := readXLSX(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,131 @@
+package covidcheck
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testXLSX will return an Excel workbook whose first sheet is the sheet,
+// followed by another sheet which shouldn't be read.
+func testXLSX(t *testing.T, sheet string) []byte {
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Exposures" sheetId="1" r:id="rId2"/><sheet name="Notes" sheetId="2" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/notes.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/exposures.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Location</t></si><si><t>Suburb</t></si><si><t>Date</t></si><si><t>Arrival Time</t></si><si><t>Contact</t></si>
+<si><r><t>ALDI </t></r><r><rPr><b/></rPr><t>Belconnen</t></r></si><si><t>Belconnen</t></si><si><t>Casual</t></si>
+</sst>`,
+		"xl/styles.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="dd/mm/yyyy\ &quot;(day)&quot;"/></numFmts>
+<cellXfs count="3"><xf numFmtId="0"/><xf numFmtId="164"/><xf numFmtId="20"/></cellXfs>
+</styleSheet>`,
+		"xl/worksheets/exposures.xml": sheet,
+		"xl/worksheets/notes.xml":     `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Not this sheet</t></is></c></row></sheetData></worksheet>`,
+	}
+	for name, data := range files {
+		w, err := z.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testXLSXSheet is a sheet of exposure sites with a header row, shared,
+// rich and inline strings, and dates and times as serial numbers.
+var testXLSXSheet = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c></row>
+<row r="3"><c r="A3" t="s"><v>5</v></c><c r="B3" t="s"><v>6</v></c><c r="C3" s="1"><v>44473</v></c><c r="D3" s="2"><v>0.79166666666666663</v></c><c r="E3" t="s"><v>7</v></c></row>
+<row r="4"><c r="A4" t="inlineStr"><is><t>Kaleen Plaza</t></is></c><c r="B4" t="str"><v>Kaleen</v></c><c r="C4" t="d"><v>2021-09-01T00:00:00</v></c><c r="E4" t="inlineStr"><is><t>Close</t></is></c></row>
+</sheetData></worksheet>`
+
+// TestXLSX will read static Excel workbooks and check the rows of their
+// first sheet are read as records.
+func TestXLSX(t *testing.T) {
+	t.Run("Reading number formats", func(t *testing.T) {
+		examples := map[string]xlsxDateKind{
+			"General": xlsxNumber, "0.00": xlsxNumber, "dd/mm/yyyy": xlsxDate, "mmm-yy": xlsxDate,
+			"h:mm AM/PM": xlsxTime, "[h]:mm:ss": xlsxTime, "d/m/yy h:mm": xlsxDateTime, `0.0 "days"`: xlsxNumber, "[Red]0": xlsxNumber,
+		}
+		for code, kind := range examples {
+			if k := xlsxKind(164, code); k != kind {
+				t.Errorf("expected %q to be %d, got %d", code, kind, k)
+			}
+		}
+		if xlsxKind(14, "") != xlsxDate || xlsxKind(21, "") != xlsxTime || xlsxKind(22, "") != xlsxDateTime || xlsxKind(2, "") != xlsxNumber {
+			t.Error("unexpected kinds of built in formats")
+		}
+		if xlsxSerial("44473.8125", xlsxDateTime, false) != "04/10/2021 7:30pm" || xlsxSerial("0", xlsxDate, true) != "01/01/1904" {
+			t.Error("unexpected serial dates")
+		}
+		if xlsxColumn("A1") != 0 || xlsxColumn("AB12") != 27 || xlsxColumn("") != -1 {
+			t.Error("unexpected columns")
+		}
+	})
+
+	t.Run("Reading the first sheet", func(t *testing.T) {
+		records, err := readXLSX(testXLSX(t, testXLSXSheet))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 3 {
+			t.Fatalf("expected 3 records without the empty row, got %q", records)
+		}
+		if strings.Join(records[1], "|") != "ALDI Belconnen|Belconnen|04/10/2021|7:00pm|Casual" || strings.Join(records[2], "|") != "Kaleen Plaza|Kaleen|01/09/2021||Close" {
+			t.Errorf("unexpected records %q", records)
+		}
+
+		if _, err := readXLSX([]byte(xlsxMagic + "garbage")); err == nil {
+			t.Error("expected an error for a broken workbook")
+		}
+		broken := strings.Replace(testXLSXSheet, "<v>7</v>", "<v>70</v>", 1)
+		if _, err := readXLSX(testXLSX(t, broken)); err == nil || !strings.Contains(err.Error(), "E3") {
+			t.Errorf("expected an error for a missing shared string, got %v", err)
+		}
+	})
+
+	t.Run("Fetching workbooks", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "sites.xlsx")
+		if err := ioutil.WriteFile(path, testXLSX(t, testXLSXSheet), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, format := range []string{"", "xlsx"} {
+			src, _ := NewSource("act", SourceOptions{File: path, Format: format})
+			entries, err := src.Fetch()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entries.Len() != 2 || entries.Items[0].ExposureLocation != "ALDI Belconnen" || entries.Items[0].ArrivalTime.Format("3:04PM") != "7:00PM" || entries.Items[1].Contact != ContactClose {
+				t.Errorf("expected the entries of the sheet, got %+v", entries.Items)
+			}
+		}
+
+		csv := filepath.Join(dir, "sites.csv")
+		ioutil.WriteFile(csv, []byte(actTestCSV), 0644)
+		src, _ := NewSource("act", SourceOptions{File: csv, Format: "xlsx"})
+		if _, err := src.Fetch(); err == nil {
+			t.Errorf("expected an error reading a csv file as a workbook, got %v", err)
+		}
+	})
+}
@@ -1,9 +1,10 @@
-module github.com/fubarhouse/covid-check
+module github.com/fubarhouse/covid-check/v2
 
 go 1.17
 
 require (
 	github.com/PuerkitoBio/goquery v1.7.1
+	github.com/mattn/go-runewidth v0.0.9
 	github.com/olekukonko/tablewriter v0.0.5
 )
 
@@ -13,7 +14,6 @@ require (
 	github.com/antchfx/xpath v1.1.6 // indirect
 	github.com/gocarina/gocsv v0.0.0-20210516172204-ca9e8a8ddea8 // indirect
 	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
-	github.com/mattn/go-runewidth v0.0.9 // indirect
 	golang.org/x/net v0.0.0-20210614182718-04defd469f4e // indirect
 	golang.org/x/text v0.3.6 // indirect
 )
@@ -0,0 +1,421 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fubarhouse/covid-check/v2/covidcheck"
+)
+
+// flagGroup registers a group of related flags on a FlagSet.
+type flagGroup func(fs *flag.FlagSet)
+
+// command is a subcommand of the CLI, which only accepts the flags of its
+// groups.
+type command struct {
+	// Name is the name given before the flags.
+	Name string
+	// Args describes the arguments given after the flags.
+	Args string
+	// Description is a sentence describing what the command does.
+	Description string
+	// Formats are the accepted values of -output, the first being the
+	// default.
+	Formats []string
+	// Flags are the groups of flags the command accepts.
+	Flags []flagGroup
+}
+
+// commands are the subcommands of the CLI. Running covid-check without one
+// accepts every flag, as it did before it had subcommands.
+var commands = []command{
+	{
+		Name:        "fetch",
+		Description: "Download every exposure site of the source, unfiltered.",
+		Formats:     []string{"csv", "json"},
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags},
+	},
+	{
+		Name:        "query",
+		Description: "Display the exposure sites matching the filters.",
+		Formats:     []string{"table", "markdown", "csv", "json", "html", "geojson"},
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, modelFlags, filterFlags, enrichFlags, renderFlags, summaryFlags, reportFlags, publishFlags},
+	},
+	{
+		Name:        "watch",
+		Description: "Keep polling the source and display the new or updated exposure sites matching the filters.",
+		Formats:     []string{"table", "markdown", "csv", "json"},
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, modelFlags, filterFlags, renderFlags, reportFlags, pollFlags, feedFlags, notifyFlags, remindFlags, logFlags, debugFlags},
+	},
+	{
+		Name:        "serve",
+		Description: "Keep polling the source and serve the exposure sites matching the filters over HTTP.",
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, modelFlags, filterFlags, pollFlags, serveFlags, logFlags, debugFlags},
+	},
+	{
+		Name:        "diff",
+		Args:        "[old new]",
+		Description: "Report the exposure sites added, removed or updated between two files, or since the snapshot taken by -since.",
+		Formats:     []string{"table", "csv", "json"},
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, filterFlags, renderFlags},
+	},
+	{
+		Name:        "compare",
+		Args:        "old new",
+		Description: "Report the exposure sites added, removed or updated between two files or snapshots.",
+		Formats:     []string{"table", "csv", "json"},
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, filterFlags, renderFlags},
+	},
+	{
+		Name:        "simulate",
+		Description: "Replay the archived snapshots of the source through the filters, and report which alerts watch mode would have sent when.",
+		Formats:     []string{"table", "json"},
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, filterFlags, renderFlags, simulateFlags},
+	},
+	{
+		Name:        "stats",
+		Description: "Report the new exposure sites of each day and the busiest suburbs from the snapshot archive, with their trends.",
+		Formats:     []string{"table", "json", "sparkline"},
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, filterFlags, statsFlags},
+	},
+	{
+		Name:        "export",
+		Description: "Write the exposure sites matching the filters as csv, json, html or geojson.",
+		Formats:     []string{"json", "csv", "html", "geojson"},
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, modelFlags, filterFlags, enrichFlags, reportFlags, publishFlags},
+	},
+	{
+		Name:        "check",
+		Args:        "[name=]visits.csv|visits.json...",
+		Description: "Report the exposure sites overlapping with your visits.",
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, modelFlags, filterFlags, enrichFlags, renderFlags, notifyFlags, remindFlags},
+	},
+	{
+		Name:        "show",
+		Args:        "slug",
+		Description: "Display the exposure site with a slug.",
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags, modelFlags, enrichFlags},
+	},
+	{
+		Name:        "service",
+		Args:        "install|uninstall|start [watch flags]",
+		Description: "Install, uninstall or start a service running watch with the flags given, using systemd, launchd or a Windows scheduled task.",
+	},
+	{
+		Name:        "features",
+		Description: "List the features which can be given to -enable, and the deprecated flags.",
+		Formats:     []string{"table", "json"},
+		Flags:       []flagGroup{featureFlags},
+	},
+	{
+		Name:        "completion",
+		Args:        "bash|zsh|fish",
+		Description: "Write a completion script for bash, zsh or fish, which completes -suburb from the cached dataset.",
+	},
+	{
+		Name:        "snapshot",
+		Description: "Save the exposure sites of the source to the snapshot archive.",
+		Flags:       []flagGroup{sourceFlags, verbosityFlags, featureFlags},
+	},
+}
+
+// legacyCommand accepts every flag when no subcommand is given.
+var legacyCommand = command{
+	Formats: []string{"table", "markdown", "csv", "json", "html", "geojson"},
+	Flags:   []flagGroup{sourceFlags, verbosityFlags, featureFlags, modelFlags, filterFlags, enrichFlags, renderFlags, summaryFlags, reportFlags, publishFlags, pollFlags, feedFlags, notifyFlags, remindFlags, logFlags, debugFlags, legacyFlags},
+}
+
+// findCommand will return the command with a name.
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// parseCommand will split the subcommand from the arguments, returning the
+// legacy command when none is given.
+func parseCommand(args []string) (command, []string) {
+	if len(args) > 0 {
+		if c, ok := findCommand(args[0]); ok {
+			return c, args[1:]
+		}
+	}
+	return legacyCommand, args
+}
+
+// setDefaults will set every flag variable to its default, so the flags of
+// the groups a command doesn't accept still have their defaults.
+func setDefaults() {
+	fs := flag.NewFlagSet("defaults", flag.ContinueOnError)
+	for _, group := range legacyCommand.Flags {
+		group(fs)
+	}
+	fs.StringVar(&output, "output", "table", "")
+}
+
+// newFlagSet will return a FlagSet with the flags of the command, and help
+// text describing them.
+func (c command) newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(strings.TrimSpace("covid-check "+c.Name), flag.ExitOnError)
+	for _, group := range c.Flags {
+		group(fs)
+	}
+	if len(c.Formats) > 0 {
+		fs.StringVar(&output, "output", c.Formats[0], fmt.Sprintf("output format [%s]", strings.Join(c.Formats, "|")))
+		fs.StringVar(&outFile, "o", "", "path of a file to write the results to instead of stdout, in the format of its extension unless -output is given")
+	}
+	fs.Usage = func() {
+		w := fs.Output()
+		switch {
+		case c.Name == "":
+			usage(w)
+			fmt.Fprintln(w, "\nflags:")
+		case len(c.Flags) == 0:
+			fmt.Fprintf(w, "usage: covid-check %s %s\n\n%s\n", c.Name, c.Args, c.Description)
+			return
+		default:
+			fmt.Fprintf(w, "usage: %s\n\n%s\n\nflags:\n", strings.TrimSpace("covid-check "+c.Name+" [flags] "+c.Args), c.Description)
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// outputExtensions are the formats of -o files, by extension.
+var outputExtensions = map[string]string{
+	".csv":     "csv",
+	".geojson": "geojson",
+	".html":    "html",
+	".json":    "json",
+	".md":      "markdown",
+	".txt":     "table",
+}
+
+// validate will check the -output given is a format of the command. When
+// -o is given without -output, the format is chosen by its extension.
+func (c command) validate(fs *flag.FlagSet) error {
+	if len(c.Formats) == 0 {
+		return nil
+	}
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		explicit = explicit || f.Name == "output"
+	})
+	if format, ok := outputExtensions[strings.ToLower(filepath.Ext(outFile))]; ok && !explicit && !rawOutput {
+		output = format
+	}
+	for _, format := range c.Formats {
+		if output == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format '%s', expected one of [%s]", output, strings.Join(c.Formats, "|"))
+}
+
+// usage will write the commands of the CLI to w.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: covid-check [command] [flags]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.Name, c.Description)
+	}
+	fmt.Fprintln(w, "\nRun covid-check help command to see the flags of a command. Without a")
+	fmt.Fprintln(w, "command, the exposure sites matching the flags are displayed.")
+}
+
+// help will print the help text of the command given to the help
+// subcommand, or of the CLI.
+func help(args []string) {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return
+	}
+	c, ok := findCommand(args[0])
+	if !ok {
+		fail(fmt.Errorf("unknown command '%s'", args[0]))
+		return
+	}
+	fs := c.newFlagSet()
+	fs.SetOutput(os.Stdout)
+	fs.Usage()
+}
+
+func sourceFlags(fs *flag.FlagSet) {
+	fs.StringVar(&source, "source", "act", fmt.Sprintf("data source to fetch exposure sites from, or a comma separated list of them [%s]", strings.Join(covidcheck.SourceNames(), "|")))
+	fs.IntVar(&parallel, "parallel", 4, "number of sources fetched at once when several are given")
+	fs.StringVar(&endpoint, "endpoint", "", "endpoint of the source's covid exposure list (defaults to the official endpoint for -source)")
+	fs.StringVar(&csvURL, "csv-url", "", "url of the act csv file, downloaded instead of finding it on the page of -endpoint")
+	fs.StringVar(&file, "file", "", "relative path to csv file, or xlsx workbook, to use instead of new data, or - to read it from stdin.")
+	fs.IntVar(&retries, "retries", 3, "number of times to retry failed downloads")
+	fs.DurationVar(&retryWait, "retry-wait", time.Second, "base time to wait before retrying a download, doubling with each attempt")
+	fs.StringVar(&proxy, "proxy", "", "url of the proxy to send requests through (defaults to $HTTPS_PROXY or $HTTP_PROXY)")
+	fs.BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "don't verify the tls certificates of endpoints, such as behind a tls-inspecting proxy")
+	fs.StringVar(&caCert, "ca-cert", "", "path to a pem file of certificate authorities to trust along with the system's")
+	fs.StringVar(&userAgent, "user-agent", "", "user agent of every request (defaults to covid-check's)")
+	fs.Var(&Headers, "header", "header of every request formatted as 'Key: Value', can be given more than once")
+	fs.StringVar(&dataFormat, "format", "csv", "format of the act data [csv|json|xlsx], where json is downloaded from -endpoint and read with the paths of -mapping, and xlsx workbooks are also read as csv")
+	fs.StringVar(&aliases, "aliases", "", "path to a json file of the names of places keyed by their variants, added to the built in aliases (defaults to $XDG_CONFIG_HOME/covid-check/aliases.json)")
+	fs.StringVar(&mapping, "mapping", "", "path to a json file declaring which column of the act csv file each field is read from")
+	fs.BoolVar(&showSkipped, "show-skipped", false, "list the rows of the source which couldn't be fully parsed on stderr")
+	fs.BoolVar(&strict, "strict", false, "exit with an error if any rows of the source were dropped while parsing")
+	fs.IntVar(&maxRows, "max-rows", 0, "most exposure sites kept from each source, giving partial results past it (0 for no limit)")
+	fs.Int64Var(&maxFetchBytes, "max-fetch-bytes", 0, "most bytes read from each download, giving partial results past it where the format allows (0 for no limit)")
+	fs.DurationVar(&maxRuntime, "max-runtime", 0, "how long each fetch may take before its downloads are abandoned (0 for no limit)")
+	fs.BoolVar(&cache, "cache", false, "cache downloaded data under $XDG_CACHE_HOME/covid-check and reuse it while fresh")
+	fs.DurationVar(&cacheTTL, "cache-ttl", 15*time.Minute, "how long cached data is considered fresh for")
+	fs.StringVar(&snapshotDir, "snapshot-dir", "", "directory of the snapshot archive (defaults to $XDG_DATA_HOME/covid-check/snapshots)")
+	fs.StringVar(&asOf, "as-of", "", "query the latest snapshot taken by a date instead of the live data")
+	fs.BoolVar(&canonical, "canonical", false, "sort exported rows by hash and use fixed formats for diff-friendly snapshots")
+}
+
+func modelFlags(fs *flag.FlagSet) {
+	fs.StringVar(&riskModel, "risk-model", "", "path to a json file configuring the risk score")
+	fs.StringVar(&openingHours, "opening-hours", "", "path to a json file of the usual opening hours of venues")
+	fs.StringVar(&guidance, "guidance", "", "path to a json file of the official advice for each contact level")
+}
+
+func filterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&contact, "contact", "", "contact rating [|close|casual|monitor]")
+	fs.StringVar(&location, "location", "", "location")
+	fs.StringVar(&suburb, "suburb", "", "suburb")
+	fs.StringVar(&status, "status", "", "status rating [|new|archived|updated]")
+	fs.StringVar(&street, "street", "", "street")
+	fs.StringVar(&state, "state", "", "state")
+	fs.Var(&ExcludeStatus, "exclude-status", "status rating to filter out, can be given more than once")
+	fs.Var(&ExcludeLocation, "exclude-location", "location to filter out, can be given more than once")
+	fs.Var(&ExcludeSuburb, "exclude-suburb", "suburb to filter out, can be given more than once")
+	fs.Var(&ExcludeContact, "exclude-contact", "contact rating to filter out, can be given more than once")
+	fs.StringVar(&trust, "trust", "", "trust label [|official|community|imported]")
+	fs.StringVar(&near, "near", "", "only show results near a location (\"lat,lon\" or an address)")
+	fs.StringVar(&radius, "radius", "5km", "maximum distance of results from -near (eg 5km or 500m)")
+	fs.StringVar(&geocoderEndpoint, "geocoder-endpoint", covidcheck.NominatimEndpointURL, "endpoint of the nominatim api used to geocode addresses")
+	fs.StringVar(&udate, "date", "", "date (formatted as DD/MM/YYYY, or such as Sep 28, yesterday or last tuesday)")
+	fs.StringVar(&since, "since", "", "only show results on or after a date, or within an age such as 3d or 2w")
+	fs.BoolVar(&lastWeek, "last-week", false, "only show results from the last week (deprecated, use -since 1w)")
+	fs.StringVar(&atime, "start-time", "", "start time")
+	fs.StringVar(&dtime, "end-time", "", "end time")
+	fs.StringVar(&expression, "filter", "", "filter expression, eg 'suburb == \"Belconnen\" && contact != \"Monitor\" && date >= 2021-10-01'")
+	fs.BoolVar(&regex, "regex", false, "match filters as case-insensitive regular expressions instead of substrings (or prefix one filter with re:)")
+	fs.BoolVar(&fuzzy, "fuzzy", false, "match the location, street and suburb filters by the similarity of their words, so typos still match")
+	fs.Float64Var(&fuzzyThreshold, "fuzzy-threshold", covidcheck.DefaultFuzzyThreshold, "similarity between 0 and 1 of the words matched by -fuzzy")
+	fs.Var(&PositiveQueries, "query", "arbitrary query of terms which must all match, scoped to a field like suburb:kaleen")
+	fs.Var(&NegativeQueries, "query-not", "arbitrary query reversed (not), leaving out anything matching all of its terms")
+	fs.Var(&PositiveQueries, "q", "arbitrary query of terms which must all match, scoped to a field like suburb:kaleen")
+	fs.Var(&NegativeQueries, "qn", "arbitrary query reversed (not), leaving out anything matching all of its terms")
+	fs.Float64Var(&minRisk, "min-risk", 0, "minimum risk score between 0 and 1 of the results")
+}
+
+func enrichFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&enrich, "enrich", false, "look up the venues of the results in openstreetmap for their category, location, website and phone number")
+	fs.StringVar(&pipeline, "pipeline", "", "comma separated post-processing stages to run over the results, with an optional concurrency [venue|geocode|classify] (eg venue,geocode:2,classify)")
+	if fs.Lookup("geocoder-endpoint") == nil {
+		fs.StringVar(&geocoderEndpoint, "geocoder-endpoint", covidcheck.NominatimEndpointURL, "endpoint of the nominatim api used to geocode addresses")
+	}
+}
+
+func renderFlags(fs *flag.FlagSet) {
+	fs.IntVar(&width, "width", 50, "width of table columns")
+	fs.IntVar(&limit, "limit", 0, "Limit how many results are shown.")
+	fs.BoolVar(&rawOutput, "raw", false, "display output as csv (deprecated, use -output csv)")
+	fs.StringVar(&sortBy, "sort", "", "comma separated fields to sort by, prefix with - to reverse (eg date,suburb,start-time)")
+	fs.StringVar(&columnList, "columns", "", "comma separated columns of the table, csv and json output, in order [status|location|street|suburb|state|date|start|end|contact|trust|risk|slug|hash]")
+	fs.BoolVar(&slug, "slug", false, "display a column of shareable slugs for use with the show subcommand")
+	fs.BoolVar(&emoji, "emoji", false, "display contact level and status glyphs in the table and notifications")
+	fs.BoolVar(&truncate, "truncate", false, "truncate values wider than -width with an ellipsis instead of wrapping them")
+	fs.BoolVar(&footnotes, "footnotes", false, "list the full values of truncated values below the table")
+	fs.BoolVar(&risk, "risk", false, "display a risk score column")
+	fs.BoolVar(&noPager, "no-pager", false, "write tables longer than the terminal straight to stdout instead of through $PAGER")
+	fs.BoolVar(&quiet, "quiet", false, "display nothing but errors, exiting 0 when exposure sites are found, 1 when none are and 2 on errors")
+	fs.BoolVar(&noColor, "no-color", false, "display tables without colouring the contact levels, as does setting NO_COLOR")
+	fs.BoolVar(&hours, "hours", false, "display a column flagging exposure windows outside the usual opening hours of the venue")
+}
+
+func summaryFlags(fs *flag.FlagSet) {
+	fs.StringVar(&summary, "summary", "", "display counts of the results grouped by a field instead of every result [by=suburb|by=contact|by=date]")
+}
+
+func reportFlags(fs *flag.FlagSet) {
+	fs.StringVar(&reportFile, "report-file", "", "path to write a json report of the run to")
+}
+
+func publishFlags(fs *flag.FlagSet) {
+	fs.StringVar(&archiveRepo, "archive-repo", "", "path to a git repository to commit canonical snapshots into")
+	fs.StringVar(&archiveFile, "archive-file", "snapshot.csv", "snapshot path inside the archive repository (.csv or .json)")
+	fs.BoolVar(&archivePush, "archive-push", false, "push the archive repository after committing a snapshot")
+	fs.StringVar(&upload, "upload", "", "upload a snapshot of the results to storage (s3://bucket/prefix or gs://bucket/prefix)")
+	fs.StringVar(&uploadEndpoint, "upload-endpoint", "", "endpoint of an S3-compatible storage service")
+}
+
+func pollFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&watchInterval, "watch-interval", 5*time.Minute, "time between each poll in watch mode")
+}
+
+func feedFlags(fs *flag.FlagSet) {
+	fs.StringVar(&feedFile, "feed-file", "", "path of a json lines file to append every change found in watch mode to")
+	fs.IntVar(&feedMaxSize, "feed-max-size", 0, "size in megabytes after which the feed file is rotated (0 for no limit)")
+	fs.DurationVar(&feedMaxAge, "feed-max-age", 0, "age of the first change in the feed file after which it is rotated (0 for no limit)")
+}
+
+func notifyFlags(fs *flag.FlagSet) {
+	fs.Var(&Notify, "notify", "send new and updated results in watch mode, or exposures found by check, prefixed with name: for one person [webhook=URL|slack=URL|discord=URL|desktop]")
+	fs.StringVar(&matrixHomeserver, "matrix-homeserver", "", "url of a matrix homeserver to send new and updated results to in watch mode")
+	fs.StringVar(&matrixRoom, "matrix-room", "", "id of the matrix room to send results to")
+	fs.StringVar(&discordWebhook, "discord-webhook", "", "url of a discord webhook to post new and updated results to in watch mode")
+	fs.IntVar(&notifyRetries, "notify-retries", covidcheck.DefaultNotificationRetry.Retries, "number of times a failed notification is retried on later polls in watch mode")
+	if fs.Lookup("emoji") == nil {
+		fs.BoolVar(&emoji, "emoji", false, "display contact level and status glyphs in notifications")
+	}
+}
+
+func remindFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&remind, "remind", false, "schedule test reminders for the exposures found by check, which watch mode sends when due")
+	fs.StringVar(&remindDays, "remind-days", "", "days after an exposure to be reminded to get tested for each contact level (default \"close=0,5,12;casual=0,5\")")
+	fs.StringVar(&stateDir, "state-dir", "", "directory to store scheduled reminders and queued notifications in (defaults to $XDG_STATE_HOME/covid-check)")
+}
+
+func logFlags(fs *flag.FlagSet) {
+	fs.StringVar(&logFile, "log-file", "", "path of a file to write log messages to instead of stderr")
+	fs.IntVar(&logMaxSize, "log-max-size", 10, "size in megabytes after which the log file is rotated (0 for no limit)")
+	fs.DurationVar(&logMaxAge, "log-max-age", 0, "age after which the log file is rotated (0 for no limit)")
+	fs.IntVar(&logKeep, "log-keep", 5, "number of rotated log files to keep (0 to keep all)")
+}
+
+func verbosityFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&verbose, "v", false, "log what was fetched and queried, and how long it took")
+	fs.BoolVar(&veryVerbose, "vv", false, "log every request, skipped row and unrecognized field as well")
+	fs.StringVar(&logFormat, "log-format", "text", "format of log messages [text|json]")
+}
+
+func debugFlags(fs *flag.FlagSet) {
+	fs.StringVar(&debugListen, "debug-listen", "", "private address to serve the internal status and runtime profiles on in watch mode (eg localhost:6060)")
+}
+
+func serveFlags(fs *flag.FlagSet) {
+	fs.StringVar(&listen, "listen", ":8080", "address to serve the exposure sites on")
+}
+
+func statsFlags(fs *flag.FlagSet) {
+	fs.StringVar(&window, "window", "1w", "days the stats command counts new exposure sites over, compared with the days before them (eg 14d or 2w)")
+	fs.IntVar(&top, "top", 10, "number of the busiest suburbs the stats command lists (0 for every suburb)")
+}
+
+func featureFlags(fs *flag.FlagSet) {
+	fs.Var(&enable, "enable", "comma separated features to enable ahead of their release, as listed by the features command (eg experimental.simulate)")
+}
+
+func simulateFlags(fs *flag.FlagSet) {
+	fs.StringVar(&history, "history", "", "directory of the snapshots to replay, instead of -snapshot-dir")
+}
+
+func legacyFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&watch, "watch", false, "keep polling the source and print only new or updated results")
+	fs.BoolVar(&generate, "generate", false, "download a mirror of a source dataset to stdout")
+}
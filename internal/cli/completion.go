@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fubarhouse/covid-check/v2/covidcheck"
+)
+
+// completionUsage is the usage of the completion subcommand.
+const completionUsage = "usage: covid-check completion bash|zsh|fish, or covid-check completion suburbs [fetch flags]"
+
+// suburbFlags are the flags completed with the suburbs of the cached
+// dataset.
+var suburbFlags = []string{"suburb", "exclude-suburb"}
+
+// datasetFlags are the flags of the command line being completed which are
+// passed on to find the dataset the suburbs are read from.
+var datasetFlags = []string{"source", "endpoint", "csv-url", "file"}
+
+// completionCommand will write the completion script of a shell, or the
+// suburbs of the cached dataset used by the scripts to complete -suburb.
+func completionCommand(args []string) {
+	if len(args) == 0 {
+		fail(errors.New(completionUsage))
+		return
+	}
+
+	var err error
+	switch args[0] {
+	case "bash":
+		err = writeBashCompletion(os.Stdout)
+	case "zsh":
+		err = writeZshCompletion(os.Stdout)
+	case "fish":
+		err = writeFishCompletion(os.Stdout)
+	case "suburbs":
+		err = writeSuburbs(os.Stdout, args[1:])
+	default:
+		fail(errors.New(completionUsage))
+		return
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// writeSuburbs will write the suburbs of the dataset of the source given by
+// the fetch flags, one per line. Only a cached or local copy is read, however
+// old it is, so completion never waits on the network.
+func writeSuburbs(w io.Writer, args []string) error {
+	c, _ := findCommand("fetch")
+	fs := c.newFlagSet()
+	fs.Init("covid-check completion suburbs", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cache, err := covidcheck.NewCache(time.Duration(math.MaxInt64))
+	if err != nil {
+		return err
+	}
+	covidcheck.DefaultLogger = &covidcheck.Logger{Writer: ioutil.Discard}
+	covidcheck.DefaultRetryPolicy.Retries = 0
+	// Downloads are abandoned as soon as they start, leaving the cache.
+	covidcheck.DefaultLimits.Deadline = time.Now()
+	src, err := covidcheck.NewSource(source, covidcheck.SourceOptions{Endpoint: endpoint, CSVURL: csvURL, File: file, Cache: cache})
+	if err != nil {
+		return err
+	}
+	entries, _ := src.Fetch()
+
+	seen := map[string]bool{}
+	suburbs := []string{}
+	for _, e := range entries.Items {
+		key := strings.ToLower(e.Suburb)
+		if e.Suburb != "" && !seen[key] {
+			seen[key] = true
+			suburbs = append(suburbs, e.Suburb)
+		}
+	}
+	sort.Strings(suburbs)
+	for _, suburb := range suburbs {
+		fmt.Fprintln(w, suburb)
+	}
+	return nil
+}
+
+// completionFlag is a flag of a command, as completed by the scripts.
+type completionFlag struct {
+	Name  string
+	Usage string
+	// Value is whether the flag takes a value, rather than being a switch.
+	Value bool
+}
+
+// commandFlags will return the flags of the command, sorted by name.
+func commandFlags(c command) []completionFlag {
+	flags := []completionFlag{}
+	c.newFlagSet().VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{Name: f.Name, Usage: f.Usage, Value: !ok || !b.IsBoolFlag()})
+	})
+	return flags
+}
+
+// completedCommands are the commands of the CLI along with help, which
+// isn't listed as a command.
+func completedCommands() []command {
+	return append(append([]command{}, commands...), command{
+		Name:        "help",
+		Description: "Display the flags of a command.",
+	})
+}
+
+// flagNames will return the names of the flags with a leading dash,
+// separated by spaces.
+func flagNames(flags []completionFlag) string {
+	names := []string{}
+	for _, f := range flags {
+		names = append(names, "-"+f.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+// shellQuote will quote the value for a POSIX shell, zsh or fish.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// writeBashCompletion will write a bash completion script, which completes
+// the commands, the flags of each command, and the suburbs of -suburb.
+func writeBashCompletion(w io.Writer) error {
+	names := []string{}
+	for _, c := range completedCommands() {
+		names = append(names, c.Name)
+	}
+
+	var b strings.Builder
+	b.WriteString("# bash completion for covid-check, loaded with:\n")
+	b.WriteString("#   source <(covid-check completion bash)\n")
+	b.WriteString("_covid_check() {\n")
+	b.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\" i src=()\n")
+	b.WriteString("\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(&b, "\t\tcase \"${COMP_WORDS[i]}\" in -%s) src+=(\"${COMP_WORDS[i]}\" \"${COMP_WORDS[i+1]}\") ;; esac\n", strings.Join(datasetFlags, "|-"))
+	b.WriteString("\tdone\n")
+	b.WriteString("\tcase \"$prev\" in\n")
+	fmt.Fprintf(&b, "\t-%s)\n", strings.Join(suburbFlags, "|-"))
+	b.WriteString("\t\tlocal IFS=$'\\n'\n")
+	b.WriteString("\t\tCOMPREPLY=($(compgen -W \"$(covid-check completion suburbs \"${src[@]}\" 2>/dev/null)\" -- \"$cur\"))\n")
+	b.WriteString("\t\treturn\n")
+	b.WriteString("\t\t;;\n")
+	b.WriteString("\tesac\n")
+	b.WriteString("\tif [ \"$COMP_CWORD\" -eq 1 ] && [[ \"$cur\" != -* ]]; then\n")
+	fmt.Fprintf(&b, "\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(names, " ")))
+	b.WriteString("\t\treturn\n")
+	b.WriteString("\tfi\n")
+	b.WriteString("\tlocal flags\n")
+	b.WriteString("\tcase \"${COMP_WORDS[1]}\" in\n")
+	for _, c := range commands {
+		if len(c.Flags) == 0 && len(c.Formats) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s) flags=%s ;;\n", c.Name, shellQuote(flagNames(commandFlags(c))))
+	}
+	fmt.Fprintf(&b, "\t*) flags=%s ;;\n", shellQuote(flagNames(commandFlags(legacyCommand))))
+	b.WriteString("\tesac\n")
+	b.WriteString("\tCOMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -o default -F _covid_check covid-check\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeZshCompletion will write a zsh completion script, which completes
+// the commands and flags with their descriptions, and the suburbs of
+// -suburb.
+func writeZshCompletion(w io.Writer) error {
+	describe := func(name, description string) string {
+		return shellQuote(strings.ReplaceAll(name, ":", `\:`) + ":" + strings.ReplaceAll(description, "\n", " "))
+	}
+
+	var b strings.Builder
+	b.WriteString("#compdef covid-check\n")
+	b.WriteString("# zsh completion for covid-check, loaded with:\n")
+	b.WriteString("#   source <(covid-check completion zsh)\n")
+	b.WriteString("_covid_check() {\n")
+	b.WriteString("\tlocal -a items src\n")
+	b.WriteString("\tlocal i\n")
+	b.WriteString("\tfor ((i = 2; i < CURRENT; i++)); do\n")
+	fmt.Fprintf(&b, "\t\tcase \"${words[i]}\" in -%s) src+=(\"${words[i]}\" \"${words[i+1]}\") ;; esac\n", strings.Join(datasetFlags, "|-"))
+	b.WriteString("\tdone\n")
+	b.WriteString("\tcase \"${words[CURRENT-1]}\" in\n")
+	fmt.Fprintf(&b, "\t-%s)\n", strings.Join(suburbFlags, "|-"))
+	b.WriteString("\t\titems=(\"${(@f)$(covid-check completion suburbs \"${src[@]}\" 2>/dev/null)}\")\n")
+	b.WriteString("\t\tcompadd -a items\n")
+	b.WriteString("\t\treturn\n")
+	b.WriteString("\t\t;;\n")
+	b.WriteString("\tesac\n")
+	b.WriteString("\tif (( CURRENT == 2 )) && [[ \"${words[CURRENT]}\" != -* ]]; then\n")
+	b.WriteString("\t\titems=(\n")
+	for _, c := range completedCommands() {
+		fmt.Fprintf(&b, "\t\t\t%s\n", describe(c.Name, c.Description))
+	}
+	b.WriteString("\t\t)\n")
+	b.WriteString("\t\t_describe command items\n")
+	b.WriteString("\t\treturn\n")
+	b.WriteString("\tfi\n")
+	writeFlags := func(flags []completionFlag) {
+		b.WriteString("\t\titems=(\n")
+		for _, f := range flags {
+			fmt.Fprintf(&b, "\t\t\t%s\n", describe("-"+f.Name, f.Usage))
+		}
+		b.WriteString("\t\t)\n")
+		b.WriteString("\t\t;;\n")
+	}
+	b.WriteString("\tcase \"${words[2]}\" in\n")
+	for _, c := range commands {
+		if len(c.Flags) == 0 && len(c.Formats) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s)\n", c.Name)
+		writeFlags(commandFlags(c))
+	}
+	b.WriteString("\t*)\n")
+	writeFlags(commandFlags(legacyCommand))
+	b.WriteString("\tesac\n")
+	b.WriteString("\t_describe flag items\n")
+	b.WriteString("}\n")
+	b.WriteString("compdef _covid_check covid-check\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFishCompletion will write a fish completion script, which completes
+// the commands and flags with their descriptions, and the suburbs of
+// -suburb.
+func writeFishCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# fish completion for covid-check, loaded with:\n")
+	b.WriteString("#   covid-check completion fish | source\n")
+	b.WriteString("complete -c covid-check -f\n")
+	for _, c := range completedCommands() {
+		fmt.Fprintf(&b, "complete -c covid-check -n __fish_use_subcommand -a %s -d %s\n", c.Name, shellQuote(c.Description))
+	}
+	writeFlags := func(condition string, flags []completionFlag) {
+		for _, f := range flags {
+			args := ""
+			if f.Value {
+				args = " -r"
+			}
+			for _, s := range suburbFlags {
+				if f.Name == s {
+					args = " -x -a '(covid-check completion suburbs 2>/dev/null)'"
+				}
+			}
+			fmt.Fprintf(&b, "complete -c covid-check -n %s -o %s%s -d %s\n", shellQuote(condition), f.Name, args, shellQuote(strings.ReplaceAll(f.Usage, "\n", " ")))
+		}
+	}
+	for _, c := range commands {
+		if len(c.Flags) == 0 && len(c.Formats) == 0 {
+			continue
+		}
+		writeFlags("__fish_seen_subcommand_from "+c.Name, commandFlags(c))
+	}
+	writeFlags("__fish_use_subcommand", commandFlags(legacyCommand))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fubarhouse/covid-check/v2/covidcheck"
+)
+
+// feature is a subsystem which is rolled out in stages, so it can be tried
+// with -enable before it is relied on.
+type feature struct {
+	// Name is the name given to -enable, prefixed by its stage.
+	Name string `json:"name"`
+	// Stage is "experimental" while the feature is off unless enabled, or
+	// "stable" once it is on for everyone and -enable has no effect.
+	Stage string `json:"stage"`
+	// Description is a sentence describing what the feature does.
+	Description string `json:"description"`
+}
+
+// features are the features of the CLI. A stable feature is kept in the
+// list, so scripts enabling it keep working.
+var features = []feature{
+	{
+		Name:        "experimental.simulate",
+		Stage:       "experimental",
+		Description: "The simulate command, which replays archived snapshots through the filters.",
+	},
+}
+
+// deprecation is a flag which is still accepted, but warned about as it
+// will be removed.
+type deprecation struct {
+	// Flag is the name of the flag, without the dash.
+	Flag string `json:"flag"`
+	// Replacement is what to use instead.
+	Replacement string `json:"replacement"`
+}
+
+// deprecations are the deprecated flags of the CLI.
+var deprecations = []deprecation{
+	{Flag: "raw", Replacement: "-output csv"},
+	{Flag: "last-week", Replacement: "-since 1w"},
+}
+
+// featureNames are the features given to -enable.
+type featureNames []string
+
+func (i *featureNames) String() string {
+	return strings.Join(*i, ",")
+}
+
+// Set will add the comma separated features, which must be known.
+func (i *featureNames) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := findFeature(name); !ok {
+			names := []string{}
+			for _, f := range features {
+				names = append(names, f.Name)
+			}
+			return fmt.Errorf("unknown feature '%s', expected one of [%s]", name, strings.Join(names, "|"))
+		}
+		*i = append(*i, name)
+	}
+	return nil
+}
+
+// findFeature will return the feature with a name.
+func findFeature(name string) (feature, bool) {
+	for _, f := range features {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return feature{}, false
+}
+
+// enabled will check whether the feature is on, because it was given to
+// -enable or is stable.
+func enabled(name string) bool {
+	if f, ok := findFeature(name); ok && f.Stage == "stable" {
+		return true
+	}
+	for _, e := range enable {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// warnDeprecated will log a warning for each deprecated flag which was set
+// on the command line or from the environment.
+func warnDeprecated(fs *flag.FlagSet) {
+	fs.Visit(func(f *flag.Flag) {
+		for _, d := range deprecations {
+			if d.Flag == f.Name {
+				covidcheck.DefaultLogger.Warn("-"+d.Flag+" is deprecated and will be removed", "replacement", d.Replacement)
+			}
+		}
+	})
+}
+
+// writeFeatures will write the features and deprecated flags to w as a
+// table, or as a JSON object of "features" and "deprecations" arrays.
+func writeFeatures(w io.Writer, format string) error {
+	type listed struct {
+		feature
+		Enabled bool `json:"enabled"`
+	}
+	list := []listed{}
+	for _, f := range features {
+		list = append(list, listed{feature: f, Enabled: enabled(f.Name)})
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Features     []listed      `json:"features"`
+			Deprecations []deprecation `json:"deprecations"`
+		}{list, deprecations})
+	}
+	fmt.Fprintln(w, "features:")
+	for _, f := range list {
+		state := "disabled"
+		if f.Enabled {
+			state = "enabled"
+		}
+		fmt.Fprintf(w, "  %-22s %-8s %s\n", f.Name, state, f.Description)
+	}
+	fmt.Fprintln(w, "\ndeprecated flags:")
+	for _, d := range deprecations {
+		fmt.Fprintf(w, "  %-22s use %s instead\n", "-"+d.Flag, d.Replacement)
+	}
+	return nil
+}
@@ -0,0 +1,1644 @@
+// Package cli is the command line interface of covid-check, which is run by
+// the main packages of the repository.
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fubarhouse/covid-check/v2/covidcheck"
+)
+
+// The exit codes of the CLI, so scripts can test whether exposure sites
+// were found by the exit status alone.
+const (
+	// exitMatches is the exit code when exposure sites were found, or
+	// the command succeeded.
+	exitMatches = 0
+	// exitNoMatches is the exit code when no exposure sites were found.
+	exitNoMatches = 1
+	// exitError is the exit code when fetching or parsing the data, or the
+	// flags, failed.
+	exitError = 2
+)
+
+var (
+	// rawOutput tells the app to print the raw csv data instead of
+	// rendering a table.
+	rawOutput = true
+	// endpoint is the URL/endpoint which contains the exposure sites.
+	// notably, this is only compatible with the Canberra website.
+	// other examples using a similar convention would need to be
+	// identified to be compatible.
+	endpoint string
+	// generate will fetch a known copy of the original source dataset.
+	// this will be useful for running the application after covid is
+	// no longer a thing, because this data won't exist forever.
+	generate bool
+	// contact is the filter for the contact fiels, notably it will
+	// only return results when set to "casual", "monitor" or "close".
+	// There is no way to filter for nil value as the filter checks
+	// if the result contains the input.
+	contact string
+	// file provides a csv input which circumvents downloading a new
+	// set of data from the endpoint, or - to read it from stdin.
+	file string
+	// limit will limit the results to a specific number.
+	limit int
+	// location is the filter for the location field, and will check
+	// if the result contains the input information.
+	location string
+	// suburb is the filter for the suburb field, and will check
+	// if the result contains the input information.
+	suburb string
+	// status is the filter for the status field, and will check
+	// if the result contains the input information. Results will
+	// only be returned for "archived", "updated" or "new".
+	status string
+	// street is the filter for the street field, and will check
+	// if the result contains the input information.
+	street string
+	// state is the filter for the state field, and will check
+	// if the result contains the input information. Results will
+	// only be returned if the value is not set, or set to "ACT".
+	state string
+	// udate is the filter for the time field, and will check
+	// if the result contains the input information. You will need
+	// to set this to something in the format of 01/02/2006 for
+	// this to actually work - failing this the application will panic
+	// unless it is not set.
+	udate string
+	// since is the start of a date range filter, either a date or an
+	// age such as "3d" or "2w".
+	since string
+	// lastWeek filters the results to the last week, as "-since 1w".
+	lastWeek bool
+	// expression is a filter expression combining conditions on the fields
+	// with boolean logic.
+	expression string
+	// regex will match the filters as regular expressions, instead of
+	// substrings.
+	regex bool
+	// fuzzy will match the location, street and suburb filters by the
+	// similarity of their words, so typos still match.
+	fuzzy bool
+	// fuzzyThreshold is the similarity between 0 and 1 of the words
+	// matched by fuzzy.
+	fuzzyThreshold float64
+	// atime is the filter for the arrival time field, and will check
+	// if the result contains the input information. This is treated
+	// strictly as a string at this time.
+	atime string
+	// dtime is the filter for the finish time field, and will check
+	// if the result contains the input information. This is treated
+	//	// strictly as a string at this time.
+	dtime string
+	// trust is the filter for the trust label, and will check if the
+	// result contains the input information. Results will only be
+	// returned for "official", "community" or "imported".
+	trust string
+	// near is the centre of a distance filter, either "lat,lon" or an
+	// address which is geocoded.
+	near string
+	// radius is the maximum distance of the results from near.
+	radius string
+	// geocoderEndpoint is the URL of the Nominatim API used to find the
+	// locations of addresses.
+	geocoderEndpoint string
+	// enrich will look up the venues of the results in OpenStreetMap, to
+	// attach their category, location, website and phone number.
+	enrich bool
+	// pipeline is a comma separated list of post-processing stages to run
+	// over the results, each optionally with a concurrency such as
+	// "venue:2".
+	pipeline string
+	// slug will add a column of shareable slugs to the table.
+	slug bool
+	// emoji will add a column of contact level and status glyphs to the
+	// table and notifications.
+	emoji bool
+	// truncate will cut table values wider than the columns with an
+	// ellipsis, instead of wrapping them.
+	truncate bool
+	// footnotes will list the full values of truncated table values
+	// below the table.
+	footnotes bool
+	// retries is the number of times failed downloads are retried.
+	retries int
+	// retryWait is the base time to wait before retrying a download,
+	// which doubles with each attempt.
+	retryWait time.Duration
+	// proxy is the URL of the proxy every request is sent through, instead
+	// of the one of the HTTP_PROXY and HTTPS_PROXY environment variables.
+	proxy string
+	// insecureSkipVerify disables the verification of TLS certificates.
+	insecureSkipVerify bool
+	// caCert is the path to a PEM file of extra certificate authorities to
+	// trust.
+	caCert string
+	// csvURL is the URL of the ACT CSV file, which is downloaded without
+	// scraping the web page of the endpoint for it.
+	csvURL string
+	// dataFormat is the format of the ACT data, being csv, json or xlsx.
+	dataFormat string
+	// userAgent replaces the User-Agent of every request.
+	userAgent string
+	// mapping is the path of a json file mapping the columns of the ACT
+	// CSV file onto the fields of exposure sites.
+	mapping string
+	// showSkipped lists the rows of the source which couldn't be fully
+	// parsed on stderr.
+	showSkipped bool
+	// strict fails when any rows of the source were dropped while parsing.
+	strict bool
+	// maxRows is the most entries kept from each source, or 0 for no limit.
+	maxRows int
+	// maxFetchBytes is the most bytes read from each download, or 0 for no
+	// limit.
+	maxFetchBytes int64
+	// maxRuntime is how long each fetch may take before its downloads are
+	// abandoned, or 0 for no limit.
+	maxRuntime time.Duration
+	// snapshotDir is the directory of the snapshot archive, which
+	// defaults to $XDG_DATA_HOME/covid-check/snapshots.
+	snapshotDir string
+	// asOf is a date to query the latest snapshot taken by, instead of
+	// the live data.
+	asOf string
+	// reportFile is the path to write a structured JSON report of the
+	// run to.
+	reportFile string
+	// width is the width of the table column, should you be so inclined.
+	width int
+	// sortBy is a comma separated list of fields to sort the results by,
+	// in order of priority. Prefixing a field with "-" reverses it.
+	sortBy string
+	// output is the format to display the results in, either "table",
+	// "markdown", "csv", "json", "html" or "geojson".
+	output string
+	// source is the jurisdiction to fetch exposure sites for, either
+	// "act" or "nsw".
+	source string
+	// canonical will sort exported rows by their hash and use fixed
+	// formats, so snapshots of unchanged data are byte-identical.
+	canonical bool
+	// archiveRepo is the path to a git repository which canonical
+	// snapshots of the results are committed to.
+	archiveRepo string
+	// archiveFile is the path of the snapshot inside archiveRepo.
+	archiveFile string
+	// archivePush will push archiveRepo after committing a snapshot.
+	archivePush bool
+	// upload is a s3:// or gs:// destination which snapshots of the
+	// results are uploaded to.
+	upload string
+	// uploadEndpoint overrides the storage service endpoint, for use with
+	// S3-compatible services.
+	uploadEndpoint string
+	// cache will store the downloaded data on disk and reuse it while
+	// it is fresh, instead of downloading it on every run.
+	cache bool
+	// cacheTTL is how long cached data is considered fresh for.
+	cacheTTL time.Duration
+	// risk will add a risk score column to the table.
+	risk bool
+	// minRisk is the minimum risk score of the results.
+	minRisk float64
+	// riskModel is the path to a JSON file configuring the risk score.
+	riskModel string
+	// guidance is the path to a JSON file of the official advice for each
+	// contact level.
+	guidance string
+	// aliases is the path to a JSON file of the names of places keyed by
+	// their variants, which are treated as the same place.
+	aliases string
+	// hours will add a column flagging exposure windows outside the usual
+	// opening hours of the venue.
+	hours bool
+	// openingHours is the path to a JSON file of the usual opening hours
+	// of venues.
+	openingHours string
+	// watch will keep polling the source and print only the new or
+	// updated results, instead of exiting after the first run.
+	watch bool
+	// watchInterval is the time between each poll in watch mode.
+	watchInterval time.Duration
+	// logFile is the path of a file the watch and serve subcommands log
+	// to instead of stderr.
+	logFile string
+	// logMaxSize is the size in megabytes after which the log file is
+	// rotated.
+	logMaxSize int
+	// logMaxAge is the age after which the log file is rotated.
+	logMaxAge time.Duration
+	// logKeep is the number of rotated log files which are kept.
+	logKeep int
+	// logger is where log messages are written.
+	logger io.Writer = os.Stderr
+	// verbose logs what was fetched and queried, and how long it took.
+	verbose bool
+	// veryVerbose logs every request, skipped row and unrecognized field
+	// as well.
+	veryVerbose bool
+	// logFormat is the format of log messages, text or json.
+	logFormat string
+	// outFile is the path of a file to write the results to instead of
+	// stdout.
+	outFile string
+	// stdout is where the results are written, which is buffered until
+	// they are written to outFile.
+	stdout io.Writer = os.Stdout
+	// debugListen is the address to serve the internal status and runtime
+	// profiles on in watch mode.
+	debugListen string
+	// debug records the internal status served on debugListen.
+	debug *covidcheck.Debug
+	// parallel is the number of sources fetched at once when several are
+	// given.
+	parallel int
+	// listen is the address the serve subcommand serves the exposure
+	// sites on.
+	listen string
+	// summary is the field the results are counted by instead of being
+	// displayed, such as "by=suburb".
+	summary string
+	// window is how many days the stats subcommand counts new exposure
+	// sites over, as an age such as "2w".
+	window string
+	// top is how many of the busiest suburbs the stats subcommand lists.
+	top int
+	// noPager will write long tables straight to stdout, instead of
+	// through $PAGER.
+	noPager bool
+	// columnList are the comma separated columns of the table, CSV and
+	// JSON output, parsed into selectedColumns.
+	columnList string
+	// selectedColumns are the names of the columns given to -columns.
+	selectedColumns []string
+	// quiet will display nothing but errors, leaving the exit code to tell
+	// whether exposure sites were found.
+	quiet bool
+	// exitCode is the code the CLI exits with once its output is written.
+	exitCode = exitMatches
+	// noColor will leave the contact levels of tables uncoloured, as will
+	// setting NO_COLOR.
+	noColor bool
+	// color is whether tables are coloured, which they are when written
+	// to a terminal.
+	color bool
+	// history is the directory of snapshots the simulate subcommand
+	// replays, instead of snapshotDir.
+	history string
+	// feedFile is the path of a JSON lines file which every change found
+	// in watch mode is appended to.
+	feedFile string
+	// feedMaxSize is the size in megabytes after which the feed file is
+	// rotated.
+	feedMaxSize int
+	// feedMaxAge is the age of the first change in the feed file after
+	// which it is rotated.
+	feedMaxAge time.Duration
+	// matrixHomeserver is the URL of a Matrix homeserver which watch mode
+	// sends new and updated results to.
+	matrixHomeserver string
+	// matrixRoom is the ID of the Matrix room to send results to.
+	matrixRoom string
+	// discordWebhook is the URL of a Discord webhook which watch mode
+	// posts new and updated results to.
+	discordWebhook string
+	// remind will schedule test reminders for the exposures found by the
+	// check subcommand, which watch mode sends when they are due.
+	remind bool
+	// remindDays are the days after an exposure to be reminded to get
+	// tested on for each contact level, such as "close=0,5,12;casual=0,5".
+	remindDays string
+	// stateDir is the directory scheduled reminders are stored in, which
+	// defaults to $XDG_STATE_HOME/covid-check.
+	stateDir string
+	// notifyRetries is the number of times a failed notification is
+	// retried in watch mode before it is given up on.
+	notifyRetries int
+	// Slice input for input queries.
+
+	// NegativeQueries include queries to filter out.
+	NegativeQueries negativeQueries
+	// PositiveQueries include queries to filter in.
+	PositiveQueries positiveQueries
+	// Notify include the notifiers to send results to in watch mode.
+	Notify notifySpecs
+	// ExcludeStatus include the statuses to filter out.
+	ExcludeStatus excludeValues
+	// ExcludeLocation include the locations to filter out.
+	ExcludeLocation excludeValues
+	// ExcludeSuburb include the suburbs to filter out.
+	ExcludeSuburb excludeValues
+	// ExcludeContact include the contact ratings to filter out.
+	ExcludeContact excludeValues
+	// enable are the features enabled ahead of their release.
+	enable featureNames
+	// Headers include the headers set on every request.
+	Headers headerValues
+)
+
+type (
+	// negativeQueries are the input queries to exclude.
+	negativeQueries []string
+	// positiveQueries are the input queries to include.
+	positiveQueries []string
+	// notifySpecs are the notifiers to send results to in watch mode.
+	notifySpecs []string
+	// excludeValues are the input values of a field to exclude.
+	excludeValues []string
+)
+
+// headerValues are the headers given to -header, formatted as "Key: Value".
+type headerValues []string
+
+func (i *headerValues) String() string {
+	return strings.Join(*i, ", ")
+}
+
+// Set will add the header, which must have a name.
+func (i *headerValues) Set(value string) error {
+	n := strings.Index(value, ":")
+	if n < 0 || !validHeaderName(strings.TrimSpace(value[:n])) {
+		return fmt.Errorf("headers are formatted as 'Key: Value': could not parse '%s'", value)
+	}
+	*i = append(*i, value)
+	return nil
+}
+
+// validHeaderName will check the name of a header is a token, without
+// spaces or separators.
+func validHeaderName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t\"(),/:;<=>?@[\\]{}")
+}
+
+// header will return the headers given to -header.
+func (i headerValues) header() http.Header {
+	header := http.Header{}
+	for _, value := range i {
+		n := strings.Index(value, ":")
+		header.Add(strings.TrimSpace(value[:n]), strings.TrimSpace(value[n+1:]))
+	}
+	return header
+}
+
+func (i *excludeValues) String() string {
+	return strings.Join(*i, "|")
+}
+
+func (i *excludeValues) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
+func (i *notifySpecs) String() string {
+	return strings.Join(*i, ",")
+}
+
+func (i *notifySpecs) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
+func (i *negativeQueries) String() string {
+	return strings.Join(*i, "|")
+}
+
+func (i *negativeQueries) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
+func (i *positiveQueries) String() string {
+	return strings.Join(*i, "|")
+}
+
+func (i *positiveQueries) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
+// fail will report the error on stderr and exit with exitError once Main
+// returns, so the output given to -o or the pager is still written.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err.Error())
+	exitCode = exitError
+}
+
+// uploadSnapshot will export the results in the selected output format,
+// falling back to csv for tables, and upload it to the -upload storage.
+func uploadSnapshot(covid *covidcheck.Client) error {
+	storage, err := covidcheck.NewStorage(upload, uploadEndpoint)
+	if err != nil {
+		return err
+	}
+	format := output
+	if format == "table" || format == "markdown" {
+		format = "csv"
+	}
+	var buf bytes.Buffer
+	if err := covid.Export(&buf, format, canonical); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("snapshot-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	return storage.Upload(name, buf.Bytes())
+}
+
+// checkVisits will report which exposure sites in the results overlap with
+// the visits in the files given to the check subcommand. Several files can
+// be given as name=path to check a whole household, and the exposures are
+// then reported for each person along with their combined risk.
+func checkVisits(covid *covidcheck.Client) error {
+	if flag.NArg() == 0 {
+		return errors.New("usage: covid-check check [flags] [name=]visits.csv|visits.json...")
+	}
+	people := []covidcheck.Person{}
+	for _, spec := range flag.Args() {
+		p, err := covidcheck.LoadPerson(spec)
+		if err != nil {
+			return err
+		}
+		people = append(people, p)
+	}
+
+	household := len(people) > 1
+	results := covidcheck.CheckHousehold(people, covid.FilteredResults)
+	all := []covidcheck.Exposure{}
+	for _, r := range results {
+		if household {
+			fmt.Fprintf(stdout, "%s:\n", r.Person)
+		}
+		covidcheck.RenderExposures(stdout, r.Exposures, width)
+		if len(r.Exposures) > 0 {
+			fmt.Fprintf(stdout, "possible exposures found: %d\n", len(r.Exposures))
+		}
+		if household && len(r.Exposures) > 0 {
+			fmt.Fprintf(stdout, "combined risk: %.2f\n", r.Risk)
+		}
+		if household {
+			fmt.Fprintln(stdout)
+		}
+		all = append(all, r.Exposures...)
+	}
+	if household {
+		fmt.Fprintf(stdout, "household: %d possible exposures found, combined risk %.2f\n", len(all), covidcheck.CombinedRisk(all))
+	}
+	if len(all) == 0 {
+		exitCode = exitNoMatches
+	}
+
+	if remind && len(all) > 0 {
+		schedule, err := covidcheck.ParseReminderSchedule(remindDays)
+		if err != nil {
+			return err
+		}
+		store, err := reminderStore()
+		if err != nil {
+			return err
+		}
+		scheduled := 0
+		for _, r := range results {
+			person := ""
+			if household {
+				person = r.Person
+			}
+			added, err := store.Schedule(person, r.Exposures, schedule)
+			if err != nil {
+				return err
+			}
+			scheduled += len(added)
+		}
+		fmt.Fprintf(stdout, "scheduled %d test reminders in %s\n", scheduled, store.Path)
+	}
+
+	routes, err := notifiers()
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		exposures := all
+		if route.Person != "" {
+			exposures = nil
+			for _, r := range results {
+				if r.Person == route.Person {
+					exposures = r.Exposures
+				}
+			}
+		}
+		if len(exposures) == 0 {
+			continue
+		}
+		if err := route.Notifier.Notify(covidcheck.Changes{Added: covidcheck.ExposedEntries(exposures)}); err != nil {
+			covidcheck.DefaultLogger.Error(err.Error())
+		}
+	}
+	return nil
+}
+
+// showSlug will display the exposure site with the slug given to the show
+// subcommand, after running the pipeline over it.
+func showSlug(covid *covidcheck.Client, stages *covidcheck.Pipeline) error {
+	if flag.NArg() != 1 {
+		return errors.New("usage: covid-check show [flags] slug")
+	}
+	found := covid.RawResults.FindSlug(flag.Arg(0))
+	switch found.Len() {
+	case 0:
+		fmt.Fprintf(os.Stderr, "no exposure site found for '%s'\n", flag.Arg(0))
+		exitCode = exitNoMatches
+	case 1:
+		if err := stages.Run(&found); err != nil {
+			return err
+		}
+		covidcheck.RenderEntry(stdout, &found.Items[0])
+	default:
+		fmt.Fprintf(stdout, "'%s' matches %d exposure sites, use more of the slug:\n", flag.Arg(0), found.Len())
+		for i := range found.Items {
+			fmt.Fprintf(stdout, "%s %s\n", found.Items[i].Slug(), found.Items[i].ExposureLocation)
+		}
+		exitCode = exitError
+	}
+	return nil
+}
+
+// loadDataset will read the entries of a file given to the diff subcommand,
+// which is either a JSON export or snapshot, or a file of the -source.
+func loadDataset(path string) (covidcheck.Entries, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		f, err := os.Open(path)
+		if err != nil {
+			return covidcheck.Entries{}, err
+		}
+		defer f.Close()
+		if entries, err := covidcheck.ReadJSON(f); err == nil {
+			return entries, nil
+		}
+	}
+	src, err := covidcheck.NewSource(source, covidcheck.SourceOptions{File: path})
+	if err != nil {
+		return covidcheck.Entries{}, err
+	}
+	return src.Fetch()
+}
+
+// runSource is a DataSource which logs how long each fetch of its source
+// took, such as each poll of the watch and serve subcommands, gives each a
+// deadline, and lists or fails on the rows which couldn't be parsed.
+type runSource struct {
+	covidcheck.DataSource
+	// Name is the name of the source, as given to -source.
+	Name string
+	// Runtime is how long each fetch may take, or 0 for no limit.
+	Runtime time.Duration
+}
+
+// Fetch will fetch the source with a deadline of Runtime from now.
+func (s *runSource) Fetch() (covidcheck.Entries, error) {
+	start := time.Now()
+	if s.Runtime > 0 {
+		covidcheck.DefaultLimits.Deadline = start.Add(s.Runtime)
+		defer func() { covidcheck.DefaultLimits.Deadline = time.Time{} }()
+	}
+	entries, err := s.DataSource.Fetch()
+	covidcheck.DefaultLogger.Info("fetched exposure sites", "source", s.Name, "entries", entries.Len(), "duration", time.Since(start))
+	if showSkipped {
+		for _, w := range entries.Warnings {
+			fmt.Fprintln(os.Stderr, w.String())
+		}
+	}
+	if n := entries.Dropped(); strict && n > 0 && err == nil {
+		return entries, fmt.Errorf("parsing the source dropped %d row(s), which -show-skipped lists", n)
+	}
+	return entries, err
+}
+
+// fetchSource will fetch the source, printing a warning for each source
+// which failed when others succeeded or was cut short by a limit, and
+// returning their failures.
+func fetchSource(src covidcheck.DataSource) (covidcheck.Entries, []string, error) {
+	entries, err := src.Fetch()
+	if limit, ok := err.(*covidcheck.LimitError); ok {
+		covidcheck.DefaultLogger.Warn("results are partial", "error", limit)
+		return entries, []string{limit.Error()}, nil
+	}
+	failed, ok := err.(*covidcheck.FetchError)
+	if !ok || !failed.Partial() {
+		return entries, nil, err
+	}
+	failures := []string{}
+	for _, e := range failed.Errors {
+		covidcheck.DefaultLogger.Warn("source failed", "source", e.Source, "error", e.Err)
+		failures = append(failures, e.Error())
+	}
+	return entries, failures, nil
+}
+
+// diffDatasets will report the exposure sites which were added, removed or
+// updated between two files given to the diff subcommand, or between the
+// snapshot taken by -since and the live data.
+func diffDatasets(src covidcheck.DataSource, archive *covidcheck.SnapshotArchive, filter *covidcheck.Filter, since *time.Time) error {
+	var old, current covidcheck.Entries
+	var err error
+	switch {
+	case flag.NArg() == 2:
+		if old, err = loadDataset(flag.Arg(0)); err == nil {
+			current, err = loadDataset(flag.Arg(1))
+		}
+	case flag.NArg() == 0 && since != nil:
+		if old, _, err = archive.Load(source, *since); err == nil {
+			current, _, err = fetchSource(src)
+		}
+	default:
+		return errors.New("usage: covid-check diff [flags] old new, or covid-check diff -since DATE [flags]")
+	}
+	if err != nil {
+		return err
+	}
+
+	// -since picks the snapshot to compare against, so it doesn't filter
+	// the changes by date.
+	f := *filter
+	f.Since = nil
+	return writeChanges(covidcheck.Diff(old, current), &f)
+}
+
+// compareDatasets will report the exposure sites which were added, removed
+// or updated between the two datasets given to the compare subcommand, each
+// either a file or a reference to a snapshot in the archive.
+func compareDatasets(archive *covidcheck.SnapshotArchive, filter *covidcheck.Filter) error {
+	if flag.NArg() != 2 {
+		return errors.New("usage: covid-check compare [flags] old new, where each is a file, a snapshot timestamp, a date or latest")
+	}
+	datasets := make([]covidcheck.Entries, 2)
+	for i, ref := range flag.Args() {
+		var err error
+		if _, statErr := os.Stat(ref); statErr == nil {
+			datasets[i], err = loadDataset(ref)
+		} else {
+			datasets[i], _, err = archive.Find(source, ref, time.Now())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return writeChanges(covidcheck.Diff(datasets[0], datasets[1]), filter)
+}
+
+// simulateAlerts will replay the snapshots of the source in the archive
+// through the filters, and report the alerts watch mode would have sent.
+func simulateAlerts(archive *covidcheck.SnapshotArchive, filter *covidcheck.Filter) error {
+	alerts, replayed, err := covidcheck.Simulate(archive, source, *filter)
+	if err != nil {
+		return err
+	}
+	if replayed == 0 {
+		return fmt.Errorf("no %s snapshots found in %s", source, archive.Dir)
+	}
+
+	if output == "json" {
+		return covidcheck.ExportAlerts(stdout, alerts)
+	}
+	for _, alert := range alerts {
+		fmt.Fprintf(stdout, "%s: %d new and %d updated items would have been alerted\n", alert.Time.Local().Format("2006-01-02 15:04:05"), alert.Changes.Added.Len(), alert.Changes.Updated.Len())
+		covidcheck.RenderChanges(stdout, alert.Changes, width)
+	}
+	fmt.Fprintf(stdout, "%d alerts would have fired from %d snapshots\n", len(alerts), replayed)
+	return nil
+}
+
+// showStats will report the new exposure sites found in the snapshots of
+// the source in the archive over the -window, in the selected output format.
+func showStats(archive *covidcheck.SnapshotArchive, filter *covidcheck.Filter) error {
+	now := time.Now()
+	since, err := covidcheck.ParseSince(window, now)
+	if err != nil {
+		return err
+	}
+	days := int(now.UTC().Truncate(24*time.Hour).Sub(since).Hours() / 24)
+	if days < 1 || top < 0 {
+		return errors.New("window must be at least a day, and top zero or more")
+	}
+	stats, err := covidcheck.ComputeStats(archive, source, *filter, days, now)
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case "json":
+		err = covidcheck.ExportStats(stdout, stats)
+	case "sparkline":
+		_, err = fmt.Fprintf(stdout, "%s [%s] %s: %d new, against %d in the previous %d days (%s)\n", stats.From.Format("2006-01-02"), stats.Sparkline(), stats.To.Format("2006-01-02"), stats.New, stats.Previous, days, stats.Trend)
+	default:
+		covidcheck.RenderStats(stdout, stats, top)
+	}
+	return err
+}
+
+// writeSummary will write the counts of the entries grouped by the field
+// in the selected output format.
+func writeSummary(entries covidcheck.Entries, by string) error {
+	groups := covidcheck.Summarize(entries, by)
+	if output != "table" && output != "markdown" {
+		return covidcheck.ExportSummary(stdout, groups, by, output)
+	}
+	covidcheck.RenderSummary(stdout, groups, by, output == "markdown")
+	if output == "table" && len(groups) > 0 {
+		fmt.Fprintf(stdout, "%d items found in %d groups\n", entries.Len(), len(groups))
+	}
+	return nil
+}
+
+// writeChanges will filter the changes and write them in the selected
+// output format.
+func writeChanges(changes covidcheck.Changes, filter *covidcheck.Filter) error {
+	changes.Added = filter.Apply(changes.Added)
+	changes.Removed = filter.Apply(changes.Removed)
+	changes.Updated = filter.Apply(changes.Updated)
+
+	var err error
+	switch output {
+	case "json":
+		err = covidcheck.ExportChanges(stdout, changes)
+	case "csv":
+		err = covidcheck.ExportChangesCSV(stdout, changes)
+	default:
+		covidcheck.RenderChanges(stdout, changes, width)
+		if changes.Len() > 0 {
+			fmt.Fprintf(stdout, "%d added, %d removed and %d updated items found\n", changes.Added.Len(), changes.Removed.Len(), changes.Updated.Len())
+		}
+	}
+	return err
+}
+
+// route is a Notifier, which is only sent the exposures of the Person by
+// the check subcommand when a Person is set.
+type route struct {
+	Person   string
+	Notifier covidcheck.Notifier
+	// Name identifies the route in the notification queue, and is kept
+	// when the flags are reordered.
+	Name string
+}
+
+// routeName will return the Name of a route from the name of its notifier
+// and its specification, without keeping the webhook URLs in the queue.
+func routeName(n covidcheck.Notifier, spec string) string {
+	return fmt.Sprintf("%s-%x", covidcheck.NotifierName(n), sha256.Sum256([]byte(spec)))[:len(covidcheck.NotifierName(n))+9]
+}
+
+// splitRoute will split the person from a notifier specification such as
+// "alice:slack=URL", where the person is optional.
+func splitRoute(spec string) (string, string) {
+	i := strings.Index(spec, ":")
+	j := strings.Index(spec, "=")
+	if i > 0 && (j < 0 || i < j) {
+		return spec[:i], spec[i+1:]
+	}
+	return "", spec
+}
+
+// notifiers will return the Notifiers configured by the flags.
+func notifiers() ([]route, error) {
+	routes := []route{}
+	for _, full := range Notify {
+		person, spec := splitRoute(full)
+		notifier, err := covidcheck.NewNotifier(spec, emoji)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, route{Person: person, Notifier: notifier, Name: routeName(notifier, full)})
+	}
+	if matrixHomeserver != "" {
+		notifier := &covidcheck.MatrixNotifier{Homeserver: matrixHomeserver, Token: os.Getenv("MATRIX_ACCESS_TOKEN"), Room: matrixRoom, Emoji: emoji}
+		routes = append(routes, route{Notifier: notifier, Name: routeName(notifier, matrixHomeserver+"/"+matrixRoom)})
+	}
+	if discordWebhook != "" {
+		notifier := &covidcheck.DiscordNotifier{Webhook: discordWebhook, Emoji: emoji}
+		routes = append(routes, route{Notifier: notifier, Name: routeName(notifier, discordWebhook)})
+	}
+	return routes, nil
+}
+
+// reminderStore will return the ReminderStore in the state directory.
+func reminderStore() (*covidcheck.ReminderStore, error) {
+	if stateDir != "" {
+		return &covidcheck.ReminderStore{Path: filepath.Join(stateDir, "reminders.json")}, nil
+	}
+	return covidcheck.NewReminderStore()
+}
+
+// notificationQueue will return the NotificationQueue in the state directory.
+func notificationQueue() (*covidcheck.NotificationQueue, error) {
+	queue := &covidcheck.NotificationQueue{Path: filepath.Join(stateDir, "notifications.json"), Retry: covidcheck.DefaultNotificationRetry, Keep: covidcheck.DefaultNotificationKeep}
+	if stateDir == "" {
+		var err error
+		if queue, err = covidcheck.NewNotificationQueue(); err != nil {
+			return nil, err
+		}
+	}
+	queue.Retry.Retries = notifyRetries
+	return queue, nil
+}
+
+// deliver will send the queued notifications which are due to their routes,
+// logging the failures, and return the notifications which were attempted.
+func deliver(queue *covidcheck.NotificationQueue, notify []route) []covidcheck.Notification {
+	attempted, err := queue.Flush(func(n *covidcheck.Notification) error {
+		for _, route := range notify {
+			if route.Name == n.Notifier {
+				return route.Notifier.Notify(n.Changes())
+			}
+		}
+		return fmt.Errorf("notifier %s is no longer configured", n.Notifier)
+	})
+	if err != nil {
+		covidcheck.DefaultLogger.Error(err.Error())
+	}
+	for _, n := range attempted {
+		switch {
+		case n.Failed:
+			covidcheck.DefaultLogger.Error("gave up notifying", "notifier", n.Notifier, "attempts", n.Attempts, "error", n.Error)
+		case n.Error != "":
+			covidcheck.DefaultLogger.Warn("notifying failed", "notifier", n.Notifier, "error", n.Error, "retry", n.Next.Format("2006-01-02 15:04:05"))
+		}
+	}
+	return attempted
+}
+
+// fireReminders will print the test reminders which are due and queue them
+// for the notifiers, where a notifier for one person is only sent their own
+// reminders.
+func fireReminders(store *covidcheck.ReminderStore, queue *covidcheck.NotificationQueue, notify []route) error {
+	return store.Fire(func(due []covidcheck.Reminder) error {
+		for i := range due {
+			fmt.Fprintf(stdout, "%s: reminder: %s\n", time.Now().Format("2006-01-02 15:04:05"), due[i].Text())
+		}
+		for _, route := range notify {
+			reminders := []covidcheck.Reminder{}
+			for _, r := range due {
+				if route.Person == "" || route.Person == r.Person {
+					reminders = append(reminders, r)
+				}
+			}
+			if len(reminders) == 0 {
+				continue
+			}
+			if _, err := queue.Enqueue(route.Name, covidcheck.Changes{Reminders: reminders}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// watchSource will poll the source every watchInterval, printing the new and
+// updated results which match the filter until the program is interrupted.
+// After the first poll, they are also sent to any configured notifiers,
+// along with any test reminders which are due, and appended to the feed file.
+// Notifications are queued in the state directory, so those which fail to
+// send are retried on later polls.
+func watchSource(src covidcheck.DataSource, filter *covidcheck.Filter, sortKeys []covidcheck.SortKey) error {
+	notify, err := notifiers()
+	if err != nil {
+		return err
+	}
+	store, err := reminderStore()
+	if err != nil {
+		return err
+	}
+	queue, err := notificationQueue()
+	if err != nil {
+		return err
+	}
+	feed := &covidcheck.ChangeFeed{Path: feedFile, Source: source, MaxSize: int64(feedMaxSize) << 20, MaxAge: feedMaxAge}
+	var w *covidcheck.Watcher
+	w = &covidcheck.Watcher{
+		Source:   src,
+		Filter:   *filter,
+		Interval: watchInterval,
+		OnError: func(err error) {
+			covidcheck.DefaultLogger.Error(err.Error())
+		},
+		OnPoll: func() {
+			debug.Update(w.Current())
+			if err := fireReminders(store, queue, notify); err != nil {
+				covidcheck.DefaultLogger.Error(err.Error())
+			}
+			deliver(queue, notify)
+		},
+		OnChange: func(changes covidcheck.Changes) {
+			if feedFile != "" {
+				if err := feed.Append(changes); err != nil {
+					covidcheck.DefaultLogger.Error(err.Error())
+				}
+			}
+
+			covid := &covidcheck.Client{}
+			for i := range changes.Added.Items {
+				covid.AddFiltered(&changes.Added.Items[i])
+			}
+			for i := range changes.Updated.Items {
+				covid.AddFiltered(&changes.Updated.Items[i])
+			}
+			if len(covid.FilteredResults.Items) == 0 {
+				return
+			}
+			covid.FilteredResults.SortBy(sortKeys)
+
+			alerts := []covidcheck.ReportAlert{}
+			if !changes.Initial {
+				ids := map[string]string{}
+				for _, route := range notify {
+					id, err := queue.Enqueue(route.Name, changes)
+					if err != nil {
+						covidcheck.DefaultLogger.Error(err.Error())
+						continue
+					}
+					ids[id] = covidcheck.NotifierName(route.Notifier)
+				}
+				for _, n := range deliver(queue, notify) {
+					if name, ok := ids[n.ID]; ok {
+						alerts = append(alerts, covidcheck.ReportAlert{Notifier: name, Entries: len(covid.FilteredResults.Items), Error: n.Error})
+					}
+				}
+			}
+
+			if reportFile != "" {
+				report := covidcheck.NewReport(source, w.Current(), filter, covid.FilteredResults)
+				report.Alerts = alerts
+				if err := report.Write(reportFile); err != nil {
+					covidcheck.DefaultLogger.Error(err.Error())
+				}
+			}
+
+			if output != "table" && output != "markdown" {
+				if err := covid.ExportColumns(stdout, output, canonical, selectedColumns); err != nil {
+					covidcheck.DefaultLogger.Error(err.Error())
+				}
+				return
+			}
+			fmt.Fprintf(stdout, "%s: %d new and %d updated items found\n", time.Now().Format("2006-01-02 15:04:05"), changes.Added.Len(), changes.Updated.Len())
+			covid.Render(stdout, covidcheck.RenderParams{
+				Width:     width,
+				Risk:      risk,
+				Slug:      slug,
+				Hours:     hours,
+				Emoji:     emoji,
+				Truncate:  truncate,
+				Footnotes: footnotes,
+				Markdown:  output == "markdown",
+				Color:     color,
+				Highlight: color,
+				Columns:   selectedColumns,
+			})
+		},
+	}
+	w.Run(nil)
+	return nil
+}
+
+// envPrefix is the prefix of the environment variables which set flags.
+const envPrefix = "COVID_CHECK_"
+
+// envDefaults will set each flag from its environment variable, such as
+// COVID_CHECK_SUBURB for -suburb, so the flags given on the command line
+// still take precedence when they are parsed afterwards.
+func envDefaults(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok || err != nil {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value '%s' for %s: %s", value, name, setErr.Error())
+		}
+	})
+	return err
+}
+
+// serveSource will poll the source every watchInterval, serving the results
+// which match the filter over HTTP on the listen address.
+func serveSource(src covidcheck.DataSource, filter *covidcheck.Filter) error {
+	server := &covidcheck.Server{Filter: *filter}
+	failed := false
+	w := &covidcheck.Watcher{
+		Source:   src,
+		Interval: watchInterval,
+		OnError: func(err error) {
+			covidcheck.DefaultLogger.Error(err.Error())
+			server.Fail(err)
+			failed = true
+		},
+	}
+	w.OnPoll = func() {
+		if !failed {
+			server.Update(w.Current())
+		}
+		failed = false
+		debug.Update(w.Current())
+	}
+	go w.Run(nil)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", server)
+	covidcheck.DefaultLogger.Info("serving exposure sites", "listen", listen)
+	return http.ListenAndServe(listen, mux)
+}
+
+// Main is the starting point of covid-check, which parses the command line
+// and runs the command it gives.
+func Main() {
+
+	// The exit code of the results is only used once the deferred output
+	// has been written.
+	defer func() {
+		if exitCode != exitMatches {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// A bug shouldn't surface as a stack trace, so a panic is reported as an
+	// internal error with an exit code like any other error.
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "internal error: %v\nplease report this at https://github.com/fubarhouse/covid-check/issues\n", r)
+			os.Exit(exitError)
+		}
+	}()
+
+	// The subcommand is given before its flags, and every flag is accepted
+	// without one.
+	setDefaults()
+	if len(os.Args) > 1 && os.Args[1] == "help" {
+		help(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "service" {
+		serviceCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		completionCommand(os.Args[2:])
+		return
+	}
+	cmd, args := parseCommand(os.Args[1:])
+	command := cmd.Name
+	flag.CommandLine = cmd.newFlagSet()
+	if err := envDefaults(flag.CommandLine); err != nil {
+		fail(err)
+		return
+	}
+	flag.CommandLine.Parse(args)
+	// A trailing - reads the data from stdin, as -file - does.
+	if flag.Arg(0) == "-" && command != "check" && command != "compare" {
+		if flag.NArg() > 1 || file != "" {
+			fail(errors.New("- reads the data from stdin, so it is given after every flag and without -file"))
+			return
+		}
+		file = "-"
+	}
+
+	if rawOutput {
+		output = "csv"
+	}
+	if err := cmd.validate(flag.CommandLine); err != nil {
+		fail(err)
+		return
+	}
+	if outFile != "" {
+		if watch || command == "watch" {
+			fail(errors.New("-o can't be used in watch mode, which keeps writing results"))
+			return
+		}
+		buf := &bytes.Buffer{}
+		stdout = buf
+		// The file is kept as it was when the command fails before writing
+		// any results.
+		defer func() {
+			if exitCode == exitError && buf.Len() == 0 {
+				return
+			}
+			if err := covidcheck.WriteFileAtomic(outFile, buf.Bytes(), 0644); err != nil {
+				fail(err)
+			}
+		}()
+	}
+
+	if quiet && outFile == "" {
+		stdout = ioutil.Discard
+	}
+
+	// Tables are only coloured on a terminal, following https://no-color.org.
+	color = isTerminal(os.Stdout) && outFile == "" && !noColor && os.Getenv("NO_COLOR") == ""
+
+	// Long tables are paged rather than scrolling past, unless they are
+	// written to a file or keep being written in watch mode.
+	if rows := terminalRows(os.Stdout); rows > 0 && outFile == "" && !quiet && flag.Lookup("no-pager") != nil && !noPager && (output == "table" || output == "markdown") && !watch && command != "watch" && command != "serve" {
+		buf := &bytes.Buffer{}
+		stdout = buf
+		defer page(buf, rows)
+	}
+
+	if logFile != "" {
+		l := &covidcheck.LogFile{Path: logFile, MaxSize: int64(logMaxSize) << 20, MaxAge: logMaxAge, MaxBackups: logKeep}
+		defer l.Close()
+		logger = l
+	}
+	if logFormat != "text" && logFormat != "json" {
+		fail(fmt.Errorf("unknown log format %s, expected one of [text|json]", logFormat))
+		return
+	}
+	// Watch mode and serve log what they do by default, as they run
+	// unattended.
+	level := covidcheck.LevelWarn
+	if command == "watch" || command == "serve" || watch || verbose {
+		level = covidcheck.LevelInfo
+	}
+	if veryVerbose {
+		level = covidcheck.LevelDebug
+	}
+	if quiet && !verbose && !veryVerbose {
+		level = covidcheck.LevelError
+	}
+	covidcheck.DefaultLogger = &covidcheck.Logger{Writer: logger, Level: level, JSON: logFormat == "json"}
+	warnDeprecated(flag.CommandLine)
+
+	if command == "features" {
+		if err := writeFeatures(stdout, output); err != nil {
+			fail(err)
+			return
+		}
+		return
+	}
+	if command == "simulate" && !enabled("experimental.simulate") {
+		fail(errors.New("simulate is experimental, run it with -enable experimental.simulate"))
+		return
+	}
+
+	if generate {
+		c, err := covidcheck.GenerateData()
+		if limit, ok := err.(*covidcheck.LimitError); ok {
+			covidcheck.DefaultLogger.Warn("results are partial", "error", limit)
+		} else if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Fprintln(stdout, c.RawCSV)
+		return
+	}
+
+	covid := &covidcheck.Client{}
+
+	if riskModel != "" {
+		model, err := covidcheck.LoadRiskModel(riskModel)
+		if err != nil {
+			fail(err)
+			return
+		}
+		covidcheck.DefaultRiskModel = model
+	}
+
+	if guidance != "" {
+		g, err := covidcheck.LoadGuidance(guidance)
+		if err != nil {
+			fail(err)
+			return
+		}
+		covidcheck.DefaultGuidance = g
+	}
+
+	// The aliases of the config directory are loaded when there are any,
+	// unless -aliases names another file.
+	aliasesPath := aliases
+	if aliasesPath == "" {
+		if path, err := covidcheck.AliasesPath(); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				aliasesPath = path
+			}
+		}
+	}
+	if aliasesPath != "" {
+		a, err := covidcheck.LoadAliases(aliasesPath)
+		if err != nil {
+			fail(err)
+			return
+		}
+		covidcheck.DefaultAliases = a
+	}
+
+	if openingHours != "" {
+		h, err := covidcheck.LoadOpeningHours(openingHours)
+		if err != nil {
+			fail(err)
+			return
+		}
+		covidcheck.DefaultOpeningHours = h
+	}
+
+	if proxy != "" || insecureSkipVerify || caCert != "" || userAgent != "" || len(Headers) > 0 {
+		t, err := covidcheck.NewTransport(covidcheck.TransportOptions{
+			Proxy:              proxy,
+			InsecureSkipVerify: insecureSkipVerify,
+			CACert:             caCert,
+			UserAgent:          userAgent,
+			Header:             Headers.header(),
+		})
+		if err != nil {
+			fail(err)
+			return
+		}
+		if insecureSkipVerify {
+			covidcheck.DefaultLogger.Warn("tls certificates are not being verified")
+		}
+		covidcheck.DefaultTransport = t
+	}
+
+	covidcheck.DefaultRetryPolicy.Retries = retries
+	covidcheck.DefaultRetryPolicy.Wait = retryWait
+	if maxRows < 0 || maxFetchBytes < 0 || maxRuntime < 0 {
+		fail(errors.New("max-rows, max-fetch-bytes and max-runtime must be zero or more"))
+		return
+	}
+	covidcheck.DefaultLimits.MaxRows = maxRows
+	covidcheck.DefaultLimits.MaxFetchBytes = maxFetchBytes
+
+	if dataFormat != "csv" && dataFormat != "json" && dataFormat != "xlsx" {
+		fail(fmt.Errorf("unknown format '%s', expected one of [csv|json|xlsx]", dataFormat))
+		return
+	}
+	if err := covidcheck.CheckFile(file); err != nil {
+		fail(err)
+		return
+	}
+	options := covidcheck.SourceOptions{Endpoint: endpoint, CSVURL: csvURL, Format: dataFormat, File: file, Parallelism: parallel}
+	if mapping != "" {
+		m, err := covidcheck.LoadMapping(mapping)
+		if err != nil {
+			fail(err)
+			return
+		}
+		options.Mapping = m
+	}
+	if cache {
+		c, err := covidcheck.NewCache(cacheTTL)
+		if err != nil {
+			fail(err)
+			return
+		}
+		options.Cache = c
+	}
+
+	src, err := covidcheck.NewSource(source, options)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	if command == "simulate" && history != "" {
+		snapshotDir = history
+	}
+	var archive *covidcheck.SnapshotArchive
+	if command == "snapshot" || command == "diff" || command == "compare" || command == "simulate" || command == "stats" || asOf != "" {
+		archive = &covidcheck.SnapshotArchive{Dir: snapshotDir}
+		if snapshotDir == "" {
+			if archive, err = covidcheck.NewSnapshotArchive(); err != nil {
+				fail(err)
+				return
+			}
+		}
+	}
+	if asOf != "" {
+		date, err := covidcheck.ParseDate(asOf, time.Now())
+		if err != nil {
+			fail(err)
+			return
+		}
+		src = covidcheck.NewSnapshotSource(archive, source, date)
+	}
+	src = &runSource{DataSource: src, Name: source, Runtime: maxRuntime}
+
+	sortKeys, err := covidcheck.ParseSortKeys(sortBy)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if limit < 0 {
+		fail(fmt.Errorf("limit must be zero or more, got %d", limit))
+		return
+	}
+	var summaryBy string
+	if summary != "" {
+		if summaryBy, err = covidcheck.ParseSummary(summary); err != nil {
+			fail(err)
+			return
+		}
+	}
+	if columnList != "" {
+		if selectedColumns, err = covidcheck.ParseColumns(columnList); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	// validate input date requirements
+	now := time.Now()
+	t := &time.Time{}
+	if udate != "" {
+		tparse, err := covidcheck.ParseDate(udate, now)
+		if err != nil {
+			fail(err)
+			return
+		}
+		t = &tparse
+	}
+	if lastWeek && since == "" {
+		since = "1w"
+	}
+	var from *time.Time
+	if since != "" {
+		sparse, err := covidcheck.ParseSince(since, now)
+		if err != nil {
+			fail(err)
+			return
+		}
+		from = &sparse
+	}
+
+	if enrich {
+		pipeline = "venue," + pipeline
+	}
+	if output == "geojson" && !strings.Contains(","+strings.ToLower(pipeline), ",geocode") {
+		pipeline += ",geocode"
+	}
+	var centre *covidcheck.Point
+	var distance float64
+	var geocoder *covidcheck.NominatimGeocoder
+	if near != "" || enrich || pipeline != "" {
+		geocache, err := covidcheck.NewCache(30 * 24 * time.Hour)
+		if err != nil {
+			fail(err)
+			return
+		}
+		geocoder = &covidcheck.NominatimGeocoder{Endpoint: geocoderEndpoint, Cache: geocache}
+	}
+	stages, err := covidcheck.ParsePipeline(pipeline, map[string]covidcheck.Stage{
+		"venue":    &covidcheck.VenueStage{Lookup: geocoder},
+		"geocode":  &covidcheck.GeocodeStage{Geocoder: geocoder},
+		"classify": &covidcheck.ClassifyStage{},
+	})
+	if err != nil {
+		fail(err)
+		return
+	}
+	if near != "" {
+		p, ok := covidcheck.ParsePoint(near)
+		if !ok {
+			if p, err = geocoder.Geocode(near); err != nil {
+				fail(err)
+				return
+			}
+		}
+		centre = &p
+		if distance, err = covidcheck.ParseDistance(radius); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	filter := &covidcheck.Filter{
+		Status:           status,
+		ExposureLocation: location,
+		Street:           street,
+		Suburb:           suburb,
+		State:            state,
+		Date:             t,
+		Since:            from,
+		ArrivalTime:      atime,
+		DepartureTime:    dtime,
+		Contact:          contact,
+		Trust:            trust,
+		Regex:            regex,
+		Fuzzy:            fuzzy,
+		FuzzyThreshold:   fuzzyThreshold,
+		ExcludeStatus:    ExcludeStatus,
+		ExcludeLocation:  ExcludeLocation,
+		ExcludeSuburb:    ExcludeSuburb,
+		ExcludeContact:   ExcludeContact,
+		Queries:          PositiveQueries,
+		NotQueries:       NegativeQueries,
+		MinRisk:          minRisk,
+		Near:             centre,
+		Radius:           distance,
+	}
+	if near != "" {
+		filter.Geocoder = geocoder
+	}
+	if expression != "" {
+		if filter.Expression, err = covidcheck.ParseExpression(expression, now); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		fail(err)
+		return
+	}
+
+	if debugListen != "" {
+		debug = &covidcheck.Debug{Started: time.Now(), Caches: map[string]*covidcheck.Cache{"source": options.Cache}}
+		if geocoder != nil {
+			debug.Caches["geocoder"] = geocoder.Cache
+		}
+		go func() {
+			if err := http.ListenAndServe(debugListen, debug); err != nil {
+				covidcheck.DefaultLogger.Error(err.Error())
+			}
+		}()
+	}
+
+	if command == "serve" {
+		if err := serveSource(src, filter); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if watch || command == "watch" {
+		if err := watchSource(src, filter, sortKeys); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if command == "diff" {
+		if err := diffDatasets(src, archive, filter, from); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if command == "compare" {
+		if err := compareDatasets(archive, filter); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if command == "simulate" {
+		if err := simulateAlerts(archive, filter); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if command == "stats" {
+		if err := showStats(archive, filter); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	entries, failures, err := fetchSource(src)
+	if err != nil {
+		fail(err)
+		return
+	}
+	for i := range entries.Items {
+		covid.AddRaw(&entries.Items[i])
+		covid.AddFiltered(&entries.Items[i])
+	}
+
+	if command == "fetch" {
+		if err := covid.Export(stdout, output, canonical); err != nil {
+			fail(err)
+			return
+		}
+		return
+	}
+
+	if command == "snapshot" {
+		path, err := archive.Save(source, covid.RawResults, time.Now())
+		if err != nil {
+			fail(err)
+			return
+		}
+		fmt.Fprintf(stdout, "saved %d exposure sites to %s\n", covid.RawResults.Len(), path)
+		return
+	}
+
+	covid.RawResults.SortBy(sortKeys)
+
+	covid.Query(filter, covidcheck.QueryParams{
+		PrintRAWCSV: false,
+	})
+
+	if command != "show" {
+		if err := stages.Run(&covid.FilteredResults); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	if command == "check" {
+		if err := checkVisits(covid); err != nil {
+			fail(err)
+		}
+		return
+	}
+	if command == "show" {
+		if err := showSlug(covid, stages); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if reportFile != "" {
+		report := covidcheck.NewReport(source, covid.RawResults, filter, covid.FilteredResults)
+		if failures != nil {
+			report.Errors = failures
+		}
+		if err := report.Write(reportFile); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	if archiveRepo != "" {
+		archiver := &covidcheck.GitArchiver{Repo: archiveRepo, File: archiveFile, Push: archivePush}
+		if _, err := archiver.Archive(covid); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	if upload != "" {
+		if err := uploadSnapshot(covid); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	if len(covid.FilteredResults.Items) == 0 {
+		exitCode = exitNoMatches
+	}
+
+	if summaryBy != "" {
+		if err := writeSummary(covid.FilteredResults, summaryBy); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if output != "table" && output != "markdown" {
+		if err := covid.ExportColumns(stdout, output, canonical, selectedColumns); err != nil {
+			fail(err)
+			return
+		}
+		return
+	}
+
+	// Render!
+	covid.Render(stdout, covidcheck.RenderParams{
+		Width:     width,
+		Limit:     limit,
+		Risk:      risk,
+		Slug:      slug,
+		Hours:     hours,
+		Emoji:     emoji,
+		Truncate:  truncate,
+		Footnotes: footnotes,
+		Markdown:  output == "markdown",
+		Color:     color,
+		Highlight: color,
+		Columns:   selectedColumns,
+	})
+	// The footer is left out of Markdown, so it can be pasted as is.
+	if output == "markdown" {
+		return
+	}
+	if !rawOutput && limit == 0 && len(covid.FilteredResults.Items) > 0 {
+		fmt.Fprintf(stdout, "total items found: %d\n", len(covid.FilteredResults.Items))
+	}
+	if !rawOutput && limit != 0 && len(covid.FilteredResults.Items) > 0 {
+		count := limit
+		if count > len(covid.FilteredResults.Items) {
+			count = len(covid.FilteredResults.Items)
+		}
+		fmt.Fprintf(stdout, "displaying %d of %d total items found\n", count, len(covid.FilteredResults.Items))
+	}
+}
@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fubarhouse/covid-check/v2/covidcheck"
+)
+
+// argsEnv is the environment variable the test binary reads the command
+// line of the CLI from, separated by newlines, when run as covid-check.
+const argsEnv = "COVID_CHECK_TEST_ARGS"
+
+// sampleEnv is the environment variable the test binary reads the URL of
+// the SampleEndpointURL from, so -generate doesn't reach the mirror.
+const sampleEnv = "COVID_CHECK_TEST_SAMPLE"
+
+// TestMain will run the CLI instead of the tests when the test binary is
+// started by TestPathologicalInputs, so its exit code can be checked.
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(argsEnv); ok {
+		if url, ok := os.LookupEnv(sampleEnv); ok {
+			covidcheck.SampleEndpointURL = url
+		}
+		os.Args = append([]string{"covid-check"}, strings.Split(args, "\n")...)
+		Main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// TestPathologicalInputs will run the CLI against missing, empty, truncated
+// and garbage files and invalid flags, and check each exits with the
+// expected code and an error message rather than a stack trace.
+func TestPathologicalInputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	garbage := make([]byte, 4096)
+	for i := range garbage {
+		garbage[i] = byte(i*7919 + i/13)
+	}
+	files := map[string][]byte{
+		"act.csv":      []byte(`1,"New","ALDI Belconnen","Westfield Belconnen, Benjamin Way","Belconnen","ACT","04/10/2021 - Monday",7:00pm,7:30pm,"Casual"` + "\n"),
+		"empty.csv":    {},
+		"garbage.bin":  garbage,
+		"sparse.csv":   []byte(",,,,\"Belconnen\",,\"01/09/2021 - Wednesday\",,,\n\"a\",\"b\"\n,,,,,,,,,,,,,,,,,,\n"),
+		"bad.json":     []byte("{"),
+		"undated.json": []byte(`[{"location":"Undated","suburb":"Belconnen"}]`),
+		"mapping.json": []byte(`{"fields": {"location": {"column": 3}, "suburb": {"column": 5}, "date": {"column": 7}, "contact": {"column": 10}}}`),
+		"broken.xlsx":  []byte("PK\x03\x04broken"),
+		"nsw.json":     []byte(`{"data": {"monitor": [{"Venue": "Woolworths Queanbeyan", "Suburb": "Queanbeyan", "Date": "Wednesday 3 November 2021", "Time": "8:40am to 9:10am"}]}}`),
+		"vic.csv":      []byte("Suburb,Site_title,Site_streetaddress,Site_state,Exposure_date,Exposure_time,Advice_title\nCarlton,Lygon Street Cafe,1 Lygon Street,,05/10/2021,8:15am - 9am,Tier 1\n"),
+		"qld.html":     []byte(`<table><tr><th>Date</th><th>Place</th><th>Suburb</th><th>Time</th></tr><tr><td>1 August 2021</td><td>Coles Toowong</td><td>Toowong</td><td>5pm - 6pm</td></tr></table>`),
+		"aliases.json": []byte(`{"Beltown": "Belconnen"}`),
+		"sites.json":   []byte(`{"sites": [{"venue": {"name": "ALDI"}, "suburb": "Belconnen", "date": "2021-10-04"}]}`),
+		"paths.json":   []byte(`{"records": "$.sites[*]", "fields": {"location": {"path": "venue.name"}, "suburb": {"name": "suburb"}, "date": {"name": "date", "format": "2006-01-02"}}}`),
+	}
+	files[filepath.Join("data", "covid-check", "snapshots", "act-20211001T000000Z.json")] = files["undated.json"]
+	for name, data := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	examples := []struct {
+		args string
+		code int
+	}{
+		{"-file missing.csv", 2},
+		{"-file empty.csv", 1},
+		{"-file garbage.bin", 1},
+		{"-file garbage.bin -output html", 1},
+		{"-file sparse.csv", 0},
+		{"-file sparse.csv -output json", 0},
+		{"export -file sparse.csv -output geojson", 0},
+		{"-file act.csv -date 31/02/2021", 2},
+		{"-file act.csv -since 3y", 2},
+		{"-file act.csv -filter (((", 2},
+		{"-file act.csv -regex -location (", 2},
+		{"-file act.csv -output xml", 2},
+		{"-file act.csv -sort bogus", 2},
+		{"-file act.csv -limit -1", 2},
+		{"-file act.csv -max-rows -1", 2},
+		{"-file act.csv -log-format xml", 2},
+		{"-file act.csv -mapping mapping.json -strict", 0},
+		{"-file act.csv -mapping bad.json", 2},
+		{"-file act.csv -mapping missing.json", 2},
+		{"query -file sites.json -format json -mapping paths.json -suburb belconnen -output json", 0},
+		{"-file sites.json -format json -mapping mapping.json", 2},
+		{"-file sites.json -format json", 2},
+		{"-file bad.json -format json -mapping paths.json", 2},
+		{"-file act.csv -format xml", 2},
+		{"-file broken.xlsx", 2},
+		{"-file act.csv -format xlsx", 2},
+		{"-file sparse.csv -strict", 2},
+		{"-file act.csv -strict -show-skipped", 0},
+		{"-file sparse.csv -vv -log-format json", 0},
+		{"-file act.csv -max-rows 1 -max-fetch-bytes 10 -max-runtime 1s", 0},
+		{"-file act.csv -proxy http://", 2},
+		{"-file act.csv -ca-cert missing.pem", 2},
+		{"-file act.csv -proxy 127.0.0.1:3128 -insecure-skip-verify", 0},
+		{"-file act.csv -user-agent test -header X-Api-Key:secret -header Accept:text/csv", 0},
+		{"-file act.csv -header bogus", 2},
+		{"-csv-url http://127.0.0.1:1/data.csv -retries 0", 2},
+		{"-file act.csv -header X(bad):1", 2},
+		{"-file act.csv -summary by=suburb", 0},
+		{"query -file act.csv -summary contact -output json", 0},
+		{"-file act.csv -summary by=street", 2},
+		{"-file act.csv -summary date -output html", 2},
+		{"-file act.csv -width -5", 0},
+		{"-file act.csv -width 1 -risk -slug -hours -emoji -truncate -footnotes", 0},
+		{"-file act.csv -o " + filepath.Join(dir, "missing", "out.csv"), 2},
+		{"-file act.csv -unknown-flag", 2},
+		{"-source vic -file bad.json", 2},
+		{"-source nsw -file bad.json", 2},
+		{"-source qld -file garbage.bin", 1},
+		{"-as-of 2021-10-02", 0},
+		{"-as-of 2021-10-02 -sort date -output csv", 0},
+		{"-as-of 2021-09-01", 2},
+		{"simulate", 2},
+		{"simulate -enable experimental.simulate", 0},
+		{"simulate -enable experimental.simulate -history missing", 2},
+		{"features -enable bogus", 2},
+		{"features -enable experimental.simulate -output json", 0},
+		{"-file act.csv -raw -last-week", 1},
+		{"stats", 0},
+		{"stats -window 2w -top 0 -output sparkline", 0},
+		{"stats -window 0d", 2},
+		{"stats -source nsw", 2},
+		{"completion bash", 0},
+		{"completion zsh", 0},
+		{"completion fish", 0},
+		{"completion tcsh", 2},
+		{"completion suburbs -file act.csv", 0},
+		{"completion suburbs -bogus", 2},
+		{"compare undated.json undated.json", 0},
+		{"diff undated.json act.csv", 0},
+		{"show -file act.csv zzzz", 1},
+		{"check -file act.csv missing.csv", 2},
+		{"check -file act.csv garbage.bin", 2},
+		{"check -file act.csv undated.json", 2},
+		{"query -file act.csv -no-color", 0},
+		{"query -file act.csv -columns suburb,contact,date -output json", 0},
+		{"query -file act.csv -columns suburb,bogus", 2},
+		{"-file act.csv -quiet", 0},
+		{"query -file empty.csv -quiet -output json", 1},
+		{"-file missing.csv -quiet", 2},
+		{"-file data -quiet", 2},
+		{"-source nsw -file nsw.json -suburb queanbeyan -quiet", 0},
+		{"-source vic -file vic.csv -suburb carlton -quiet", 0},
+		{"-source qld -file qld.html -suburb toowong -quiet", 0},
+		{"-source qld -file missing.html -quiet", 2},
+		{"query -file act.csv -suburb belconnen -output json", 0},
+		{"-file act.csv -suburb belconen -quiet", 1},
+		{"-file act.csv -suburb belconen -fuzzy -quiet", 0},
+		{"-file act.csv -suburb belconen -fuzzy -fuzzy-threshold 2", 2},
+		{"-file act.csv -suburb beltown -aliases aliases.json -quiet", 0},
+		{"-file act.csv -exclude-suburb beltown -aliases aliases.json -quiet", 1},
+		{"-file act.csv -aliases bad.json", 2},
+		{"-file act.csv -aliases missing.json", 2},
+		{"-file act.csv -q location:aldi -q suburb:belconnen -quiet", 0},
+		{"-file act.csv -q suburb:aldi -quiet", 1},
+		{"-file act.csv -q lcation:aldi", 2},
+		{"-file act.csv -qn contact:casual -quiet", 1},
+		{"query -quiet -output json -", 1},
+		{"-file act.csv -", 2},
+		{"- -suburb Kaleen", 2},
+	}
+	for _, example := range examples {
+		t.Run(example.args, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0])
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(),
+				argsEnv+"="+strings.Join(strings.Fields(example.args), "\n"),
+				"XDG_CACHE_HOME="+filepath.Join(dir, "cache"),
+				"XDG_STATE_HOME="+filepath.Join(dir, "state"),
+				"XDG_DATA_HOME="+filepath.Join(dir, "data"),
+				"XDG_CONFIG_HOME="+filepath.Join(dir, "config"),
+			)
+			var out bytes.Buffer
+			cmd.Stdout = &out
+			cmd.Stderr = &out
+			code := 0
+			if err := cmd.Run(); err != nil {
+				exit, ok := err.(*exec.ExitError)
+				if !ok {
+					t.Fatal(err)
+				}
+				code = exit.ExitCode()
+			}
+			if code != example.code {
+				t.Errorf("expected exit code %d, got %d: %s", example.code, code, out.String())
+			}
+			if s := out.String(); strings.Contains(s, "panic") || strings.Contains(s, "goroutine ") || strings.Contains(s, "internal error") {
+				t.Errorf("expected an error message, got %s", s)
+			}
+		})
+	}
+}
+
+// runCLI will run the CLI with the arguments in dir, stopping it after the
+// timeout, and return what it wrote to stdout with its exit code.
+func runCLI(t *testing.T, dir, args string, timeout time.Duration) (string, int) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		argsEnv+"="+strings.Join(strings.Fields(args), "\n"),
+		"XDG_CACHE_HOME="+filepath.Join(dir, "cache"),
+		"XDG_STATE_HOME="+filepath.Join(dir, "state"),
+		"XDG_DATA_HOME="+filepath.Join(dir, "data"),
+		"XDG_CONFIG_HOME="+filepath.Join(dir, "config"),
+	)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Run()
+	return out.String(), cmd.ProcessState.ExitCode()
+}
+
+// TestQuiet will run the watch and check commands with -quiet and check
+// nothing but errors is written, which they write without it.
+func TestQuiet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "act.csv"), []byte(`1,"New","ALDI Belconnen","Westfield Belconnen, Benjamin Way","Belconnen","ACT","04/10/2021 - Monday",7:00pm,7:30pm,"Casual"`+"\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "visits.json"), []byte(`[{"place": "ALDI Belconnen", "suburb": "Belconnen", "date": "04/10/2021", "start": "7:00pm", "end": "7:15pm"}]`), 0644)
+
+	for _, test := range []struct {
+		Args, Visits string
+	}{
+		{"watch -file act.csv -watch-interval 1h", ""},
+		{"check -file act.csv -remind", "visits.json"},
+	} {
+		t.Run(test.Args, func(t *testing.T) {
+			os.RemoveAll(filepath.Join(dir, "state"))
+			if out, _ := runCLI(t, dir, test.Args+" "+test.Visits, 3*time.Second); out == "" {
+				t.Fatal("expected results without -quiet")
+			}
+			os.RemoveAll(filepath.Join(dir, "state"))
+			if out, _ := runCLI(t, dir, test.Args+" -quiet "+test.Visits, 3*time.Second); out != "" {
+				t.Errorf("expected nothing with -quiet, got %q", out)
+			}
+		})
+	}
+}
+
+// TestGenerate will check -generate prints the dataset of the mirror and
+// exits before checking anything, or fails when it can't be downloaded.
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sample := `1,"New","ALDI Belconnen","Westfield Belconnen, Benjamin Way","Belconnen","ACT","04/10/2021 - Monday",7:00pm,7:30pm,"Casual"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, sample)
+	}))
+	defer server.Close()
+
+	os.Setenv(sampleEnv, server.URL)
+	defer os.Unsetenv(sampleEnv)
+	out, code := runCLI(t, dir, "-generate", 10*time.Second)
+	if code != 0 || !strings.Contains(out, sample) || strings.Contains(out, "LOCATION") {
+		t.Errorf("expected the sample dataset alone and exit code 0, got %d and %q", code, out)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	os.Setenv(sampleEnv, failing.URL)
+	if out, code := runCLI(t, dir, "-generate -retries 0", 10*time.Second); code != exitError || out != "" {
+		t.Errorf("expected the failed download to be an error, got %d and %q", code, out)
+	}
+}
+
+// TestErrors will check errors are written to stderr rather than with the
+// results, the file given to -o is left as it was when a command fails
+// before writing any results, and check exits like a query when none of the
+// visits were exposed.
+func TestErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covid-check-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "act.csv"), []byte(`1,"New","ALDI Belconnen","Westfield Belconnen, Benjamin Way","Belconnen","ACT","04/10/2021 - Monday",7:00pm,7:30pm,"Casual"`+"\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "visits.json"), []byte(`[{"place": "ALDI Belconnen", "suburb": "Belconnen", "date": "04/10/2021", "start": "7:00pm", "end": "7:15pm"}]`), 0644)
+
+	for _, args := range []string{
+		"-file missing.csv",
+		"check -file act.csv",
+		"show -file act.csv nowhere",
+	} {
+		if out, code := runCLI(t, dir, args, 3*time.Second); out != "" || code == exitMatches {
+			t.Errorf("expected %q to fail without writing to stdout, got %d and %q", args, code, out)
+		}
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "elsewhere.json"), []byte(`[{"place": "Coles Kaleen", "suburb": "Kaleen", "date": "04/10/2021", "start": "7:00pm", "end": "7:15pm"}]`), 0644)
+	for visits, expected := range map[string]int{"visits.json": exitMatches, "elsewhere.json": exitNoMatches} {
+		if _, code := runCLI(t, dir, "check -file act.csv "+visits, 3*time.Second); code != expected {
+			t.Errorf("expected checking %s to exit with %d, got %d", visits, expected, code)
+		}
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "out.csv"), []byte("previous results\n"), 0644)
+	if out, code := runCLI(t, dir, "-file missing.csv -o out.csv", 3*time.Second); code != exitError || out != "" {
+		t.Errorf("expected the missing file to fail, got %d and %q", code, out)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "out.csv")); string(data) != "previous results\n" {
+		t.Errorf("expected -o to be left as it was, got %q", data)
+	}
+	if out, code := runCLI(t, dir, "-file act.csv -o out.csv", 3*time.Second); code != exitMatches || out != "" {
+		t.Errorf("expected the results to be written to -o, got %d and %q", code, out)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "out.csv")); !strings.Contains(string(data), "ALDI Belconnen") {
+		t.Errorf("expected the results in -o, got %q", data)
+	}
+}
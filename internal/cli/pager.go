@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// pagerCommand will return the command line of the pager, which is $PAGER
+// or less, or more on Windows.
+func pagerCommand() []string {
+	if pager := strings.Fields(os.Getenv("PAGER")); len(pager) > 0 {
+		return pager
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"more"}
+	}
+	return []string{"less"}
+}
+
+// page will write the output to stdout, through the pager when it has more
+// lines than the terminal has rows. The output is written directly when the
+// pager can't be started.
+func page(output *bytes.Buffer, rows int) {
+	if bytes.Count(output.Bytes(), []byte("\n")) < rows {
+		os.Stdout.Write(output.Bytes())
+		return
+	}
+
+	data := output.Bytes()
+	pager := pagerCommand()
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Unless LESS is already set, less exits when the output fits on the
+	// screen after all, leaves it there once quit, and keeps its colours.
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Run(); err != nil && cmd.ProcessState == nil {
+		os.Stdout.Write(data)
+	}
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/fubarhouse/covid-check/v2/covidcheck"
+)
+
+// serviceUsage is the usage of the service subcommand.
+const serviceUsage = "usage: covid-check service install|uninstall|start [watch flags]"
+
+// serviceCommand will install, uninstall or start a service running the
+// watch subcommand with the flags given after the action, as a systemd user
+// unit on Linux, a launchd agent on macOS or a scheduled task on Windows.
+func serviceCommand(args []string) {
+	if len(args) == 0 {
+		fail(errors.New(serviceUsage))
+		return
+	}
+	action, flags := args[0], args[1:]
+
+	// The flags are checked now, rather than when the service first fails.
+	c, _ := findCommand("watch")
+	fs := c.newFlagSet()
+	fs.Init("covid-check service "+action, flag.ContinueOnError)
+	if err := fs.Parse(flags); err != nil {
+		exitCode = exitError
+		return
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.Abs(executable)
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+	s := &covidcheck.Service{
+		Name:       "covid-check",
+		Label:      "com.github.fubarhouse.covid-check",
+		Executable: executable,
+		Args:       append([]string{"watch"}, flags...),
+	}
+
+	switch action {
+	case "install":
+		err = installService(s)
+	case "uninstall":
+		err = uninstallService(s)
+	case "start":
+		err = startService(s)
+	default:
+		fail(errors.New(serviceUsage))
+		return
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// servicePath will return the path of the systemd unit or launchd agent of
+// the service.
+func servicePath(s *covidcheck.Service) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "LaunchAgents", s.Label+".plist"), nil
+	case "windows":
+		return "", nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user", s.Name+".service"), nil
+}
+
+// installService will write and enable the service.
+func installService(s *covidcheck.Service) error {
+	if runtime.GOOS == "windows" {
+		if err := run("schtasks", "/Create", "/F", "/TN", s.Name, "/SC", "ONLOGON", "/TR", s.CommandLine()); err != nil {
+			return err
+		}
+		fmt.Printf("installed the %s scheduled task\n", s.Name)
+		return nil
+	}
+
+	path, err := servicePath(s)
+	if err != nil {
+		return err
+	}
+	content := s.SystemdUnit()
+	if runtime.GOOS == "darwin" {
+		content = s.LaunchdPlist()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "darwin" {
+		err = run("launchctl", "load", "-w", path)
+	} else if err = run("systemctl", "--user", "daemon-reload"); err == nil {
+		err = run("systemctl", "--user", "enable", s.Name)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("installed %s\n", path)
+	return nil
+}
+
+// uninstallService will stop, disable and remove the service.
+func uninstallService(s *covidcheck.Service) error {
+	if runtime.GOOS == "windows" {
+		return run("schtasks", "/Delete", "/F", "/TN", s.Name)
+	}
+
+	path, err := servicePath(s)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "darwin" {
+		err = run("launchctl", "unload", "-w", path)
+	} else {
+		err = run("systemctl", "--user", "disable", "--now", s.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if runtime.GOOS != "darwin" {
+		return run("systemctl", "--user", "daemon-reload")
+	}
+	return nil
+}
+
+// startService will start the installed service.
+func startService(s *covidcheck.Service) error {
+	switch runtime.GOOS {
+	case "windows":
+		return run("schtasks", "/Run", "/TN", s.Name)
+	case "darwin":
+		return run("launchctl", "start", s.Label)
+	}
+	return run("systemctl", "--user", "start", s.Name)
+}
+
+// run will run a command, showing its output.
+func run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %s", name, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package cli
+
+import (
+	"os"
+)
+
+// terminalRows will return 0, as the size of the terminal is only known on
+// Linux and macOS, so output isn't paged elsewhere.
+func terminalRows(f *os.File) int {
+	return 0
+}
+
+// isTerminal will check whether the file is a character device, such as a
+// console, rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
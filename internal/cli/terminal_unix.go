@@ -0,0 +1,34 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package cli
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// winsize will read the size of the terminal the file is open on, failing
+// when it isn't a terminal.
+func winsize(f *os.File) (rows int, ok bool) {
+	var size struct{ Rows, Cols, Width, Height uint16 }
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size))); errno != 0 {
+		return 0, false
+	}
+	return int(size.Rows), true
+}
+
+// isTerminal will check whether the file is open on a terminal, even one
+// without a known size.
+func isTerminal(f *os.File) bool {
+	_, ok := winsize(f)
+	return ok
+}
+
+// terminalRows will return the number of rows of the terminal the file is
+// open on, or 0 when it isn't a terminal.
+func terminalRows(f *os.File) int {
+	rows, _ := winsize(f)
+	return rows
+}